@@ -0,0 +1,84 @@
+package simultaneous
+
+// WithAccumulatingReservation keeps large requests from AcquireN from
+// being starved by a steady stream of smaller ones. Without it, a waiter
+// that wants more space than is available is passed by smaller requests
+// that fit, so the space it needs may never all be free at once. With
+// it, once such a waiter is at the head of the queue, space is held for
+// it as it is released, rather than being granted to those behind it or
+// to new arrivals, until it has enough.
+//
+// Unlike WithFIFO, a waiter at the head that cannot be granted space
+// because its Child is full does not hold up others, and new arrivals
+// may still take space without waiting while nobody is waiting. New
+// arrivals always take the lock, so they never see space that is being
+// released before it has been held for the waiter.
+//
+// Only one waiter has space held for it at a time: if a waiter with a
+// higher priority arrives, the space is handed to it instead. Space held
+// for a waiter counts as in use in Stats. It is given back if the waiter
+// gives up. It has no effect with WithFairShare or when WithLIFO
+// applies.
+//
+// WithAccumulatingReservation changes the Limit and all of its copies.
+// It returns the Limit so that it can be chained with New.
+func (l *Limit[T]) WithAccumulatingReservation() *Limit[T] {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	l.core.accumulate = true
+	l.core.updateSlow()
+	return l
+}
+
+// WithAccumulatingReservation holds space for the waiter at the head of
+// the queue. See the WithAccumulatingReservation method.
+func WithAccumulatingReservation() Option {
+	return func(s *state) {
+		s.core.accumulate = true
+		s.core.updateSlow()
+	}
+}
+
+// hold takes the available space for a waiter at the head of the queue
+// that did not fit. It returns false, and takes nothing, if the waiter
+// would not fit in its Child anyway. Must be called with the lock held.
+func (c *core) hold(w *waiter) bool {
+	if !fitsSub(w.n, w.sub) {
+		return false
+	}
+	if c.holding != nil && c.holding != w {
+		c.unhold(c.holding)
+	}
+	c.holding = w
+	size := c.sizeNow()
+	unavailable := c.unavailable(w.class)
+	for {
+		used := c.used.Load()
+		take := size - used - unavailable
+		if take > w.n-w.held {
+			take = w.n - w.held
+		}
+		if take <= 0 {
+			return true
+		}
+		if c.used.CompareAndSwap(used, used+take) {
+			w.held += take
+			return true
+		}
+	}
+}
+
+// unhold gives back the space held for a waiter that is leaving the
+// queue or is no longer at its head. Must be called with the lock held.
+func (c *core) unhold(w *waiter) {
+	if c.holding == w {
+		c.holding = nil
+	}
+	if w.held == 0 {
+		return
+	}
+	c.used.Add(-w.held)
+	w.held = 0
+	c.afterBurstChange()
+	c.checkIdle()
+}
@@ -0,0 +1,100 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestAccumulatingReservation(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](4, simultaneous.WithAccumulatingReservation())
+	ctx := context.Background()
+	small := []simultaneous.Limited[any]{limit.Forever(ctx), limit.Forever(ctx), limit.Forever(ctx)}
+
+	big := make(chan simultaneous.Limited[any])
+	go func() {
+		done, err := limit.AcquireN(ctx, 4)
+		assert.NoError(t, err)
+		big <- done
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+
+	// the free unit and the released ones are held for the big request
+	_, ok := limit.TryAcquireN(1)
+	assert.False(t, ok, "free space is held")
+	small[0].Done()
+	_, ok = limit.TryAcquireN(1)
+	assert.False(t, ok, "released space is held")
+	assert.Equal(t, 4, limit.InUse(), "held space counts as in use")
+
+	small[1].Done()
+	small[2].Done()
+	done := <-big
+	assert.Equal(t, 4, limit.InUse())
+	done.Done()
+	assert.Equal(t, 0, limit.InUse())
+}
+
+func TestAccumulatingReservationGiveUp(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](4).WithAccumulatingReservation()
+	held := limit.Forever(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error)
+	go func() {
+		_, err := limit.AcquireN(ctx, 4)
+		result <- err
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 4, limit.InUse())
+	cancel()
+	assert.ErrorIs(t, <-result, context.Canceled)
+	assert.Equal(t, 1, limit.InUse(), "held space given back")
+	held.Done()
+	require.NoError(t, limit.WaitForIdle(context.Background()))
+}
+
+func TestAccumulatingReservationStream(t *testing.T) {
+	t.Parallel()
+
+	const size = 4
+	limit := simultaneous.New[any](size, simultaneous.WithAccumulatingReservation())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// a steady stream of small requests that always keeps some space busy
+	var wg sync.WaitGroup
+	for i := 0; i < size; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				done, err := limit.Acquire(ctx)
+				if err != nil {
+					return
+				}
+				time.Sleep(100 * time.Microsecond)
+				done.Done()
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	bigCtx, bigCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer bigCancel()
+	done, err := limit.AcquireN(bigCtx, size)
+	require.NoError(t, err, "the large request completes")
+	done.Done()
+	cancel()
+	wg.Wait()
+}
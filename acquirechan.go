@@ -0,0 +1,45 @@
+package simultaneous
+
+import (
+	"context"
+)
+
+// AcquireChan waits for space in the background and delivers it on the
+// returned channel so that the wait can be part of a select with other
+// channels:
+//
+//	ctx, cancel := context.WithCancel(ctx)
+//	defer cancel()
+//	select {
+//	case done, ok := <-limit.AcquireChan(ctx):
+//		if !ok {
+//			return ctx.Err()
+//		}
+//		defer done.Done()
+//		...
+//	case <-shutdown:
+//		return nil
+//	}
+//
+// The channel is unbuffered: space is only handed over when it is
+// received. Cancelling the context gives up on the wait and releases
+// space that has been obtained but not yet received. The context must be
+// cancelled if the channel will not be received from, or a goroutine and
+// possibly some space will be held forever. If the space cannot be
+// obtained, the channel is closed without sending anything.
+func (l *Limit[T]) AcquireChan(ctx context.Context) <-chan Limited[T] {
+	c := make(chan Limited[T])
+	go func() {
+		defer close(c)
+		done, err := l.Acquire(ctx)
+		if err != nil {
+			return
+		}
+		select {
+		case c <- done:
+		case <-ctx.Done():
+			done.Done()
+		}
+	}()
+	return c
+}
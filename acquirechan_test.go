@@ -0,0 +1,63 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestAcquireChan(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done, ok := <-limit.AcquireChan(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, 1, limit.InUse())
+
+	other := make(chan struct{})
+	c := limit.AcquireChan(ctx)
+	go close(other)
+	select {
+	case <-c:
+		t.Fatal("limit is full")
+	case <-other:
+	}
+
+	done.Done()
+	select {
+	case done, ok = <-c:
+		assert.True(t, ok)
+		done.Done()
+	case <-time.After(time.Second):
+		t.Fatal("space was not delivered")
+	}
+	assert.Equal(t, 0, limit.InUse())
+}
+
+func TestAcquireChanCancel(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	held := limit.Forever(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	c := limit.AcquireChan(ctx)
+	assert.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	_, ok := <-c
+	assert.False(t, ok, "closed without space")
+	held.Done()
+
+	// space obtained but never received is released by cancelling
+	ctx, cancel = context.WithCancel(context.Background())
+	_ = limit.AcquireChan(ctx)
+	assert.Eventually(t, func() bool { return limit.InUse() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	assert.Eventually(t, func() bool { return limit.InUse() == 0 }, time.Second, time.Millisecond)
+}
@@ -0,0 +1,282 @@
+package simultaneous
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// AdaptiveLimit is a Limit whose capacity is adjusted automatically from
+// the outcome of the work done while holding space. Each holder reports
+// how long its work took and whether it failed, and an AdaptiveAlgorithm
+// turns those reports into a new capacity. This suits a downstream whose
+// capacity varies so that any fixed limit would be wrong.
+//
+// Forever, Acquire, and Timeout return an AdaptiveLimited so that the
+// outcome can be reported.
+type AdaptiveLimit[T any] struct {
+	limit     *Limit[T]
+	lock      sync.Mutex
+	algorithm AdaptiveAlgorithm
+	estimate  float64
+	min       float64
+	max       float64
+}
+
+// AdaptiveLimited is a Limited from an AdaptiveLimit
+type AdaptiveLimited[T any] interface {
+	Limited[T]
+	// Report provides the outcome of the work done while holding the
+	// space. Only the first call counts. Report may be called before
+	// or after Done. Space that is released without a report does not
+	// change the capacity.
+	Report(latency time.Duration, err error)
+}
+
+// AdaptiveSample is the outcome of one piece of work
+type AdaptiveSample struct {
+	Latency  time.Duration
+	Err      error
+	InFlight int // units of space in use, including this one, when the space was obtained
+}
+
+// AdaptiveAlgorithm computes a new capacity from the current one and a
+// sample. Update calls are serialized so an algorithm may keep state.
+type AdaptiveAlgorithm interface {
+	Update(limit float64, sample AdaptiveSample) float64
+}
+
+// NewAdaptive creates an AdaptiveLimit with an initial capacity. The
+// capacity will never go below one. Use WithBounds to change that.
+func NewAdaptive[T any](initial int, algorithm AdaptiveAlgorithm, opts ...Option) *AdaptiveLimit[T] {
+	return &AdaptiveLimit[T]{
+		limit:     New[T](initial, opts...),
+		algorithm: algorithm,
+		estimate:  float64(initial),
+		min:       1,
+		max:       math.Inf(1),
+	}
+}
+
+// WithBounds keeps the capacity between min and max. A max of zero means
+// no maximum. It returns the AdaptiveLimit so that it can be chained with
+// NewAdaptive.
+func (a *AdaptiveLimit[T]) WithBounds(min, max int) *AdaptiveLimit[T] {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.min = float64(min)
+	if max > 0 {
+		a.max = float64(max)
+	} else {
+		a.max = math.Inf(1)
+	}
+	a.set(a.estimate)
+	return a
+}
+
+// SetLimit changes the capacity of the AdaptiveLimit. The algorithm
+// continues to adjust the capacity from there.
+func (a *AdaptiveLimit[T]) SetLimit(n int) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.set(float64(n))
+}
+
+// Limit returns the current capacity of the AdaptiveLimit
+func (a *AdaptiveLimit[T]) Limit() int { return a.limit.Limit() }
+
+// Name returns the name given by WithName
+func (a *AdaptiveLimit[T]) Name() string { return a.limit.Name() }
+
+// Stats is like Limit.Stats
+func (a *AdaptiveLimit[T]) Stats() Stats { return a.limit.Stats() }
+
+// InUse is like Limit.InUse
+func (a *AdaptiveLimit[T]) InUse() int { return a.limit.InUse() }
+
+// Waiting is like Limit.Waiting
+func (a *AdaptiveLimit[T]) Waiting() int { return a.limit.Waiting() }
+
+// Holders is like Limit.Holders
+func (a *AdaptiveLimit[T]) Holders() []HolderInfo { return a.limit.Holders() }
+
+// Base returns the underlying Limit. Space obtained from it counts
+// against the AdaptiveLimit but cannot be reported. Calling SetLimit on
+// the base is overridden by the next report.
+func (a *AdaptiveLimit[T]) Base() *Limit[T] { return a.limit }
+
+var _ Named = &AdaptiveLimit[any]{}
+
+// Forever is like Limit.Forever
+func (a *AdaptiveLimit[T]) Forever(ctx context.Context) AdaptiveLimited[T] {
+	return a.wrap(a.limit.Forever(ctx))
+}
+
+// Acquire is like Limit.Acquire
+func (a *AdaptiveLimit[T]) Acquire(ctx context.Context) (AdaptiveLimited[T], error) {
+	done, err := a.limit.Acquire(ctx)
+	return a.wrap(done), err
+}
+
+// Timeout is like Limit.Timeout
+func (a *AdaptiveLimit[T]) Timeout(ctx context.Context, timeout time.Duration) (AdaptiveLimited[T], error) {
+	done, err := a.limit.Timeout(ctx, timeout)
+	return a.wrap(done), err
+}
+
+func (a *AdaptiveLimit[T]) wrap(done Limited[T]) AdaptiveLimited[T] {
+	t := &adaptiveToken[T]{
+		Limited: done,
+	}
+	if _, ok := done.(*token[T]); ok {
+		t.adaptive = a
+		t.inFlight = a.InUse()
+	}
+	return t
+}
+
+func (a *AdaptiveLimit[T]) update(sample AdaptiveSample) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.set(a.algorithm.Update(a.estimate, sample))
+}
+
+// set must be called with the lock held
+func (a *AdaptiveLimit[T]) set(estimate float64) {
+	if estimate < a.min {
+		estimate = a.min
+	}
+	if estimate > a.max {
+		estimate = a.max
+	}
+	a.estimate = estimate
+	if n := int(estimate); n != a.limit.Limit() {
+		a.limit.SetLimit(n)
+	}
+}
+
+type adaptiveToken[T any] struct {
+	Limited[T]
+	lock     sync.Mutex
+	adaptive *AdaptiveLimit[T] // nil if no space was obtained or already reported
+	inFlight int
+}
+
+func (t *adaptiveToken[T]) Report(latency time.Duration, err error) {
+	t.lock.Lock()
+	a := t.adaptive
+	t.adaptive = nil
+	t.lock.Unlock()
+	if a == nil {
+		return
+	}
+	a.update(AdaptiveSample{
+		Latency:  latency,
+		Err:      err,
+		InFlight: t.inFlight,
+	})
+}
+
+// AIMD is an AdaptiveAlgorithm that grows the capacity slowly while work
+// succeeds and cuts it sharply when work fails or is too slow, like TCP
+// congestion control.
+type AIMD struct {
+	// Increase is added to the capacity over each capacity's worth of
+	// successful samples. Zero means one.
+	Increase float64
+	// Backoff multiplies the capacity on failure. Zero means 0.9.
+	Backoff float64
+	// Timeout, if not zero, counts samples slower than this as failures
+	Timeout time.Duration
+}
+
+var _ AdaptiveAlgorithm = AIMD{}
+
+func (a AIMD) Update(limit float64, sample AdaptiveSample) float64 {
+	if sample.Err != nil || (a.Timeout > 0 && sample.Latency > a.Timeout) {
+		backoff := a.Backoff
+		if backoff == 0 {
+			backoff = 0.9
+		}
+		return limit * backoff
+	}
+	if float64(sample.InFlight)*2 < limit {
+		// the capacity isn't what is limiting the work
+		return limit
+	}
+	increase := a.Increase
+	if increase == 0 {
+		increase = 1
+	}
+	return limit + increase/limit
+}
+
+// Gradient is an AdaptiveAlgorithm that compares recent latency to the
+// long term latency. When recent latency rises above the long term
+// latency, the downstream is queueing and the capacity is reduced in
+// proportion. Otherwise the capacity grows by a small amount of queueing.
+// Failures reduce the capacity like AIMD.
+//
+// A Gradient keeps state and must not be shared by AdaptiveLimits.
+type Gradient struct {
+	// Tolerance is how much recent latency may exceed the long term
+	// latency before the capacity is reduced. Zero means 1.5.
+	Tolerance float64
+	// Smoothing is the fraction of each change that is applied. Zero
+	// means 0.2.
+	Smoothing float64
+	// Window is the number of samples averaged for the long term
+	// latency. Zero means 600.
+	Window int
+	// Queue returns how much the capacity may grow beyond what the
+	// latency gradient supports. Nil means the square root of the
+	// capacity.
+	Queue func(limit float64) float64
+
+	long float64
+}
+
+var _ AdaptiveAlgorithm = &Gradient{}
+
+func (g *Gradient) Update(limit float64, sample AdaptiveSample) float64 {
+	if sample.Err != nil {
+		return limit * 0.9
+	}
+	short := float64(sample.Latency)
+	if short <= 0 {
+		return limit
+	}
+	window := g.Window
+	if window == 0 {
+		window = 600
+	}
+	if g.long == 0 {
+		g.long = short
+	} else {
+		alpha := 2 / float64(window+1)
+		g.long = g.long*(1-alpha) + short*alpha
+	}
+	if g.long/short > 2 {
+		// latency has dropped a lot: let the long term catch up
+		g.long *= 0.95
+	}
+	if float64(sample.InFlight)*2 < limit {
+		return limit
+	}
+	tolerance := g.Tolerance
+	if tolerance == 0 {
+		tolerance = 1.5
+	}
+	smoothing := g.Smoothing
+	if smoothing == 0 {
+		smoothing = 0.2
+	}
+	queue := math.Sqrt(limit)
+	if g.Queue != nil {
+		queue = g.Queue(limit)
+	}
+	gradient := math.Max(0.5, math.Min(1, tolerance*g.long/short))
+	target := limit*gradient + queue
+	return limit*(1-smoothing) + target*smoothing
+}
@@ -0,0 +1,99 @@
+package simultaneous_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestAdaptiveAIMD(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.NewAdaptive[any](10, simultaneous.AIMD{Timeout: time.Second}).WithBounds(2, 12)
+	var _ simultaneous.Named = limit
+
+	for i := 0; i < 5; i++ {
+		done := limit.Forever(context.Background())
+		done.Report(time.Millisecond, fmt.Errorf("failed"))
+		done.Report(time.Millisecond, fmt.Errorf("only the first report counts"))
+		done.Done()
+	}
+	assert.Equal(t, 5, limit.Limit(), "0.9^5 * 10")
+
+	for i := 0; i < 2; i++ {
+		done := limit.Forever(context.Background())
+		done.Report(2*time.Second, nil)
+		done.Done()
+	}
+	assert.Equal(t, 4, limit.Limit(), "slow counts as failure")
+
+	for i := 0; i < 20; i++ {
+		done := limit.Forever(context.Background())
+		done.Report(time.Millisecond, fmt.Errorf("failed"))
+		done.Done()
+	}
+	assert.Equal(t, 2, limit.Limit(), "min")
+
+	for i := 0; i < 200; i++ {
+		held := make([]simultaneous.AdaptiveLimited[any], limit.Limit())
+		for j := range held {
+			held[j] = limit.Forever(context.Background())
+		}
+		for _, done := range held {
+			done.Report(time.Millisecond, nil)
+			done.Done()
+		}
+	}
+	assert.Equal(t, 12, limit.Limit(), "max")
+
+	limit.SetLimit(3)
+	assert.Equal(t, 3, limit.Limit())
+}
+
+func TestAdaptiveNotHeld(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.NewAdaptive[any](1, simultaneous.AIMD{})
+	held, err := limit.Acquire(context.Background())
+	require.NoError(t, err)
+
+	done, err := limit.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+	done.Report(0, fmt.Errorf("failed"))
+	done.Done()
+	assert.Equal(t, 1, limit.Limit())
+	assert.Equal(t, 1, limit.InUse())
+	held.Done()
+}
+
+func TestAdaptiveGradient(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.NewAdaptive[any](10, &simultaneous.Gradient{}).WithBounds(1, 100)
+	run := func(latency time.Duration) {
+		held := make([]simultaneous.AdaptiveLimited[any], limit.Limit())
+		for j := range held {
+			held[j] = limit.Forever(context.Background())
+		}
+		for _, done := range held {
+			done.Report(latency, nil)
+			done.Done()
+		}
+	}
+	for i := 0; i < 10; i++ {
+		run(10 * time.Millisecond)
+	}
+	grown := limit.Limit()
+	assert.Greater(t, grown, 10, "steady latency grows the limit")
+
+	for i := 0; i < 10; i++ {
+		run(100 * time.Millisecond)
+	}
+	assert.Less(t, limit.Limit(), grown, "rising latency shrinks the limit")
+}
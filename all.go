@@ -0,0 +1,87 @@
+package simultaneous
+
+import (
+	"context"
+	"sort"
+)
+
+// Acquirer is implemented by *Limit[T] for any T. It is what AcquireAll
+// takes.
+type Acquirer interface {
+	acquireForAll(ctx context.Context) (func(), error)
+	allOrder() (id uint64, depth int)
+}
+
+var _ Acquirer = &Limit[any]{}
+
+// AllLimited is the space obtained by AcquireAll
+type AllLimited struct {
+	done []func()
+}
+
+// Done releases the space in all of the limits
+func (a *AllLimited) Done() {
+	done := a.done
+	a.done = nil
+	for i := len(done) - 1; i >= 0; i-- {
+		done[i]()
+	}
+}
+
+// AcquireAll waits for space in each of the limits. The limits may be of
+// different types. The limits are always acquired in the same order,
+// regardless of the order they are passed in, so that callers that need
+// overlapping sets of limits cannot deadlock each other. A Child is
+// acquired before its parent.
+//
+// If the context is cancelled before all of the space is obtained, the
+// space that was obtained is released and an error wrapping ctx.Err() is
+// returned.
+//
+//	all, err := simultaneous.AcquireAll(ctx, perHost, global)
+//	if err != nil {
+//		return err
+//	}
+//	defer all.Done()
+func AcquireAll(ctx context.Context, limits ...Acquirer) (*AllLimited, error) {
+	ordered := make([]Acquirer, len(limits))
+	copy(ordered, limits)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		iID, iDepth := ordered[i].allOrder()
+		jID, jDepth := ordered[j].allOrder()
+		if iID != jID {
+			return iID < jID
+		}
+		return iDepth > jDepth
+	})
+	all := &AllLimited{
+		done: make([]func(), 0, len(ordered)),
+	}
+	for _, l := range ordered {
+		done, err := l.acquireForAll(ctx)
+		if err != nil {
+			all.Done()
+			return all, err
+		}
+		all.done = append(all.done, done)
+	}
+	return all, nil
+}
+
+func (l *Limit[T]) acquireForAll(ctx context.Context) (func(), error) {
+	done, err := l.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return done.Done, nil
+}
+
+// allOrder returns the order for AcquireAll: by core and then children
+// before parents
+func (l *Limit[T]) allOrder() (uint64, int) {
+	var depth int
+	for s := l.sub; s != nil; s = s.parent {
+		depth++
+	}
+	return l.core.id, depth
+}
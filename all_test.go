@@ -0,0 +1,83 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+type (
+	hostLimit   struct{}
+	globalLimit struct{}
+)
+
+func TestAcquireAll(t *testing.T) {
+	t.Parallel()
+
+	host := simultaneous.New[hostLimit](1)
+	global := simultaneous.New[globalLimit](2)
+
+	// opposite orders would deadlock without a canonical order
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			all, err := simultaneous.AcquireAll(context.Background(), host, global)
+			if assert.NoError(t, err) {
+				all.Done()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			all, err := simultaneous.AcquireAll(context.Background(), global, host)
+			if assert.NoError(t, err) {
+				all.Done()
+			}
+		}()
+	}
+	wg.Wait()
+
+	all, err := simultaneous.AcquireAll(context.Background(), host, global)
+	require.NoError(t, err)
+	assert.Equal(t, 1, host.InUse())
+	assert.Equal(t, 1, global.InUse())
+	all.Done()
+	all.Done()
+	assert.Equal(t, 0, host.InUse())
+	assert.Equal(t, 0, global.InUse())
+}
+
+func TestAcquireAllCancel(t *testing.T) {
+	t.Parallel()
+
+	first := simultaneous.New[any](1)
+	second := simultaneous.New[any](1)
+	held := second.Forever(context.Background())
+	defer held.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := simultaneous.AcquireAll(ctx, second, first)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, first.InUse(), "released")
+}
+
+func TestAcquireAllChild(t *testing.T) {
+	t.Parallel()
+
+	parent := simultaneous.New[any](2)
+	child := parent.Child(1)
+	all, err := simultaneous.AcquireAll(context.Background(), parent, child)
+	require.NoError(t, err)
+	assert.Equal(t, 2, parent.InUse())
+	assert.Equal(t, 1, child.InUse())
+	all.Done()
+	assert.Equal(t, 0, parent.InUse())
+}
@@ -0,0 +1,81 @@
+//go:build go1.21
+
+package simultaneous
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// AcquireAutoRelease waits for space in the Limit, like Forever2, and then
+// arranges for the space to be released when the context ends. Calling Done
+// before then releases the space immediately and stops watching the context.
+// An error wrapping ctx.Err() is returned if the context is cancelled before
+// space becomes available.
+//
+// AcquireAutoRelease uses context.AfterFunc and thus requires go1.21.
+func (l *Limit[T]) AcquireAutoRelease(ctx context.Context) (Limited[T], error) {
+	done, _, err := l.Forever2(ctx)
+	if err != nil {
+		return done, err
+	}
+	a := &autoRelease[T]{
+		inner: done,
+	}
+	a.stop = context.AfterFunc(ctx, a.release)
+	return a, nil
+}
+
+type autoRelease[T any] struct {
+	lock     sync.Mutex
+	inner    Limited[T]
+	stop     func() bool
+	released bool // so that Done after the context ends is not misuse
+}
+
+var _ Limited[any] = &autoRelease[any]{}
+
+func (a *autoRelease[T]) privateMethod() {}
+
+func (a *autoRelease[T]) Done() {
+	a.stop()
+	a.release()
+}
+
+func (a *autoRelease[T]) WaitDuration() time.Duration {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.inner.WaitDuration()
+}
+
+func (a *autoRelease[T]) ShouldYield() bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return !a.released && a.inner.ShouldYield()
+}
+
+func (a *autoRelease[T]) Yield(ctx context.Context) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.inner.Yield(ctx)
+}
+
+// Transfer stops watching the context: the space carried by the
+// ticket is no longer released when the context ends.
+func (a *autoRelease[T]) Transfer() *TransferTicket[T] {
+	a.stop()
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.inner.Transfer()
+}
+
+func (a *autoRelease[T]) release() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.released {
+		return
+	}
+	a.released = true
+	a.inner.Done()
+}
@@ -0,0 +1,64 @@
+//go:build go1.21
+
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestAcquireAutoRelease(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := limit.AcquireAutoRelease(ctx)
+	require.NoError(t, err)
+
+	_, err = limit.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "held")
+
+	cancel()
+	done, err := limit.Timeout(context.Background(), time.Second)
+	require.NoError(t, err, "released when the context ended")
+	done.Done()
+}
+
+func TestAcquireAutoReleaseExplicitDone(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	ctx, cancel := context.WithCancel(context.Background())
+	auto, err := limit.AcquireAutoRelease(ctx)
+	require.NoError(t, err)
+	other := limit.Forever(context.Background())
+
+	auto.Done()
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+
+	done, err := limit.Timeout(context.Background(), 0)
+	require.NoError(t, err)
+	_, err = limit.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "not released twice")
+	done.Done()
+	other.Done()
+}
+
+func TestAcquireAutoReleaseCancelled(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	defer limit.Forever(context.Background()).Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := limit.AcquireAutoRelease(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
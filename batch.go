@@ -0,0 +1,60 @@
+package simultaneous
+
+import (
+	"context"
+)
+
+// Batch holds space in a Limit across a series of tasks. Unlike
+// Limited.Yield, Batch.Yield only gives up the space when someone else
+// is waiting for it, so a long-running holder can check in between
+// tasks without paying to release and reacquire when the Limit is not
+// busy.
+//
+// Batch is a Limited so it can be passed wherever an Enforced is needed
+// and must be released with Done.
+type Batch[T any] struct {
+	Limited[T]
+	limit *Limit[T]
+}
+
+// Batch waits for space in the Limit (or for the context to be
+// cancelled) and returns a Batch that holds it. If the context is
+// cancelled first, an error wrapping ctx.Err() is returned.
+func (l *Limit[T]) Batch(ctx context.Context) (*Batch[T], error) {
+	done, err := l.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Batch[T]{
+		Limited: done,
+		limit:   l,
+	}, nil
+}
+
+// Yield is a checkpoint between tasks. If others are waiting for space
+// in the Limit, it releases the space and waits to get it back. If the
+// context is cancelled before then, an error wrapping ctx.Err() is
+// returned and the Batch no longer holds space.
+func (b *Batch[T]) Yield(ctx context.Context) error {
+	if b.limit.core.waiting.Load() == 0 {
+		return nil
+	}
+	return b.Limited.Yield(ctx)
+}
+
+// Run runs each of the tasks in turn, calling Yield in between. It stops
+// at the first error, from a task or from Yield, and returns it. The
+// space is still held when Run returns unless Yield failed.
+func (b *Batch[T]) Run(ctx context.Context, tasks ...func(Enforced[T]) error) error {
+	for i, task := range tasks {
+		if i > 0 {
+			if err := b.Yield(ctx); err != nil {
+				return err
+			}
+		}
+		if err := task(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestBatchYield(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	ctx := context.Background()
+	batch, err := limit.Batch(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, batch.Yield(ctx))
+	assert.Equal(t, uint64(1), limit.Stats().Acquisitions, "nobody waiting so the space was kept")
+
+	var order []string
+	waiter := make(chan struct{})
+	go func() {
+		defer close(waiter)
+		done := limit.Forever(ctx)
+		order = append(order, "waiter")
+		done.Done()
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+
+	err = batch.Run(ctx,
+		func(simultaneous.Enforced[any]) error {
+			order = append(order, "first")
+			return nil
+		},
+		func(simultaneous.Enforced[any]) error {
+			order = append(order, "second")
+			return nil
+		},
+	)
+	require.NoError(t, err)
+	<-waiter
+	assert.Equal(t, []string{"first", "waiter", "second"}, order)
+	assert.Equal(t, 1, limit.InUse())
+	batch.Done()
+	assert.Zero(t, limit.InUse())
+}
+
+func TestBatchCancelled(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	held := limit.Forever(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := limit.Batch(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	held.Done()
+}
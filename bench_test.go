@@ -0,0 +1,121 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func BenchmarkForever(b *testing.B) {
+	limit := simultaneous.New[any](10)
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		limit.Forever(ctx).Done()
+	}
+}
+
+func BenchmarkAcquire(b *testing.B) {
+	limit := simultaneous.New[any](10)
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		done, err := limit.Acquire(ctx)
+		if err != nil {
+			b.Fatal(err)
+		}
+		done.Done()
+	}
+}
+
+func BenchmarkTimeout(b *testing.B) {
+	limit := simultaneous.New[any](10)
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		done, err := limit.Timeout(ctx, time.Second)
+		if err != nil {
+			b.Fatal(err)
+		}
+		done.Done()
+	}
+}
+
+func BenchmarkTryBusy(b *testing.B) {
+	limit := simultaneous.New[any](1)
+	held, _ := limit.Try()
+	defer held.Done()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = limit.Try()
+	}
+}
+
+// BenchmarkParallel has every goroutine acquire and release a large
+// limit that is never full
+func BenchmarkParallel(b *testing.B) {
+	for _, bench := range []struct {
+		name  string
+		limit *simultaneous.Limit[any]
+	}{
+		{"single", simultaneous.New[any](10000)},
+		{"sharded", simultaneous.New[any](10000, simultaneous.WithShards(0))},
+	} {
+		limit := bench.limit
+		b.Run(bench.name, func(b *testing.B) {
+			ctx := context.Background()
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					limit.Forever(ctx).Done()
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkContended has many more goroutines than space so that most
+// acquisitions wait
+func BenchmarkContended(b *testing.B) {
+	limit := simultaneous.New[any](2)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.SetParallelism(8)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			limit.Forever(ctx).Done()
+		}
+	})
+}
+
+func BenchmarkSpawnForever(b *testing.B) {
+	limit := simultaneous.New[any](8)
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit.Forever(ctx).Done()
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkWorkers(b *testing.B) {
+	limit := simultaneous.New[any](8)
+	workers := simultaneous.NewWorkers(limit, 64)
+	ctx := context.Background()
+	task := func(simultaneous.Enforced[any]) {}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := workers.Submit(ctx, task); err != nil {
+			b.Fatal(err)
+		}
+	}
+	workers.Close()
+}
@@ -0,0 +1,25 @@
+package simultaneous
+
+import (
+	"context"
+)
+
+// WithBoost raises the capacity of the Limit by extra while fn runs, for
+// a known short burst such as warming a cache at startup. When fn
+// returns, or panics, the capacity is lowered by extra again the same
+// way SetLimit lowers it: space that is held stays held and no new space
+// is granted until the space in use is below the capacity. Changes made
+// with SetLimit while fn runs are kept.
+func (l *Limit[T]) WithBoost(extra int, fn func()) {
+	l.boost(int64(extra))
+	defer l.boost(-int64(extra))
+	fn()
+}
+
+func (l *Limit[T]) boost(delta int64) {
+	from := l.Capacity()
+	l.core.resizeBy(delta, l.sub)
+	if delta != 0 {
+		l.logEvent(context.Background(), logDebug, "simultaneous limit resize", "from", from)
+	}
+}
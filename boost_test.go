@@ -0,0 +1,57 @@
+package simultaneous_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestWithBoost(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	var during []simultaneous.Limited[any]
+	limit.WithBoost(3, func() {
+		assert.Equal(t, 5, limit.Limit(), "boosted")
+		during = fill(limit)
+		assert.Len(t, during, 5)
+	})
+	assert.Equal(t, 2, limit.Limit(), "restored")
+
+	// space taken during the boost stays held
+	assert.Equal(t, 5, limit.InUse())
+	release(during[:3])
+	_, ok := limit.TryAcquireN(1)
+	assert.False(t, ok, "not below the restored limit")
+	release(during[3:4])
+	done, ok := limit.TryAcquireN(1)
+	require.True(t, ok, "below the restored limit")
+	done.Done()
+	release(during[4:])
+}
+
+func TestWithBoostPanic(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	assert.Panics(t, func() {
+		limit.WithBoost(3, func() {
+			assert.Equal(t, 5, limit.Limit())
+			panic("burst failed")
+		})
+	})
+	assert.Equal(t, 2, limit.Limit(), "restored after a panic")
+}
+
+func TestWithBoostSetLimit(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	limit.WithBoost(3, func() {
+		limit.SetLimit(10)
+	})
+	assert.Equal(t, 7, limit.Limit(), "only the boost is taken away")
+}
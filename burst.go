@@ -0,0 +1,129 @@
+package simultaneous
+
+import (
+	"time"
+)
+
+// burstAllowance is configured by WithBurst. Except for the settings, it
+// is protected by the lock of the core.
+type burstAllowance struct {
+	extra     int64         // units allowed above the size while bursting
+	allowance time.Duration // time above the size allowed in each window
+	window    time.Duration
+
+	windowStart time.Time
+	spent       time.Duration // time above the size in this window
+	over        bool          // used was above the size at the last tick
+	since       time.Time     // last tick
+	reset       Timer         // grants at the start of the next window, if waiting
+}
+
+// WithBurst lets up to burst units more than the capacity of the Limit be
+// in use, but only for a total of allowance in each window. Once the
+// allowance is used up, the capacity is enforced again until the next
+// window starts; space that is already held is not taken back. Time is
+// counted whenever more than the capacity is in use. For example, with a
+// capacity of 10, WithBurst(5, time.Second, time.Minute) allows short
+// spikes to 15 but never more than a second of them each minute.
+//
+// Stats and Limit report the capacity without the burst. A burst of zero
+// removes it. WithBurst changes the Limit and all of its copies. It
+// returns the Limit so that it can be chained with New.
+func (l *Limit[T]) WithBurst(burst int, allowance, window time.Duration) *Limit[T] {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	l.core.setBurst(burst, allowance, window)
+	l.core.grant()
+	return l
+}
+
+// WithBurst allows bursts above the capacity. See the WithBurst method.
+func WithBurst(burst int, allowance, window time.Duration) Option {
+	return func(s *state) {
+		s.core.setBurst(burst, allowance, window)
+	}
+}
+
+// setBurst must be called with the lock held
+func (c *core) setBurst(burst int, allowance, window time.Duration) {
+	if old := c.burst.Load(); old != nil && old.reset != nil {
+		old.reset.Stop()
+	}
+	if burst <= 0 {
+		c.burst.Store(nil)
+	} else {
+		c.burst.Store(&burstAllowance{
+			extra:     int64(burst),
+			allowance: allowance,
+			window:    window,
+		})
+	}
+	c.updateSlow()
+}
+
+// sizeNow is the size of the core including any burst that is allowed
+// right now, or less while warming up. Must be called with the lock held
+// unless there is no burst or warm-up.
+func (c *core) sizeNow() int64 {
+	if w := c.warm.Load(); w != nil {
+		size := c.size.Load()
+		if n := c.warmSize(w, size); n < size {
+			return n
+		}
+	}
+	b := c.burst.Load()
+	if b == nil {
+		return c.size.Load()
+	}
+	c.tickBurst(b)
+	if b.spent >= b.allowance {
+		c.scheduleBurstReset(b)
+		return c.size.Load()
+	}
+	return c.size.Load() + b.extra
+}
+
+// tickBurst counts the time spent above the size since the last tick and
+// starts a new window when it is time. It must be called, with the lock
+// held, whenever used or the size changes.
+func (c *core) tickBurst(b *burstAllowance) {
+	now := c.now()
+	if b.windowStart.IsZero() {
+		// started lazily so that the clock set by WithClock is used
+		b.windowStart = now
+		b.since = now
+	}
+	if b.over {
+		b.spent += now.Sub(b.since)
+	}
+	if b.window > 0 && now.Sub(b.windowStart) >= b.window {
+		b.windowStart = now
+		b.spent = 0
+	}
+	b.since = now
+	b.over = c.used.Load() > c.size.Load()
+}
+
+// scheduleBurstReset arranges for waiters to be granted space once the
+// next window starts. Must be called with the lock held.
+func (c *core) scheduleBurstReset(b *burstAllowance) {
+	if b.reset != nil || b.window <= 0 || c.waiting.Load() == 0 {
+		return
+	}
+	b.reset = c.afterFunc(b.windowStart.Add(b.window).Sub(c.now()), func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		b.reset = nil
+		if c.burst.Load() == b {
+			c.grant()
+		}
+	})
+}
+
+// afterBurstChange must be called, with the lock held, after used
+// changes so that time above the size is counted from then
+func (c *core) afterBurstChange() {
+	if b := c.burst.Load(); b != nil {
+		c.tickBurst(b)
+	}
+}
@@ -0,0 +1,64 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestBurst(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	limit := simultaneous.New[any](1,
+		simultaneous.WithClock(clock),
+		simultaneous.WithBurst(1, time.Second, time.Minute))
+	ctx := context.Background()
+
+	a := limit.Forever(ctx)
+	b, err := limit.Timeout(ctx, 0)
+	require.NoError(t, err, "burst above the capacity")
+	_, err = limit.Timeout(ctx, 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "no more than the burst")
+	assert.Equal(t, 1, limit.Limit(), "capacity does not include the burst")
+	assert.Equal(t, 2, limit.InUse())
+
+	clock.Advance(2 * time.Second)
+	b.Done()
+	_, err = limit.Timeout(ctx, 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "allowance used up")
+
+	got := make(chan simultaneous.Limited[any])
+	go func() {
+		done, err := limit.Acquire(ctx)
+		assert.NoError(t, err)
+		got <- done
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 && clock.pending() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Minute)
+	b = <-got
+	assert.Equal(t, 2, limit.InUse(), "bursting again in the next window")
+
+	a.Done()
+	b.Done()
+	assert.Zero(t, limit.InUse())
+}
+
+func TestBurstRemoved(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1).WithBurst(1, time.Hour, time.Hour)
+	a := limit.Forever(context.Background())
+	b, err := limit.Timeout(context.Background(), 0)
+	require.NoError(t, err)
+	b.Done()
+	limit.WithBurst(0, 0, 0)
+	_, err = limit.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+	a.Done()
+}
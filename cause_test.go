@@ -0,0 +1,53 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/memsql/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestTimeoutContextCause(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	held := limit.Forever(context.Background())
+	defer held.Done()
+
+	const shutdown errors.String = "shutting down"
+	ctx, cancel := context.WithCancelCause(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel(shutdown.Errorf("parent gave up"))
+	}()
+	_, err := limit.Timeout(ctx, time.Hour)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, err, shutdown)
+	assert.NotErrorIs(t, err, simultaneous.ErrTimeout)
+}
+
+func TestTimeoutContextDeadlineSooner(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	held := limit.Forever(context.Background())
+	defer held.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := limit.Timeout(ctx, time.Hour)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), time.Minute)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, simultaneous.ErrTimeout)
+
+	_, err = limit.Timeout(context.Background(), 10*time.Millisecond)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "genuinely full")
+	assert.NotErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,42 @@
+package simultaneous
+
+// subLimit is the accounting of a Child. Except for the counters, it is
+// protected by the lock of the core that it shares with its parent.
+type subLimit struct {
+	parent  *subLimit // nil if the parent is not itself a Child
+	size    int64
+	used    int64
+	waiting int
+	weight  float64 // for WithFairShare, not positive means 1
+	counters
+}
+
+// Child creates a Limit of n that is also limited by l: space obtained
+// from the Child counts against both, and space is only granted when both
+// have room, as a single step. For example, to allow at most 20 database
+// operations of which at most 5 may be schema changes:
+//
+//	operations := simultaneous.New[db](20)
+//	schemaChanges := operations.Child(5)
+//
+// A Child may have children of its own. Limit, SetLimit, Stats, InUse,
+// and Waiting are about the Child. The other configuration of l (like
+// WithFIFO), Close, Pause, and WaitForIdle are shared with the parent and
+// apply to both. The Child has no name.
+func (l *Limit[T]) Child(n int) *Limit[T] {
+	child := &Limit[T]{
+		state: l.state,
+	}
+	child.name = ""
+	child.sub = &subLimit{
+		parent: l.sub,
+		size:   int64(n),
+	}
+	return child
+}
+
+func (c *core) subCapacity(sub *subLimit) int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return sub.size
+}
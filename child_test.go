@@ -0,0 +1,87 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestChild(t *testing.T) {
+	t.Parallel()
+
+	parent := simultaneous.New[any](3)
+	child := parent.Child(2)
+	assert.Equal(t, 2, child.Limit())
+
+	a := child.Forever(context.Background())
+	b := child.Forever(context.Background())
+	assert.Equal(t, 2, child.InUse())
+	assert.Equal(t, 2, parent.InUse())
+
+	_, err := child.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "child is full")
+	c, err := parent.Timeout(context.Background(), 0)
+	require.NoError(t, err, "parent is not")
+	_, err = parent.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "now it is")
+
+	waiting := make(chan simultaneous.Limited[any])
+	go func() {
+		waiting <- child.Forever(context.Background())
+	}()
+	require.Eventually(t, func() bool { return child.Waiting() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 1, parent.Waiting())
+
+	c.Done()
+	select {
+	case <-waiting:
+		t.Fatal("granted space in parent but not child")
+	case <-time.After(10 * time.Millisecond):
+	}
+	a.Done()
+	d := <-waiting
+	assert.Equal(t, 0, child.Waiting())
+	assert.Equal(t, 2, parent.InUse())
+
+	b.Done()
+	d.Done()
+	assert.Equal(t, 0, child.InUse())
+	assert.Equal(t, 0, parent.InUse())
+	assert.Equal(t, uint64(3), child.Stats().Acquisitions)
+	assert.Equal(t, uint64(4), parent.Stats().Acquisitions)
+}
+
+func TestGrandchild(t *testing.T) {
+	t.Parallel()
+
+	parent := simultaneous.New[any](10)
+	child := parent.Child(3)
+	grandchild := child.Child(5)
+
+	var held []simultaneous.Limited[any]
+	for i := 0; i < 3; i++ {
+		done, err := grandchild.Timeout(context.Background(), 0)
+		require.NoError(t, err)
+		held = append(held, done)
+	}
+	_, err := grandchild.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "limited by the child")
+	assert.Equal(t, 3, child.InUse())
+	assert.Equal(t, 3, parent.InUse())
+
+	child.SetLimit(4)
+	done, err := grandchild.Timeout(context.Background(), 0)
+	require.NoError(t, err)
+	held = append(held, done)
+
+	for _, done := range held {
+		done.Done()
+	}
+	assert.Equal(t, 0, parent.InUse())
+	assert.Equal(t, 0, grandchild.InUse())
+}
@@ -0,0 +1,65 @@
+package simultaneous
+
+import (
+	"context"
+)
+
+// WithReserved reserves n units of the capacity of the Limit for the
+// class. Only ForeverClass and AcquireClass for that class may use the
+// reserved space: other callers see a Limit that is smaller by however
+// much of the reservation is not in use. Callers of the class may also use
+// the space that is not reserved. For example, with a limit of 10 and two
+// units reserved for "critical", bulk work can hold at most 8 units and
+// critical work can always get space right away unless two critical
+// holders are already running.
+//
+// Classes wait separately: with WithFIFO, a waiter only waits behind
+// earlier waiters of its own class. A reservation of zero removes the
+// reservation.
+//
+// WithReserved changes the Limit and all of its copies. It returns the
+// Limit so that it can be chained with New.
+func (l *Limit[T]) WithReserved(class string, n int) *Limit[T] {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	l.core.reserve(class, int64(n))
+	l.core.grant()
+	return l
+}
+
+// WithReserved reserves n units of the capacity for the class. See the
+// WithReserved method.
+func WithReserved(class string, n int) Option {
+	return func(s *state) {
+		s.core.reserve(class, int64(n))
+	}
+}
+
+// reserve must be called with the lock held. Classes are never removed
+// so that space held by the class is still accounted to it when released.
+func (c *core) reserve(class string, n int64) {
+	if class == "" {
+		return
+	}
+	if c.reserved == nil {
+		c.reserved = make(map[string]int64)
+		c.classUsed = make(map[string]int64)
+	}
+	c.reserved[class] = n
+	c.updateSlow()
+}
+
+// ForeverClass is like Forever except that the space may come from the
+// space reserved for the class by WithReserved. A class without a
+// reservation is the same as no class.
+func (l *Limit[T]) ForeverClass(ctx context.Context, class string) Limited[T] {
+	done, _, _ := l.forever(ctx, l.stuckTimeout, 1, 0, class)
+	return done
+}
+
+// AcquireClass is like Acquire except that the space may come from the
+// space reserved for the class. See ForeverClass.
+func (l *Limit[T]) AcquireClass(ctx context.Context, class string) (Limited[T], error) {
+	done, _, err := l.acquire(ctx, 1, 0, class)
+	return done, err
+}
@@ -0,0 +1,78 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestReserved(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](4, simultaneous.WithReserved("critical", 1))
+	var held []simultaneous.Limited[any]
+	for i := 0; i < 3; i++ {
+		held = append(held, limit.Forever(context.Background()))
+	}
+	_, err := limit.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "the last unit is reserved")
+
+	bulk := make(chan simultaneous.Limited[any])
+	go func() {
+		bulk <- limit.Forever(context.Background())
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+
+	critical, err := limit.AcquireClass(context.Background(), "critical")
+	require.NoError(t, err, "does not wait behind bulk")
+	assert.Equal(t, 4, limit.InUse())
+	critical.Done()
+	select {
+	case <-bulk:
+		t.Fatal("bulk got reserved space")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	held[0].Done()
+	held[0] = <-bulk
+
+	// critical can use unreserved space too
+	for _, done := range held {
+		done.Done()
+	}
+	for i := 0; i < 4; i++ {
+		defer limit.ForeverClass(context.Background(), "critical").Done()
+	}
+	assert.Equal(t, 4, limit.InUse())
+}
+
+func TestReservedFIFO(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2).WithFIFO().WithReserved("critical", 1)
+	held := limit.Forever(context.Background())
+	bulk := make(chan simultaneous.Limited[any])
+	go func() {
+		bulk <- limit.Forever(context.Background())
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+
+	critical := limit.ForeverClass(context.Background(), "critical")
+	assert.Equal(t, 2, limit.InUse())
+	held.Done()
+	(<-bulk).Done()
+	critical.Done()
+
+	limit.WithReserved("critical", 0)
+	held = limit.Forever(context.Background())
+	done, err := limit.Timeout(context.Background(), 0)
+	require.NoError(t, err, "reservation removed")
+	done.Done()
+	held.Done()
+	assert.Equal(t, 0, limit.InUse())
+}
@@ -0,0 +1,112 @@
+package simultaneous
+
+import (
+	"time"
+)
+
+// Clock is a source of time. A Limit uses it to measure waits and for
+// its timers: stuck timeouts, Timeout, jitter, and WithMaxHold. Tests can
+// provide a fake Clock so that they do not depend on real sleeps.
+type Clock interface {
+	Now() time.Time
+	// NewTimer is like time.NewTimer
+	NewTimer(d time.Duration) Timer
+	// AfterFunc is like time.AfterFunc. The Timer's channel is not used.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a timer from a Clock
+type Timer interface {
+	// Chan returns the channel on which the time is delivered, like
+	// time.Timer.C
+	Chan() <-chan time.Time
+	// Stop is like time.Timer.Stop
+	Stop() bool
+}
+
+// RealClock is the Clock used by default. It uses the time package.
+type RealClock struct{}
+
+var _ Clock = RealClock{}
+
+func (RealClock) Now() time.Time { return time.Now() }
+
+func (RealClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+func (RealClock) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) Chan() <-chan time.Time { return t.C }
+
+// WithClock returns a modified Limit that uses clock instead of real
+// time. Priority aging, WithBurst, and WithWarmUp use the clock given to
+// New with the WithClock Option since they are shared by all copies of
+// the Limit.
+func (l Limit[T]) WithClock(clock Clock) *Limit[T] {
+	l.clock = clock
+	return &l
+}
+
+// WithClock uses clock instead of real time. See the WithClock method.
+func WithClock(clock Clock) Option {
+	return func(s *state) {
+		s.clock = clock
+		s.core.clock = clock
+	}
+}
+
+func (s *state) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
+}
+
+func (s *state) since(t time.Time) time.Duration {
+	return s.now().Sub(t)
+}
+
+// waitedSince returns how long a caller that started waiting at start
+// has waited. A zero start means that it did not wait, and then the
+// clock is not read.
+func (s *state) waitedSince(start time.Time) time.Duration {
+	if start.IsZero() {
+		return 0
+	}
+	return s.since(start)
+}
+
+func (s *state) newTimer(d time.Duration) Timer {
+	if s.clock == nil {
+		return realTimer{time.NewTimer(d)}
+	}
+	return s.clock.NewTimer(d)
+}
+
+func (s *state) afterFunc(d time.Duration, f func()) Timer {
+	if s.clock == nil {
+		return realTimer{time.AfterFunc(d, f)}
+	}
+	return s.clock.AfterFunc(d, f)
+}
+
+// now must be called with the lock held
+func (c *core) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
+
+// afterFunc must be called with the lock held
+func (c *core) afterFunc(d time.Duration, f func()) Timer {
+	if c.clock == nil {
+		return realTimer{time.AfterFunc(d, f)}
+	}
+	return c.clock.AfterFunc(d, f)
+}
@@ -0,0 +1,209 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+type fakeClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock *fakeClock
+	at    time.Time
+	c     chan time.Time
+	f     func()
+}
+
+var _ simultaneous.Clock = &fakeClock{}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) simultaneous.Timer {
+	return c.add(d, nil)
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) simultaneous.Timer {
+	return c.add(d, f)
+}
+
+func (c *fakeClock) add(d time.Duration, f func()) *fakeTimer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	t := &fakeTimer{
+		clock: c,
+		at:    c.now.Add(d),
+		c:     make(chan time.Time, 1),
+		f:     f,
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (c *fakeClock) pending() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.timers)
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	c.now = c.now.Add(d)
+	var fire []*fakeTimer
+	remaining := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			remaining = append(remaining, t)
+		} else {
+			fire = append(fire, t)
+		}
+	}
+	c.timers = remaining
+	now := c.now
+	c.lock.Unlock()
+	for _, t := range fire {
+		if t.f != nil {
+			t.f()
+		} else {
+			t.c <- now
+		}
+	}
+}
+
+func (t *fakeTimer) Chan() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+	for i, other := range t.clock.timers {
+		if other == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func TestClockStuck(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	var stuck, unstuck atomic.Int32
+	limit := simultaneous.New[any](1,
+		simultaneous.WithClock(clock),
+		simultaneous.WithStuckMessaging(time.Hour,
+			func(context.Context) { stuck.Add(1) },
+			func(context.Context) { unstuck.Add(1) }))
+	ctx := context.Background()
+	held := limit.Forever(ctx)
+
+	got := make(chan simultaneous.Limited[any])
+	go func() { got <- limit.Forever(ctx) }()
+	require.Eventually(t, func() bool { return clock.pending() == 1 }, time.Second, time.Millisecond)
+	assert.Zero(t, stuck.Load())
+
+	clock.Advance(time.Hour)
+	require.Eventually(t, func() bool { return stuck.Load() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Minute)
+	held.Done()
+	done := <-got
+	assert.Equal(t, int32(1), unstuck.Load())
+	assert.Equal(t, time.Hour+time.Minute, done.WaitDuration())
+	done.Done()
+}
+
+func TestClockTimeout(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	limit := simultaneous.New[any](1).WithClock(clock)
+	ctx := context.Background()
+	held := limit.Forever(ctx)
+	defer held.Done()
+
+	result := make(chan error)
+	go func() {
+		_, err := limit.Timeout(ctx, time.Minute)
+		result <- err
+	}()
+	require.Eventually(t, func() bool { return clock.pending() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Second)
+	select {
+	case err := <-result:
+		require.Failf(t, "timed out early", "%v", err)
+	default:
+	}
+	clock.Advance(time.Minute)
+	err := <-result
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+}
+
+func TestClockThrottle(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	throttle := simultaneous.NewThrottle[any](10, 1, 1, simultaneous.WithClock(clock))
+	ctx := context.Background()
+	throttle.Forever(ctx).Done()
+
+	result := make(chan error)
+	go func() {
+		done, err := throttle.Acquire(ctx)
+		if err == nil {
+			done.Done()
+		}
+		result <- err
+	}()
+	require.Eventually(t, func() bool { return clock.pending() == 1 }, time.Second, time.Millisecond)
+	select {
+	case err := <-result:
+		require.Failf(t, "started before the rate allowed", "%v", err)
+	default:
+	}
+	clock.Advance(time.Second)
+	assert.NoError(t, <-result)
+
+	// the timeout and the rate are measured with the same clock
+	_, err := throttle.Timeout(ctx, 500*time.Millisecond)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+	clock.Advance(time.Second)
+	done, err := throttle.Timeout(ctx, 0)
+	require.NoError(t, err)
+	done.Done()
+}
+
+func TestClockKeyedEviction(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	limit := simultaneous.NewKeyedTotal[string, any](1, 10, simultaneous.WithClock(clock))
+	limit.SetEviction(0, time.Minute)
+	ctx := context.Background()
+
+	limit.Forever(ctx, "a").Done()
+	clock.Advance(30 * time.Second)
+	limit.Forever(ctx, "b").Done()
+	assert.Equal(t, 2, limit.Len())
+	clock.Advance(45 * time.Second)
+	limit.SetEviction(0, time.Minute)
+	assert.Equal(t, 1, limit.Len(), "only the key idle for longer than the timeout is forgotten")
+}
@@ -0,0 +1,47 @@
+package simultaneous
+
+import (
+	"context"
+
+	"github.com/memsql/errors"
+)
+
+// ErrClosed is returned when trying to obtain space in a Limit that has
+// been closed
+var ErrClosed errors.String = "simultaneous limit closed"
+
+// Close makes all acquisitions from the Limit fail, both those that are
+// waiting now and all future ones. Methods that return an error return
+// one wrapping ErrClosed. Forever returns a Limited that does not hold
+// space. Space that is already held remains valid until it is released.
+// Close affects all copies of the Limit.
+//
+// To shut down cleanly, call Close and then Drain.
+func (l *Limit[T]) Close() {
+	l.core.close()
+}
+
+// Drain waits until all the space in the Limit has been released. It does
+// the same thing as WaitForIdle, but is meant to be called after Close. If
+// the context is cancelled first, an error wrapping ctx.Err() is returned.
+func (l *Limit[T]) Drain(ctx context.Context) error {
+	return l.WaitForIdle(ctx)
+}
+
+// WaitForIdle waits, without taking any space itself, until no space in
+// the Limit is in use. It does not stop new acquisitions so space may be
+// in use again by the time it returns; it only says that there was a
+// moment when nothing was running. If the context is cancelled first, an
+// error wrapping ctx.Err() is returned.
+func (l *Limit[T]) WaitForIdle(ctx context.Context) error {
+	select {
+	case <-l.core.idleChan():
+		return nil
+	case <-ctx.Done():
+		return errors.Wrapf(contextError(ctx), "context cancelled before simultaneous limit became idle (%d in use)", l.InUse())
+	}
+}
+
+func (l *Limit[T]) closedError() error {
+	return ErrClosed.Errorf("simultaneous limit (of %d) is closed", l.capacity())
+}
@@ -0,0 +1,102 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	held := limit.Forever(context.Background())
+
+	waiting := make(chan error)
+	go func() {
+		_, err := limit.Acquire(context.Background())
+		waiting <- err
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+
+	limit.Close()
+	assert.ErrorIs(t, <-waiting, simultaneous.ErrClosed, "waiter")
+	assert.Equal(t, 0, limit.Waiting())
+
+	_, err := limit.Acquire(context.Background())
+	assert.ErrorIs(t, err, simultaneous.ErrClosed, "Acquire")
+	_, err = limit.Timeout(context.Background(), time.Second)
+	assert.ErrorIs(t, err, simultaneous.ErrClosed, "Timeout")
+	_, err = limit.AcquireN(context.Background(), 1)
+	assert.ErrorIs(t, err, simultaneous.ErrClosed, "AcquireN")
+	_, ok := limit.TryAcquireN(1)
+	assert.False(t, ok, "TryAcquireN")
+	limit.Forever(context.Background()).Done()
+	assert.Equal(t, 1, limit.InUse(), "held space is still held")
+
+	held.Done()
+	_, err = limit.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrClosed, "empty but still closed")
+	assert.NoError(t, limit.Drain(context.Background()))
+}
+
+func TestDrain(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	assert.NoError(t, limit.Drain(context.Background()), "nothing held")
+
+	a := limit.Forever(context.Background())
+	b := limit.Forever(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limit.Drain(ctx), context.DeadlineExceeded)
+
+	limit.Close()
+	drained := make(chan error)
+	go func() {
+		drained <- limit.Drain(context.Background())
+	}()
+	a.Done()
+	select {
+	case <-drained:
+		assert.Fail(t, "drained with space still held")
+	case <-time.After(10 * time.Millisecond):
+	}
+	b.Done()
+	assert.NoError(t, <-drained)
+}
+
+func TestWaitForIdle(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](3)
+	assert.NoError(t, limit.WaitForIdle(context.Background()), "nothing held")
+
+	held, err := limit.AcquireN(context.Background(), 2)
+	require.NoError(t, err)
+	idle := make(chan error)
+	go func() {
+		idle <- limit.WaitForIdle(context.Background())
+	}()
+
+	// WaitForIdle does not take space
+	other := limit.Forever(context.Background())
+	assert.Equal(t, 3, limit.InUse())
+	held.Done()
+	select {
+	case <-idle:
+		assert.Fail(t, "idle with space still held")
+	case <-time.After(10 * time.Millisecond):
+	}
+	other.Done()
+	assert.NoError(t, <-idle)
+
+	limit.Forever(context.Background()).Done()
+	assert.NoError(t, limit.WaitForIdle(context.Background()), "idle again")
+}
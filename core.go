@@ -0,0 +1,641 @@
+package simultaneous
+
+import (
+	"container/list"
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// core is the accounting of space in a Limit. It is shared by all
+// copies of a Limit.
+//
+// In the common case, space is taken and released with atomic operations
+// on used without taking the lock. The lock is needed to wait, to use
+// classes or sub-limits, and when slow is set because the configuration
+// requires more than a simple check for space. A waiter counts itself in
+// waiting before its last check for space, and a release without the
+// lock checks waiting after giving back the space, so either the waiter
+// sees the space or the release sees the waiter and grants it.
+type core struct {
+	id         uint64 // in order of creation
+	lock       sync.Mutex
+	size       atomic.Int64
+	used       atomic.Int64
+	waiting    atomic.Int64             // waiters.Len() plus arrivals about to wait
+	slow       atomic.Bool              // new arrivals must take the lock, see updateSlow
+	closed     atomic.Bool              // only set with the lock held
+	idleWanted atomic.Bool              // idle is not nil
+	shards     atomic.Pointer[shardSet] // nil unless WithShards
+	clock      Clock                    // nil means real time
+	waiters    list.List                // of *waiter, in order of arrival
+	fifo       bool
+	paused     bool
+	idle       chan struct{} // closed when used drops to zero
+
+	maxWaiters int // if not zero, more waiters than this are turned away
+
+	lifo        bool
+	lifoBacklog int // lifo only applies with more waiters than this
+
+	reserved  map[string]int64 // space only the class may use, by class
+	classUsed map[string]int64 // space held by each class in reserved
+
+	aging       time.Duration // waiting this long raises priority by one
+	prioritized int           // number of waiters with a non-zero priority
+
+	fair bool // grant to the sub-limit with the smallest share first
+
+	accumulate bool    // hold space for the waiter at the head, see WithAccumulatingReservation
+	holding    *waiter // the waiter space is held for, if any
+
+	burst atomic.Pointer[burstAllowance] // nil unless WithBurst
+
+	warm       atomic.Pointer[warmUp] // nil unless warming up
+	warmConfig *warmUp                // set by WithWarmUp
+
+	breaker  atomic.Pointer[breaker] // nil unless WithShedding
+	pressure observerList[pressureWatcher]
+
+	queuedSince time.Time     // when the queue last stopped being empty
+	lastGrant   time.Time     // when space was last granted to a waiter
+	grantEvery  time.Duration // recent average time between grants, see noteGrant
+
+	counters
+}
+
+type waiter struct {
+	n        int64
+	prio     int
+	class    string
+	sub      *subLimit
+	since    time.Time
+	ready    chan struct{} // closed once the space has been granted or the core closed
+	closed   bool          // set before ready is closed if the core was closed
+	kicked   bool          // set, along with closed, by CancelOldestWaiter
+	tooLarge bool          // set, along with closed, by failTooLarge
+	held     int64         // space already taken for the waiter, see hold
+	elem     *list.Element
+}
+
+// closedWaiter is returned by acquire after the core has been closed
+var closedWaiter = func() *waiter {
+	w := &waiter{
+		ready:  make(chan struct{}),
+		closed: true,
+	}
+	close(w.ready)
+	return w
+}()
+
+// fullWaiter is returned by acquire when there are already too many waiters
+var fullWaiter = &waiter{}
+
+// sheddingWaiter is returned by acquire when the core is shedding load
+var sheddingWaiter = &waiter{}
+
+// tooLargeWaiter is returned by acquire when more space is requested than
+// can ever be granted
+var tooLargeWaiter = &waiter{}
+
+var coreIDs atomic.Uint64
+
+func newCore(size int) *core {
+	c := &core{
+		id: coreIDs.Add(1),
+	}
+	c.size.Store(int64(size))
+	return c
+}
+
+func (c *core) capacity() int64 {
+	return c.size.Load()
+}
+
+// updateSlow must be called, with the lock held, whenever something that
+// determines whether new arrivals can skip the lock changes
+func (c *core) updateSlow() {
+	c.slow.Store(c.fifo || c.accumulate || c.paused || c.closed.Load() || len(c.reserved) > 0 || c.burst.Load() != nil || c.warm.Load() != nil)
+}
+
+// tryFast takes n units of space without the lock if that is allowed
+// and there is room. While anyone is waiting, the lock must be taken so
+// that space being released is granted to the waiters first.
+func (c *core) tryFast(n int64) bool {
+	if c.slow.Load() || c.waiting.Load() > 0 {
+		return false
+	}
+	if set := c.shards.Load(); set != nil {
+		return c.tryShard(set, n)
+	}
+	for {
+		used := c.used.Load()
+		if c.size.Load()-used < n {
+			return false
+		}
+		if c.used.CompareAndSwap(used, used+n) {
+			return true
+		}
+	}
+}
+
+// acquire takes n units of space for the class (and the sub-limit, if
+// not nil) if they're available and returns nil. If they are not
+// available, it returns a waiter that will become ready once the space has
+// been granted. It also returns the class that the space is accounted to,
+// which must be used to release it.
+func (c *core) acquire(n int64, prio int, class string, sub *subLimit) (*waiter, string) {
+	defer c.notePressure()
+	if class == "" && sub == nil && c.tryFast(n) {
+		return nil, ""
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	class = c.classOf(class)
+	if c.closed.Load() {
+		return closedWaiter, class
+	}
+	if c.tooLarge(n, sub) {
+		return tooLargeWaiter, class
+	}
+	c.waiting.Add(1)
+	if c.admit(n, class, sub) {
+		c.waiting.Add(-1)
+		return nil, class
+	}
+	if c.maxWaiters > 0 && c.waiters.Len() >= c.maxWaiters {
+		c.waiting.Add(-1)
+		return fullWaiter, class
+	}
+	if b := c.breaker.Load(); b != nil && b.shedding(c.now()) {
+		c.waiting.Add(-1)
+		return sheddingWaiter, class
+	}
+	w := &waiter{
+		n:     n,
+		prio:  prio,
+		class: class,
+		sub:   sub,
+		ready: make(chan struct{}),
+	}
+	for s := sub; s != nil; s = s.parent {
+		s.waiting++
+		s.counters.sawWaiters(s.waiting)
+	}
+	if prio != 0 {
+		c.prioritized++
+		w.since = c.now()
+	} else if c.aging > 0 {
+		w.since = c.now()
+	}
+	if c.waiters.Len() == 0 {
+		c.queuedSince = c.now()
+	}
+	w.elem = c.waiters.PushBack(w)
+	c.counters.sawWaiters(c.waiters.Len())
+	if c.accumulate {
+		// hold the available space if the waiter is at the head
+		c.grant()
+	}
+	return w, class
+}
+
+// remove takes a waiter out of the queue. Must be called with the
+// lock held.
+func (c *core) remove(w *waiter) {
+	c.unhold(w)
+	c.waiters.Remove(w.elem)
+	c.waiting.Add(-1)
+	if w.prio != 0 {
+		c.prioritized--
+	}
+	for s := w.sub; s != nil; s = s.parent {
+		s.waiting--
+	}
+}
+
+// admit takes n units of space for a new arrival of the class if it may
+// have them. Must be called with the lock held.
+func (c *core) admit(n int64, class string, sub *subLimit) bool {
+	if c.closed.Load() || c.paused {
+		return false
+	}
+	if c.fifo && c.waitingAhead(class) {
+		return false
+	}
+	c.drainShards()
+	return c.claim(n, 0, class, sub)
+}
+
+// waitingAhead returns true if there are waiters that a new arrival of
+// the class would have to wait behind. Must be called with the lock held.
+func (c *core) waitingAhead(class string) bool {
+	if len(c.reserved) == 0 {
+		return c.waiters.Len() > 0
+	}
+	for e := c.waiters.Front(); e != nil; e = e.Next() {
+		if e.Value.(*waiter).class == class {
+			return true
+		}
+	}
+	return false
+}
+
+// claim takes n units of space for the class in the sub-limit if there
+// is room. Space reserved for other classes that they are not using is
+// not available. held units of the space have already been taken from
+// the core by hold. Must be called with the lock held.
+func (c *core) claim(n int64, held int64, class string, sub *subLimit) bool {
+	if !fitsSub(n, sub) {
+		return false
+	}
+	unavailable := c.unavailable(class)
+	// others may be taking space with tryFast at the same time
+	size := c.sizeNow()
+	for {
+		used := c.used.Load()
+		if size-used-unavailable+held < n {
+			return false
+		}
+		if c.used.CompareAndSwap(used, used+n-held) {
+			break
+		}
+	}
+	c.afterBurstChange()
+	if class != "" {
+		c.classUsed[class] += n
+	}
+	for s := sub; s != nil; s = s.parent {
+		s.used += n
+	}
+	return true
+}
+
+// fitsSub returns true if there is room for n units of space in the
+// sub-limit and its parents. Must be called with the lock held.
+func fitsSub(n int64, sub *subLimit) bool {
+	for s := sub; s != nil; s = s.parent {
+		if s.size-s.used < n {
+			return false
+		}
+	}
+	return true
+}
+
+// unavailable is how much space is reserved for classes other than class
+// and not used by them. Must be called with the lock held.
+func (c *core) unavailable(class string) int64 {
+	var unavailable int64
+	for other, reserved := range c.reserved {
+		if other != class && reserved > c.classUsed[other] {
+			unavailable += reserved - c.classUsed[other]
+		}
+	}
+	return unavailable
+}
+
+// classOf returns the class that space should be accounted to: classes
+// without a reservation are the same as no class. Must be called with
+// the lock held.
+func (c *core) classOf(class string) string {
+	if _, ok := c.reserved[class]; ok {
+		return class
+	}
+	return ""
+}
+
+// tryAcquire takes n units of space if they're available
+func (c *core) tryAcquire(n int64, sub *subLimit) bool {
+	defer c.notePressure()
+	if sub == nil && c.tryFast(n) {
+		return true
+	}
+	if sub == nil && !c.slow.Load() && c.shards.Load() == nil && c.waiting.Load() == 0 {
+		// tryFast has already looked
+		return false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.admit(n, "", sub)
+}
+
+// count records an event for Stats, in the core and in the sub-limit
+// and its parents. waited is only used for outcomeAcquired.
+func (c *core) count(sub *subLimit, outcome outcome, waited time.Duration) {
+	c.counters.add(outcome, waited)
+	if b := c.breaker.Load(); b != nil {
+		b.record(outcome, waited, c.now())
+	}
+	for s := sub; s != nil; s = s.parent {
+		s.counters.add(outcome, waited)
+	}
+}
+
+// countTryFailure records, for Stats, a try without waiting that found
+// no space. It is counted as a timeout too.
+func (c *core) countTryFailure(sub *subLimit) {
+	c.count(sub, outcomeTimeout, 0)
+	c.counters.tryFailures.Add(1)
+	for s := sub; s != nil; s = s.parent {
+		s.counters.tryFailures.Add(1)
+	}
+}
+
+func (c *core) release(n int64, class string, sub *subLimit) {
+	defer c.notePressure()
+	if class == "" && sub == nil && c.burst.Load() == nil {
+		if set := c.shards.Load(); set != nil {
+			if c.releaseShard(set, n) {
+				return
+			}
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			c.drainSet(set)
+		} else if c.waiting.Load() > 0 {
+			// the space goes to the waiters before anyone without the
+			// lock can see it
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			c.used.Add(-n)
+		} else {
+			used := c.used.Add(-n)
+			if c.waiting.Load() == 0 && (used > 0 || !c.idleWanted.Load()) {
+				return
+			}
+			c.lock.Lock()
+			defer c.lock.Unlock()
+		}
+	} else {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.used.Add(-n)
+		c.afterBurstChange()
+		if class != "" {
+			c.classUsed[class] -= n
+		}
+		for s := sub; s != nil; s = s.parent {
+			s.used -= n
+		}
+	}
+	c.grant()
+	c.checkIdle()
+}
+
+// checkIdle closes idle if no space is in use. Must be called with the
+// lock held.
+func (c *core) checkIdle() {
+	c.drainShards()
+	if c.used.Load() <= 0 && c.idle != nil {
+		close(c.idle)
+		c.idle = nil
+		c.idleWanted.Store(false)
+	}
+}
+
+// close makes all current and future waiters fail
+func (c *core) close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.closed.Store(true)
+	c.updateSlow()
+	for e := c.waiters.Front(); e != nil; e = c.waiters.Front() {
+		w := e.Value.(*waiter)
+		c.remove(w)
+		w.closed = true
+		close(w.ready)
+	}
+}
+
+func (c *core) pause(paused bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.paused && !paused {
+		c.restartWarmUp()
+	}
+	c.paused = paused
+	c.updateSlow()
+	c.grant()
+}
+
+func (c *core) isPaused() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.paused
+}
+
+func (c *core) isClosed() bool {
+	return c.closed.Load()
+}
+
+// idleChan returns a channel that is closed once no space is in use
+func (c *core) idleChan() <-chan struct{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.idle == nil {
+		c.idle = make(chan struct{})
+		c.idleWanted.Store(true)
+	}
+	idle := c.idle
+	// checked after idleWanted is set so that a release without the lock
+	// either sees it or has already given back the space
+	c.checkIdle()
+	return idle
+}
+
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// grant hands available space to waiters in order of priority and then
+// in the order they arrived. Waiters that want more space than is
+// available are skipped so that they do not hold up smaller requests,
+// unless the core is strictly fifo. Must be called with the lock held.
+func (c *core) grant() {
+	if c.paused {
+		return
+	}
+	c.drainShards()
+	if c.prioritized > 0 {
+		c.grantPrioritized()
+		return
+	}
+	if c.fair {
+		c.grantFair()
+		return
+	}
+	if c.newestFirst() {
+		for e := c.waiters.Back(); e != nil && c.used.Load() < c.sizeNow(); {
+			prev := e.Prev()
+			c.grantOne(e.Value.(*waiter))
+			e = prev
+		}
+		return
+	}
+	var blocked map[string]bool
+	for e := c.waiters.Front(); e != nil && c.used.Load() < c.sizeNow(); {
+		next := e.Next()
+		if !c.grantNext(e.Value.(*waiter), &blocked) {
+			return
+		}
+		e = next
+	}
+}
+
+// grantNext is grantOne for when waiters are being granted space in
+// order. With fifo, a waiter that doesn't fit blocks the waiters of the
+// same class behind it. It returns false if nobody else can be granted
+// space. Must be called with the lock held.
+func (c *core) grantNext(w *waiter, blocked *map[string]bool) bool {
+	if (*blocked)[w.class] || c.grantOne(w) {
+		return true
+	}
+	if c.accumulate && c.hold(w) {
+		return false
+	}
+	if !c.fifo {
+		return true
+	}
+	if len(c.reserved) == 0 {
+		return false
+	}
+	if *blocked == nil {
+		*blocked = make(map[string]bool)
+	}
+	(*blocked)[w.class] = true
+	return true
+}
+
+// newestFirst returns true if waiters should be granted space in the
+// reverse order of their arrival. Must be called with the lock held.
+func (c *core) newestFirst() bool {
+	return c.lifo && c.waiters.Len() > c.lifoBacklog
+}
+
+// grantPrioritized is grant for when some waiters have a priority.
+// Waiters that have the same priority have aged equally so sorting
+// stably by effective priority keeps them in order of arrival (or the
+// reverse, when newest first).
+func (c *core) grantPrioritized() {
+	if c.used.Load() >= c.sizeNow() {
+		return
+	}
+	waiters := make([]*waiter, 0, c.waiters.Len())
+	if c.newestFirst() {
+		for e := c.waiters.Back(); e != nil; e = e.Prev() {
+			waiters = append(waiters, e.Value.(*waiter))
+		}
+	} else {
+		for e := c.waiters.Front(); e != nil; e = e.Next() {
+			waiters = append(waiters, e.Value.(*waiter))
+		}
+	}
+	if c.aging > 0 {
+		now := c.now()
+		effective := make(map[*waiter]int, len(waiters))
+		for _, w := range waiters {
+			effective[w] = w.prio + int(now.Sub(w.since)/c.aging)
+		}
+		sort.SliceStable(waiters, func(i, j int) bool {
+			return effective[waiters[i]] > effective[waiters[j]]
+		})
+	} else {
+		sort.SliceStable(waiters, func(i, j int) bool {
+			return waiters[i].prio > waiters[j].prio
+		})
+	}
+	var blocked map[string]bool
+	for _, w := range waiters {
+		if c.used.Load() >= c.sizeNow() {
+			return
+		}
+		if !c.grantNext(w, &blocked) {
+			return
+		}
+	}
+}
+
+// grantOne gives space to the waiter if there is enough. Must be called
+// with the lock held.
+func (c *core) grantOne(w *waiter) bool {
+	if !c.claim(w.n, w.held, w.class, w.sub) {
+		return false
+	}
+	w.held = 0
+	c.remove(w)
+	c.noteGrant()
+	close(w.ready)
+	return true
+}
+
+// wait waits for space to be granted to the waiter, for the context to
+// be cancelled, or for timeout to fire. It returns true if the space
+// was granted.
+func (c *core) wait(ctx context.Context, w *waiter, timeout <-chan time.Time) bool {
+	select {
+	case <-w.ready:
+		return !w.closed
+	case <-ctx.Done():
+	case <-timeout:
+	}
+	return !c.cancel(w)
+}
+
+// cancel stops waiting. It returns false if it is too late because the
+// space has already been granted.
+func (c *core) cancel(w *waiter) bool {
+	defer c.notePressure()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	select {
+	case <-w.ready:
+		return w.closed
+	default:
+	}
+	c.remove(w)
+	c.grant()
+	return true
+}
+
+// resize changes the size of the core or, if sub is not nil, of the
+// sub-limit
+func (c *core) resize(size int64, sub *subLimit) {
+	defer c.notePressure()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	shrunk := size < c.size.Load()
+	if sub != nil {
+		shrunk = size < sub.size
+		sub.size = size
+	} else {
+		c.drainShards()
+		c.size.Store(size)
+		c.afterBurstChange()
+	}
+	if shrunk {
+		c.failTooLarge()
+	}
+	c.grant()
+}
+
+// resizeBy changes the size of the core or, if sub is not nil, of the
+// sub-limit, by delta
+func (c *core) resizeBy(delta int64, sub *subLimit) {
+	defer c.notePressure()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if sub != nil {
+		sub.size += delta
+	} else {
+		c.drainShards()
+		c.size.Add(delta)
+		c.afterBurstChange()
+	}
+	if delta < 0 {
+		c.failTooLarge()
+	}
+	c.grant()
+}
@@ -0,0 +1,105 @@
+package simultaneous
+
+import (
+	"context"
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewPerCPU creates a Limit sized at perCPU times runtime.GOMAXPROCS,
+// rounded, and at least one. Use FollowGOMAXPROCS to keep it sized that
+// way if GOMAXPROCS changes.
+//
+//	// two database connections for each CPU
+//	connections := simultaneous.NewPerCPU[db](2)
+func NewPerCPU[T any](perCPU float64, opts ...Option) *Limit[T] {
+	return New[T](perCPUSize(perCPU, float64(runtime.GOMAXPROCS(0))), opts...)
+}
+
+// NewFromCgroup is like NewPerCPU except that the number of CPUs is the
+// CPU quota of the container, from the cgroup (v1 or v2) of the process,
+// rather than GOMAXPROCS. A fractional quota, like 1.5 CPUs, is not
+// rounded before multiplying. Without a quota, it is the same as
+// NewPerCPU.
+func NewFromCgroup[T any](perCPU float64, opts ...Option) *Limit[T] {
+	cpus, ok := CgroupCPUQuota()
+	if !ok {
+		cpus = float64(runtime.GOMAXPROCS(0))
+	}
+	return New[T](perCPUSize(perCPU, cpus), opts...)
+}
+
+// FollowGOMAXPROCS checks runtime.GOMAXPROCS every interval and, when it
+// has changed, resizes the Limit to perCPU times the new value, like
+// NewPerCPU. It stops when the context is cancelled. Since Go 1.25,
+// GOMAXPROCS follows changes to the container's CPU quota on its own.
+func (l *Limit[T]) FollowGOMAXPROCS(ctx context.Context, perCPU float64, interval time.Duration) {
+	last := runtime.GOMAXPROCS(0)
+	go func() {
+		for {
+			timer := l.newTimer(interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.Chan():
+			}
+			if procs := runtime.GOMAXPROCS(0); procs != last {
+				last = procs
+				l.SetLimit(perCPUSize(perCPU, float64(procs)))
+			}
+		}
+	}()
+}
+
+func perCPUSize(perCPU float64, cpus float64) int {
+	n := int(math.Round(perCPU * cpus))
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// CgroupCPUQuota returns the number of CPUs that the cgroup of the
+// process may use, from cpu.max (cgroup v2) or cpu.cfs_quota_us and
+// cpu.cfs_period_us (cgroup v1). It returns false if there is no quota
+// or it cannot be read, for example when not running on Linux.
+func CgroupCPUQuota() (float64, bool) {
+	if b, err := os.ReadFile("/sys/fs/cgroup/cpu.max"); err == nil {
+		fields := strings.Fields(string(b))
+		if len(fields) == 2 {
+			return parseQuota(fields[0], fields[1])
+		}
+		return 0, false
+	}
+	quota, err := os.ReadFile("/sys/fs/cgroup/cpu/cpu.cfs_quota_us")
+	if err != nil {
+		return 0, false
+	}
+	period, err := os.ReadFile("/sys/fs/cgroup/cpu/cpu.cfs_period_us")
+	if err != nil {
+		return 0, false
+	}
+	return parseQuota(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+}
+
+// parseQuota divides a cgroup CPU quota by its period. A quota of "max"
+// (v2) or a negative one (v1) means there is no quota.
+func parseQuota(quota, period string) (float64, bool) {
+	if quota == "max" {
+		return 0, false
+	}
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 {
+		return 0, false
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0, false
+	}
+	return q / p, true
+}
@@ -0,0 +1,43 @@
+package simultaneous_test
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestNewPerCPU(t *testing.T) {
+	t.Parallel()
+
+	procs := runtime.GOMAXPROCS(0)
+	assert.Equal(t, 2*procs, simultaneous.NewPerCPU[any](2).Limit())
+	assert.Equal(t, 1, simultaneous.NewPerCPU[any](0.0001).Limit(), "at least one")
+
+	limit := simultaneous.NewFromCgroup[any](1)
+	if quota, ok := simultaneous.CgroupCPUQuota(); ok {
+		assert.Greater(t, quota, 0.0)
+		assert.Equal(t, int(math.Max(1, math.Round(quota))), limit.Limit())
+	} else {
+		assert.Equal(t, procs, limit.Limit())
+	}
+}
+
+// TestFollowGOMAXPROCS changes GOMAXPROCS so it does not run in parallel
+func TestFollowGOMAXPROCS(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+	defer runtime.GOMAXPROCS(procs)
+
+	limit := simultaneous.NewPerCPU[any](3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	limit.FollowGOMAXPROCS(ctx, 3, time.Millisecond)
+
+	runtime.GOMAXPROCS(procs + 1)
+	assert.Eventually(t, func() bool { return limit.Limit() == 3*(procs+1) }, time.Second, time.Millisecond)
+}
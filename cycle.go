@@ -0,0 +1,178 @@
+package simultaneous
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/memsql/errors"
+)
+
+// ErrDeadlock is reported to the cycle detection callback when goroutines
+// are waiting for limits held by each other
+var ErrDeadlock errors.String = "goroutines are waiting for limits held by each other"
+
+// waitGraph tracks, across all limits that have cycle detection enabled,
+// which limits each goroutine holds and which limit each goroutine is
+// waiting for
+var waitGraph = struct {
+	sync.Mutex
+	holders map[*core]*coreHolders // only limits that are held
+	waiting map[uint64]*core       // what each waiting goroutine is waiting for
+}{
+	holders: make(map[*core]*coreHolders),
+	waiting: make(map[uint64]*core),
+}
+
+// coreHolders is removed from the waitGraph, along with the name, once
+// nothing holds the limit so that limits that are no longer used can be
+// garbage collected
+type coreHolders struct {
+	gids map[uint64]int // how much space each goroutine holds
+	name string
+}
+
+// debugCycleCallback, if not nil, is used by Limits without
+// WithCycleDetection. It is set in builds with the simultaneous_debug
+// build tag.
+var debugCycleCallback func(context.Context, error)
+
+// WithCycleDetection returns a modified Limit that tracks which
+// goroutines hold it and which are waiting for it. When a goroutine
+// starts waiting for a Limit and that completes a cycle (it holds a
+// limit that another goroutine is waiting for while that goroutine, or
+// one that it waits for, holds the Limit it is waiting for), the
+// callback is called with an error that matches ErrDeadlock and
+// describes the cycle. Unlike WithDeadlockDetection, which reports
+// inconsistent ordering that could lead to a deadlock, cycle detection
+// reports goroutines that are actually waiting for each other. With
+// capacities above one, a cycle is only a deadlock if every unit of
+// space is held by goroutines in cycles, so a report may be a near miss.
+//
+// Building with the simultaneous_debug tag enables cycle detection for
+// every Limit without it, reporting with the standard logger.
+//
+// Like deadlock detection, it identifies goroutines from a stack trace,
+// so it is meant for development and testing.
+func (l Limit[T]) WithCycleDetection(callback func(context.Context, error)) *Limit[T] {
+	l.cycleCallback = callback
+	return &l
+}
+
+// WithCycleDetection reports goroutines waiting for limits held by each
+// other. See the WithCycleDetection method.
+func WithCycleDetection(callback func(context.Context, error)) Option {
+	return func(s *state) {
+		s.cycleCallback = callback
+	}
+}
+
+func (s *state) cycleDetection() func(context.Context, error) {
+	if s.cycleCallback != nil {
+		return s.cycleCallback
+	}
+	return debugCycleCallback
+}
+
+// trackHolding records that the current goroutine holds the limit. The
+// returned function undoes that.
+func (l *Limit[T]) trackHolding() func() {
+	gid := goroutineID()
+	c := l.core
+	waitGraph.Lock()
+	holders, ok := waitGraph.holders[c]
+	if !ok {
+		holders = &coreHolders{gids: make(map[uint64]int)}
+		waitGraph.holders[c] = holders
+	}
+	holders.gids[gid]++
+	if l.name != "" {
+		holders.name = l.name
+	}
+	waitGraph.Unlock()
+	return func() {
+		waitGraph.Lock()
+		defer waitGraph.Unlock()
+		if holders.gids[gid] <= 1 {
+			delete(holders.gids, gid)
+		} else {
+			holders.gids[gid]--
+		}
+		if len(holders.gids) == 0 {
+			delete(waitGraph.holders, c)
+		}
+	}
+}
+
+// trackWaiting records that the current goroutine is waiting for the limit
+// and reports a cycle if that completes one. The returned function must be
+// called when the wait is over.
+func (l *Limit[T]) trackWaiting(ctx context.Context, callback func(context.Context, error)) func() {
+	gid := goroutineID()
+	waitGraph.Lock()
+	waitGraph.waiting[gid] = l.core
+	cycle := findCycle(gid, l.core, map[*core]bool{})
+	var description string
+	if cycle != nil {
+		description = describeCycle(gid, l.core, cycle)
+	}
+	waitGraph.Unlock()
+	if cycle != nil {
+		callObserver(func() { callback(ctx, ErrDeadlock.Errorf("deadlock: %s", description)) })
+	}
+	return func() {
+		waitGraph.Lock()
+		defer waitGraph.Unlock()
+		delete(waitGraph.waiting, gid)
+	}
+}
+
+type cycleStep struct {
+	gid    uint64
+	waitOn *core
+}
+
+// findCycle looks for a path from the holders of c, through the limits
+// they are waiting for, back to gid. It returns the goroutines along the
+// path and what each is waiting for. Must be called with the lock held.
+func findCycle(gid uint64, c *core, visited map[*core]bool) []cycleStep {
+	if visited[c] {
+		return nil
+	}
+	visited[c] = true
+	holders, ok := waitGraph.holders[c]
+	if !ok {
+		return nil
+	}
+	for holder := range holders.gids {
+		if holder == gid {
+			return []cycleStep{}
+		}
+		next, ok := waitGraph.waiting[holder]
+		if !ok {
+			continue
+		}
+		if rest := findCycle(gid, next, visited); rest != nil {
+			return append([]cycleStep{{gid: holder, waitOn: next}}, rest...)
+		}
+	}
+	return nil
+}
+
+// describeCycle must be called with the lock held
+func describeCycle(gid uint64, c *core, cycle []cycleStep) string {
+	parts := []string{fmt.Sprintf("goroutine %d waits for %s", gid, describeCore(c))}
+	for _, step := range cycle {
+		parts = append(parts, fmt.Sprintf("held by goroutine %d which waits for %s", step.gid, describeCore(step.waitOn)))
+	}
+	parts = append(parts, fmt.Sprintf("held by goroutine %d", gid))
+	return strings.Join(parts, ", ")
+}
+
+func describeCore(c *core) string {
+	if holders, ok := waitGraph.holders[c]; ok && holders.name != "" {
+		return fmt.Sprintf("limit %q", holders.name)
+	}
+	return fmt.Sprintf("limit %p (of %d)", c, c.capacity())
+}
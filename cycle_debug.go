@@ -0,0 +1,14 @@
+//go:build simultaneous_debug
+
+package simultaneous
+
+import (
+	"context"
+	"log"
+)
+
+func init() {
+	debugCycleCallback = func(_ context.Context, err error) {
+		log.Print(err)
+	}
+}
@@ -0,0 +1,71 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestCycleDetection(t *testing.T) {
+	t.Parallel()
+
+	var reports deadlockReports
+	a := simultaneous.New[any](1, simultaneous.WithName("cycle-a"), simultaneous.WithCycleDetection(reports.callback))
+	b := simultaneous.New[any](1).WithCycleDetection(reports.callback)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	heldA := make(chan struct{})
+	heldB := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer a.Forever(ctx).Done()
+		close(heldA)
+		<-heldB
+		_, _ = b.Acquire(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		defer b.Forever(ctx).Done()
+		close(heldB)
+		<-heldA
+		assert.Eventually(t, func() bool { return b.Waiting() == 1 }, time.Second, time.Millisecond)
+		_, _ = a.Acquire(ctx)
+	}()
+	require.Eventually(t, func() bool { return len(reports.get()) == 1 }, time.Second, time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	err := reports.get()[0]
+	assert.ErrorIs(t, err, simultaneous.ErrDeadlock)
+	assert.Contains(t, err.Error(), `limit "cycle-a"`)
+}
+
+func TestCycleDetectionNoCycle(t *testing.T) {
+	t.Parallel()
+
+	var reports deadlockReports
+	a := simultaneous.New[any](1).WithCycleDetection(reports.callback)
+	b := simultaneous.New[any](1).WithCycleDetection(reports.callback)
+	ctx := context.Background()
+
+	heldB := b.Forever(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer a.Forever(ctx).Done()
+		b.Forever(ctx).Done()
+	}()
+	require.Eventually(t, func() bool { return b.Waiting() == 1 }, time.Second, time.Millisecond)
+	heldB.Done()
+	<-done
+	assert.Empty(t, reports.get())
+}
@@ -0,0 +1,107 @@
+package simultaneous
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/memsql/errors"
+)
+
+// ErrLockOrder is reported to the deadlock detection callback when limits
+// are acquired in an order that is inconsistent with an order previously
+// used.
+var ErrLockOrder errors.String = "limits acquired in inconsistent order"
+
+// lockOrder tracks, across all limits that have deadlock detection enabled,
+// which limits are held by each goroutine and the order in which limits have
+// been acquired. The ordering graph is never pruned: deadlock detection is a
+// development aid.
+var lockOrder = struct {
+	sync.Mutex
+	held   map[uint64][]*core
+	before map[*core]map[*core]struct{}
+}{
+	held:   make(map[uint64][]*core),
+	before: make(map[*core]map[*core]struct{}),
+}
+
+// WithDeadlockDetection returns a modified Limit that participates in lock
+// order tracking. Whenever the Limit is acquired by a goroutine that already
+// holds other limits that also participate, the order is recorded. If the
+// acquisition would violate an order established by earlier acquisitions, the
+// callback is invoked with an error that matches ErrLockOrder.
+//
+// Deadlock detection finds the current goroutine by parsing a stack trace
+// and is intended for development and testing, not production.
+func (l Limit[T]) WithDeadlockDetection(callback func(context.Context, error)) *Limit[T] {
+	l.deadlockCallback = callback
+	return &l
+}
+
+// checkLockOrder is called before waiting on the limit
+func (l *Limit[T]) checkLockOrder(ctx context.Context) {
+	if l.deadlockCallback == nil {
+		return
+	}
+	gid := goroutineID()
+	var violations []*core
+	lockOrder.Lock()
+	for _, held := range lockOrder.held[gid] {
+		if held == l.core {
+			continue
+		}
+		if _, ok := lockOrder.before[l.core][held]; ok {
+			violations = append(violations, held)
+		}
+		after, ok := lockOrder.before[held]
+		if !ok {
+			after = make(map[*core]struct{})
+			lockOrder.before[held] = after
+		}
+		after[l.core] = struct{}{}
+	}
+	lockOrder.Unlock()
+	for _, held := range violations {
+		l.deadlockCallback(ctx, ErrLockOrder.Errorf("acquiring limit %p (of %d) while holding limit %p (of %d) but the opposite order has been used previously",
+			l.core, l.core.capacity(), held, held.capacity()))
+	}
+}
+
+// trackHeld records that the current goroutine holds the limit. The
+// returned function undoes that.
+func (l *Limit[T]) trackHeld() func() {
+	gid := goroutineID()
+	lockOrder.Lock()
+	lockOrder.held[gid] = append(lockOrder.held[gid], l.core)
+	lockOrder.Unlock()
+	return func() {
+		lockOrder.Lock()
+		defer lockOrder.Unlock()
+		held := lockOrder.held[gid]
+		for i := len(held) - 1; i >= 0; i-- {
+			if held[i] == l.core {
+				held = append(held[:i], held[i+1:]...)
+				break
+			}
+		}
+		if len(held) == 0 {
+			delete(lockOrder.held, gid)
+		} else {
+			lockOrder.held[gid] = held
+		}
+	}
+}
+
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	s := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(s, ' '); i > 0 {
+		s = s[:i]
+	}
+	id, _ := strconv.ParseUint(string(s), 10, 64)
+	return id
+}
@@ -0,0 +1,101 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+type deadlockReports struct {
+	lock   sync.Mutex
+	errors []error
+}
+
+func (r *deadlockReports) callback(_ context.Context, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.errors = append(r.errors, err)
+}
+
+func (r *deadlockReports) get() []error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]error(nil), r.errors...)
+}
+
+func TestDeadlockDetection(t *testing.T) {
+	t.Parallel()
+
+	var reports deadlockReports
+	a := simultaneous.New[any](1).WithDeadlockDetection(reports.callback)
+	b := simultaneous.New[any](1).WithDeadlockDetection(reports.callback)
+	ctx := context.Background()
+
+	doneA := a.Forever(ctx)
+	doneB, err := b.Timeout(ctx, time.Second)
+	require.NoError(t, err)
+	doneB.Done()
+	doneA.Done()
+	assert.Empty(t, reports.get(), "consistent order")
+
+	// same order again, from another goroutine
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer a.Forever(ctx).Done()
+		defer b.Forever(ctx).Done()
+	}()
+	wg.Wait()
+	assert.Empty(t, reports.get(), "consistent order")
+
+	doneB = b.Forever(ctx)
+	doneA = a.Forever(ctx)
+	doneA.Done()
+	doneB.Done()
+	errs := reports.get()
+	if assert.Len(t, errs, 1, "inconsistent order") {
+		assert.ErrorIs(t, errs[0], simultaneous.ErrLockOrder)
+		t.Log(errs[0])
+	}
+}
+
+func TestDeadlockDetectionReleased(t *testing.T) {
+	t.Parallel()
+
+	var reports deadlockReports
+	a := simultaneous.New[any](1).WithDeadlockDetection(reports.callback)
+	b := simultaneous.New[any](1).WithDeadlockDetection(reports.callback)
+	ctx := context.Background()
+
+	// released before acquiring the other: no ordering is established
+	a.Forever(ctx).Done()
+	b.Forever(ctx).Done()
+	doneB := b.Forever(ctx)
+	a.Forever(ctx).Done()
+	doneB.Done()
+	assert.Empty(t, reports.get())
+}
+
+func TestDeadlockDetectionOff(t *testing.T) {
+	t.Parallel()
+
+	var reports deadlockReports
+	a := simultaneous.New[any](1)
+	b := simultaneous.New[any](1).WithDeadlockDetection(reports.callback)
+	ctx := context.Background()
+
+	doneA := a.Forever(ctx)
+	b.Forever(ctx).Done()
+	doneA.Done()
+	doneB := b.Forever(ctx)
+	a.Forever(ctx).Done()
+	doneB.Done()
+	assert.Empty(t, reports.get(), "a does not participate")
+}
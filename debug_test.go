@@ -0,0 +1,7 @@
+//go:build simultaneous_debug
+
+package simultaneous_test
+
+// debugBuild is true in builds with the simultaneous_debug tag, where
+// cycle detection tracks every holder and so slows down acquisition
+const debugBuild = true
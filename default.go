@@ -0,0 +1,38 @@
+package simultaneous
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+var defaults = struct {
+	sync.Mutex
+	limits map[reflect.Type]any
+}{
+	limits: make(map[reflect.Type]any),
+}
+
+// Default returns the process-wide default Limit for type T. Each type
+// has its own default. Unless one has been installed with SetDefault, it
+// is created on first use with a capacity of runtime.GOMAXPROCS(0).
+func Default[T any]() *Limit[T] {
+	key := reflect.TypeOf((*T)(nil)).Elem()
+	defaults.Lock()
+	defer defaults.Unlock()
+	if l, ok := defaults.limits[key]; ok {
+		return l.(*Limit[T])
+	}
+	l := New[T](runtime.GOMAXPROCS(0))
+	defaults.limits[key] = l
+	return l
+}
+
+// SetDefault installs the Limit to be returned by Default for type T.
+// It does not change Limits previously returned by Default.
+func SetDefault[T any](l *Limit[T]) {
+	key := reflect.TypeOf((*T)(nil)).Elem()
+	defaults.Lock()
+	defer defaults.Unlock()
+	defaults.limits[key] = l
+}
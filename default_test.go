@@ -0,0 +1,49 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestDefault(t *testing.T) {
+	t.Parallel()
+
+	type racingDefault struct{}
+	var wg sync.WaitGroup
+	limits := make([]*simultaneous.Limit[racingDefault], 10)
+	for i := range limits {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limits[i] = simultaneous.Default[racingDefault]()
+		}()
+	}
+	wg.Wait()
+	for _, l := range limits {
+		assert.Same(t, limits[0], l)
+	}
+
+	type otherDefault struct{}
+	simultaneous.SetDefault(simultaneous.New[otherDefault](1))
+	defer simultaneous.Default[otherDefault]().Forever(context.Background()).Done()
+	done, err := simultaneous.Default[racingDefault]().Timeout(context.Background(), 0)
+	assert.NoError(t, err, "separate defaults per type")
+	done.Done()
+}
+
+func TestSetDefault(t *testing.T) {
+	t.Parallel()
+
+	type installedDefault struct{}
+	original := simultaneous.Default[installedDefault]()
+	replacement := simultaneous.New[installedDefault](3)
+	simultaneous.SetDefault(replacement)
+	assert.Same(t, replacement, simultaneous.Default[installedDefault]())
+	assert.NotSame(t, original, simultaneous.Default[installedDefault]())
+}
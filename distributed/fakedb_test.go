@@ -0,0 +1,160 @@
+package distributed_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeDB is an in-memory stand-in for the lease table. It understands
+// only the statements that distributed.Limit runs, so the tests can run
+// without a database.
+type fakeDB struct {
+	lock    sync.Mutex
+	rows    map[fakeKey]*fakeRow
+	inserts int // INSERT IGNORE statements run
+	claims  int // UPDATE statements run to take a slot
+}
+
+type fakeKey struct {
+	name string
+	slot int64
+}
+
+type fakeRow struct {
+	holder  string
+	expires time.Time // zero is NULL
+}
+
+func newFakeDB() (*sql.DB, *fakeDB) {
+	f := &fakeDB{rows: make(map[fakeKey]*fakeRow)}
+	return sql.OpenDB(fakeConnector{f}), f
+}
+
+func (f *fakeDB) counts() (inserts, claims int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.inserts, f.claims
+}
+
+type fakeConnector struct{ db *fakeDB }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, fmt.Errorf("fake driver must be used with sql.OpenDB")
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fake driver does not prepare statements")
+}
+func (c fakeConn) Close() error { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("fake driver has no transactions")
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	f := c.db
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	query = strings.Join(strings.Fields(query), " ")
+	now := time.Now()
+	switch {
+	case strings.HasPrefix(query, "CREATE TABLE"):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(query, "INSERT IGNORE"):
+		f.inserts++
+		var n int64
+		for i := 0; i+1 < len(args); i += 2 {
+			key := fakeKey{name: args[i].Value.(string), slot: args[i+1].Value.(int64)}
+			if _, ok := f.rows[key]; !ok {
+				f.rows[key] = &fakeRow{}
+				n++
+			}
+		}
+		return driver.RowsAffected(n), nil
+	case strings.Contains(query, "SET holder = ?,"):
+		// holder, ttl, name, limit
+		f.claims++
+		holder, ttl, name, limit := args[0].Value.(string), args[1].Value.(int64), args[2].Value.(string), args[3].Value.(int64)
+		for slot := int64(0); slot < limit; slot++ {
+			row, ok := f.rows[fakeKey{name: name, slot: slot}]
+			if ok && (row.expires.IsZero() || row.expires.Before(now)) {
+				row.holder = holder
+				row.expires = now.Add(time.Duration(ttl) * time.Microsecond)
+				return driver.RowsAffected(1), nil
+			}
+		}
+		return driver.RowsAffected(0), nil
+	case strings.Contains(query, "SET expires = DATE_ADD"):
+		// ttl, name, holder
+		ttl, name, holder := args[0].Value.(int64), args[1].Value.(string), args[2].Value.(string)
+		var n int64
+		for key, row := range f.rows {
+			if key.name == name && row.holder == holder && !row.expires.Before(now) {
+				row.expires = now.Add(time.Duration(ttl) * time.Microsecond)
+				n++
+			}
+		}
+		return driver.RowsAffected(n), nil
+	case strings.Contains(query, "SET holder = '', expires = NULL"):
+		// name, holder
+		name, holder := args[0].Value.(string), args[1].Value.(string)
+		var n int64
+		for key, row := range f.rows {
+			if key.name == name && row.holder == holder {
+				*row = fakeRow{}
+				n++
+			}
+		}
+		return driver.RowsAffected(n), nil
+	}
+	return nil, fmt.Errorf("fake driver does not understand %q", query)
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	f := c.db
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	query = strings.Join(strings.Fields(query), " ")
+	if !strings.HasPrefix(query, "SELECT COUNT(*)") {
+		return nil, fmt.Errorf("fake driver does not understand %q", query)
+	}
+	// name, limit
+	name, limit := args[0].Value.(string), args[1].Value.(int64)
+	now := time.Now()
+	var n int64
+	for key, row := range f.rows {
+		if key.name == name && key.slot < limit && !row.expires.IsZero() && !row.expires.Before(now) {
+			n++
+		}
+	}
+	return &fakeRows{count: n}, nil
+}
+
+type fakeRows struct {
+	count int64
+	done  bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"count"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.count
+	return nil
+}
@@ -0,0 +1,83 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/memsql/errors"
+
+	"github.com/singlestore-labs/simultaneous/internal/lease"
+)
+
+// slotStore keeps leases in the rows of the lease table. It implements
+// lease.Store.
+type slotStore[T any] struct {
+	limit *Limit[T]
+}
+
+var _ lease.Store = slotStore[any]{}
+
+func (l *Limit[T]) newLease() *lease.Lease {
+	return lease.New(slotStore[T]{limit: l}, lease.Config{
+		TTL:          l.ttl,
+		PollInterval: l.pollInterval,
+		LeaseLost:    l.leaseLost,
+		Space:        fmt.Sprintf("distributed slot (of %d) for %s", l.limit, l.name),
+	})
+}
+
+// Take takes a free slot, if there is one, with a single statement.
+// Slots are then found by holder, which is unique to the lease.
+func (s slotStore[T]) Take(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	l := s.limit
+	if l.limit <= 0 {
+		return false, nil
+	}
+	if err := l.ensureSlots(ctx); err != nil {
+		return false, err
+	}
+	result, err := l.db.ExecContext(ctx, `UPDATE `+l.quotedTable()+`
+		SET holder = ?, expires = DATE_ADD(NOW(6), INTERVAL ? MICROSECOND)
+		WHERE name = ? AND slot < ? AND (expires IS NULL OR expires < NOW(6))
+		LIMIT 1`,
+		holder, ttl.Microseconds(), l.name, l.limit)
+	if err != nil {
+		return false, errors.Wrapf(err, "lease a slot of %s", l.name)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrapf(err, "lease a slot of %s", l.name)
+	}
+	return n == 1, nil
+}
+
+// Renew extends the lease. It returns true if the lease has been lost.
+func (s slotStore[T]) Renew(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	l := s.limit
+	result, err := l.db.ExecContext(ctx, `UPDATE `+l.quotedTable()+`
+		SET expires = DATE_ADD(NOW(6), INTERVAL ? MICROSECOND)
+		WHERE name = ? AND holder = ? AND expires >= NOW(6)`,
+		ttl.Microseconds(), l.name, holder)
+	if err != nil {
+		return false, errors.Wrapf(err, "renew lease on a slot of %s", l.name)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrapf(err, "renew lease on a slot of %s", l.name)
+	}
+	if n == 0 {
+		return true, ErrLeaseLost.Errorf("lease on a slot of %s expired or was taken", l.name)
+	}
+	return false, nil
+}
+
+// Release frees the slot
+func (s slotStore[T]) Release(ctx context.Context, holder string) error {
+	l := s.limit
+	_, err := l.db.ExecContext(ctx, `UPDATE `+l.quotedTable()+`
+		SET holder = '', expires = NULL
+		WHERE name = ? AND holder = ?`,
+		l.name, holder)
+	return errors.Wrapf(err, "release lease on a slot of %s", l.name)
+}
@@ -0,0 +1,212 @@
+/*
+Package distributed enforces a simultaneous limit across processes by
+leasing rows in a SingleStore (or MySQL) table.
+
+The table has one row per slot. A process holds a slot by writing its
+holder id and a lease expiry time into the row. While the slot is held,
+the lease is renewed in the background. If the process crashes, the lease
+expires and the slot becomes available again. All times come from the
+database so clocks on the clients do not need to agree.
+
+Space obtained from a Limit is a simultaneous.Limited so it can be passed
+to code that takes a simultaneous.Enforced.
+*/
+package distributed
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/memsql/errors"
+
+	"github.com/singlestore-labs/simultaneous"
+	"github.com/singlestore-labs/simultaneous/internal/lease"
+)
+
+// DefaultTable is the name of the table used to hold leases
+const DefaultTable = "simultaneous_leases"
+
+const (
+	// DefaultLeaseTTL is how long a lease lasts without being renewed
+	DefaultLeaseTTL = 30 * time.Second
+	// DefaultPollInterval is how often a waiter checks for a free slot
+	DefaultPollInterval = time.Second
+	// MinLeaseTTL is the shortest lease TTL, see WithLeaseTTL
+	MinLeaseTTL = lease.MinTTL
+)
+
+// ErrLeaseLost is passed to the callback set with WithLeaseLost when a
+// held lease could not be renewed before it expired
+var ErrLeaseLost errors.String = "distributed lease lost"
+
+// Limit is a limit on simultaneous actions that is shared by every
+// process that uses the same database table and name.
+type Limit[T any] struct {
+	db           *sql.DB
+	name         string
+	limit        int
+	table        string
+	ttl          time.Duration
+	pollInterval time.Duration
+	leaseLost    func(context.Context, error)
+	slots        *slots
+}
+
+// slots records whether the rows for the slots have been created. It is
+// shared by copies of a Limit that use the same table.
+type slots struct {
+	lock    sync.Mutex
+	created bool
+}
+
+// New creates a Limit named name that allows limit holders at once across
+// all processes. The type parameter serves the same purpose as it does for
+// simultaneous.New.
+//
+// The table must exist before the Limit is used. See CreateTable.
+func New[T any](db *sql.DB, name string, limit int) *Limit[T] {
+	return &Limit[T]{
+		db:           db,
+		name:         name,
+		limit:        limit,
+		table:        DefaultTable,
+		ttl:          DefaultLeaseTTL,
+		pollInterval: DefaultPollInterval,
+		slots:        &slots{},
+	}
+}
+
+// WithTable returns a modified Limit that keeps its leases in table
+// instead of DefaultTable
+func (l Limit[T]) WithTable(table string) *Limit[T] {
+	l.table = table
+	l.slots = &slots{}
+	return &l
+}
+
+// WithLeaseTTL returns a modified Limit whose leases expire after ttl
+// if they are not renewed. Leases are renewed every third of ttl. A ttl
+// below MinLeaseTTL, including zero or less, is raised to MinLeaseTTL.
+func (l Limit[T]) WithLeaseTTL(ttl time.Duration) *Limit[T] {
+	l.ttl = ttl
+	return &l
+}
+
+// WithPollInterval returns a modified Limit that checks for a free slot
+// every interval (plus up to 10% random jitter) while waiting
+func (l Limit[T]) WithPollInterval(interval time.Duration) *Limit[T] {
+	l.pollInterval = interval
+	return &l
+}
+
+// WithLeaseLost returns a modified Limit that calls leaseLost if a held
+// lease cannot be renewed. After that, another process may be given the
+// slot so the holder should stop what it is doing. The error wraps
+// ErrLeaseLost if the lease was taken over and otherwise is the database
+// error from the last renewal attempt.
+func (l Limit[T]) WithLeaseLost(leaseLost func(context.Context, error)) *Limit[T] {
+	l.leaseLost = leaseLost
+	return &l
+}
+
+// CreateTable creates the lease table if it does not already exist
+func (l *Limit[T]) CreateTable(ctx context.Context) error {
+	_, err := l.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+l.quotedTable()+` (
+		name VARCHAR(255) NOT NULL,
+		slot INT NOT NULL,
+		holder VARCHAR(255) NOT NULL DEFAULT '',
+		expires DATETIME(6) NULL,
+		PRIMARY KEY (name, slot)
+	)`)
+	return errors.Wrapf(err, "create table %s", l.table)
+}
+
+// Acquire waits until a slot can be leased or the context is cancelled.
+// If the context is cancelled first, an error wrapping ctx.Err() is
+// returned. Database errors are returned too. In the case of an error
+// the Done method is a no-op; otherwise it releases the lease.
+func (l *Limit[T]) Acquire(ctx context.Context) (simultaneous.Limited[T], error) {
+	s := l.newLease()
+	if err := s.Acquire(ctx); err != nil {
+		return simultaneous.NotHeld[T](), err
+	}
+	return simultaneous.Adopt[T](s), nil
+}
+
+// TryAcquire leases a slot if one is free without waiting. It returns
+// false if none is.
+func (l *Limit[T]) TryAcquire(ctx context.Context) (simultaneous.Limited[T], bool, error) {
+	s := l.newLease()
+	ok, err := s.Try(ctx)
+	if err != nil || !ok {
+		return simultaneous.NotHeld[T](), false, err
+	}
+	return simultaneous.Adopt[T](s), true, nil
+}
+
+// AcquireTimeout is like Acquire except that it waits for at most
+// timeout. If the timeout expires first, the error matches
+// simultaneous.ErrTimeout. A timeout of zero or less is the same as
+// TryAcquire.
+func (l *Limit[T]) AcquireTimeout(ctx context.Context, timeout time.Duration) (simultaneous.Limited[T], error) {
+	if timeout <= 0 {
+		done, ok, err := l.TryAcquire(ctx)
+		if err == nil && !ok {
+			err = l.timeoutError(timeout)
+		}
+		return done, err
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done, err := l.Acquire(waitCtx)
+	if err != nil && ctx.Err() == nil && waitCtx.Err() != nil {
+		return simultaneous.NotHeld[T](), l.timeoutError(timeout)
+	}
+	return done, err
+}
+
+func (l *Limit[T]) timeoutError(timeout time.Duration) error {
+	return simultaneous.ErrTimeout.Errorf("timeout (%s) expired before any distributed slot (of %d) for %s became available", timeout, l.limit, l.name)
+}
+
+var _ simultaneous.Limiter[any] = &Limit[any]{}
+
+// InUse returns the number of unexpired leases
+func (l *Limit[T]) InUse(ctx context.Context) (int, error) {
+	var n int
+	err := l.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+l.quotedTable()+`
+		WHERE name = ? AND slot < ? AND expires >= NOW(6)`, l.name, l.limit).Scan(&n)
+	return n, errors.Wrapf(err, "count leases for %s", l.name)
+}
+
+func (l *Limit[T]) quotedTable() string {
+	return "`" + strings.ReplaceAll(l.table, "`", "``") + "`"
+}
+
+// ensureSlots makes sure that there is a row for every slot. Once that
+// has succeeded, it does nothing.
+func (l *Limit[T]) ensureSlots(ctx context.Context) error {
+	if l.limit <= 0 {
+		return nil
+	}
+	l.slots.lock.Lock()
+	defer l.slots.lock.Unlock()
+	if l.slots.created {
+		return nil
+	}
+	values := make([]string, l.limit)
+	args := make([]any, 0, 2*l.limit)
+	for i := range values {
+		values[i] = "(?, ?)"
+		args = append(args, l.name, i)
+	}
+	_, err := l.db.ExecContext(ctx, `INSERT IGNORE INTO `+l.quotedTable()+` (name, slot) VALUES `+strings.Join(values, ", "), args...)
+	if err != nil {
+		return errors.Wrapf(err, "create slots for %s", l.name)
+	}
+	l.slots.created = true
+	return nil
+}
@@ -0,0 +1,185 @@
+package distributed_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+	"github.com/singlestore-labs/simultaneous/distributed"
+)
+
+// openDB connects to the database named by SIMULTANEOUS_TEST_DSN, for
+// example "root:password@tcp(127.0.0.1:3306)/test". If it is not set,
+// an in-memory fake is used instead.
+func openDB(t *testing.T) *sql.DB {
+	dsn := os.Getenv("SIMULTANEOUS_TEST_DSN")
+	if dsn == "" {
+		db, _ := newFakeDB()
+		return db
+	}
+	db, err := sql.Open("mysql", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func newLimit(t *testing.T, db *sql.DB, limit int) *distributed.Limit[any] {
+	l := distributed.New[any](db, fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano()), limit).
+		WithLeaseTTL(time.Second).
+		WithPollInterval(10 * time.Millisecond)
+	require.NoError(t, l.CreateTable(context.Background()))
+	return l
+}
+
+func TestDistributed(t *testing.T) {
+	t.Parallel()
+	db := openDB(t)
+
+	const limit = 3
+	// two Limits with the same name stand in for two processes
+	a := newLimit(t, db, limit)
+	b := a.WithPollInterval(20 * time.Millisecond)
+
+	var running atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		l := a
+		if i%2 == 1 {
+			l = b
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := l.Acquire(context.Background())
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer done.Done()
+			assert.LessOrEqual(t, running.Add(1), int32(limit))
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	inUse, err := a.InUse(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, inUse)
+}
+
+func TestDistributedTryAcquire(t *testing.T) {
+	t.Parallel()
+	db := openDB(t)
+
+	l := newLimit(t, db, 1)
+	done, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+	var _ simultaneous.Enforced[any] = done
+
+	_, ok, err := l.TryAcquire(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = l.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = l.AcquireTimeout(context.Background(), 50*time.Millisecond)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+	_, err = l.AcquireTimeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+
+	done.Done()
+	done, ok, err = l.TryAcquire(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok)
+	done.Done()
+}
+
+func TestDistributedRenewal(t *testing.T) {
+	t.Parallel()
+	db := openDB(t)
+
+	l := newLimit(t, db, 1)
+	done, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+
+	// held well past the TTL: the heartbeat keeps the lease alive
+	time.Sleep(2500 * time.Millisecond)
+	_, ok, err := l.TryAcquire(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ok)
+	done.Done()
+}
+
+func TestDistributedStatements(t *testing.T) {
+	t.Parallel()
+	db, fake := newFakeDB()
+
+	l := newLimit(t, db, 3)
+	var held []simultaneous.Limited[any]
+	for i := 0; i < 3; i++ {
+		done, err := l.Acquire(context.Background())
+		require.NoError(t, err)
+		held = append(held, done)
+	}
+	inserts, claims := fake.counts()
+	assert.Equal(t, 1, inserts, "slots are created once")
+	assert.Equal(t, 3, claims, "one statement per lease")
+
+	_, ok, err := l.TryAcquire(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, claims = fake.counts()
+	assert.Equal(t, 4, claims, "one statement to find that no slot is free")
+
+	for _, done := range held {
+		done.Done()
+	}
+	inUse, err := l.InUse(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, inUse)
+}
+
+func TestDistributedFailureNotHeld(t *testing.T) {
+	t.Parallel()
+	db := openDB(t)
+
+	l := newLimit(t, db, 1)
+	held, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+	defer held.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done, err := l.Acquire(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotPanics(t, done.Done, "Done is a no-op")
+	done, err = l.AcquireTimeout(context.Background(), 10*time.Millisecond)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+	assert.NotPanics(t, done.Done, "Done is a no-op")
+}
+
+func TestDistributedZeroTTL(t *testing.T) {
+	t.Parallel()
+	db := openDB(t)
+
+	// a TTL too short to renew is raised to MinLeaseTTL rather than
+	// stopping the heartbeat with a panic
+	l := newLimit(t, db, 1).WithLeaseTTL(0)
+	done, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+	time.Sleep(10 * distributed.MinLeaseTTL)
+	done.Done()
+}
@@ -0,0 +1,29 @@
+package simultaneous
+
+import (
+	"context"
+)
+
+// enforcedKey is distinct for each T so that contexts can carry proof of
+// several different limits at once
+type enforcedKey[T any] struct{}
+
+// ContextWithEnforced returns a context that carries proof that a limit of
+// type T is being enforced. It lets the proof travel through layers that
+// only pass a context.Context rather than adding an Enforced[T] parameter
+// to every function along the way. Typically the Limited returned by
+// Forever or Acquire is the Enforced.
+//
+// The context does not hold the space: once Done is called, the proof in
+// the context is stale. Derive the context in the same scope that calls
+// Done.
+func ContextWithEnforced[T any](ctx context.Context, enforced Enforced[T]) context.Context {
+	return context.WithValue(ctx, enforcedKey[T]{}, enforced)
+}
+
+// EnforcedFromContext returns the Enforced[T] added by ContextWithEnforced.
+// It returns false if there is none.
+func EnforcedFromContext[T any](ctx context.Context) (Enforced[T], bool) {
+	enforced, ok := ctx.Value(enforcedKey[T]{}).(Enforced[T])
+	return enforced, ok
+}
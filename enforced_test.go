@@ -0,0 +1,44 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+type (
+	diskLimit    struct{}
+	networkLimit struct{}
+)
+
+func TestContextWithEnforced(t *testing.T) {
+	t.Parallel()
+
+	_, ok := simultaneous.EnforcedFromContext[diskLimit](context.Background())
+	assert.False(t, ok)
+
+	disk := simultaneous.New[diskLimit](1)
+	network := simultaneous.New[networkLimit](1)
+	diskDone := disk.Forever(context.Background())
+	defer diskDone.Done()
+	networkDone := network.Forever(context.Background())
+	defer networkDone.Done()
+
+	ctx := simultaneous.ContextWithEnforced[diskLimit](context.Background(), diskDone)
+	ctx = simultaneous.ContextWithEnforced[networkLimit](ctx, networkDone)
+
+	enforced, ok := simultaneous.EnforcedFromContext[diskLimit](ctx)
+	assert.True(t, ok)
+	assert.Equal(t, simultaneous.Enforced[diskLimit](diskDone), enforced)
+	_, ok = simultaneous.EnforcedFromContext[networkLimit](ctx)
+	assert.True(t, ok)
+	_, ok = simultaneous.EnforcedFromContext[any](ctx)
+	assert.False(t, ok, "other types")
+
+	ctx = simultaneous.ContextWithEnforced(ctx, simultaneous.Unlimited[any]())
+	_, ok = simultaneous.EnforcedFromContext[any](ctx)
+	assert.True(t, ok, "Unlimited")
+}
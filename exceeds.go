@@ -0,0 +1,54 @@
+package simultaneous
+
+import (
+	"github.com/memsql/errors"
+)
+
+// ErrExceedsCapacity is returned when more space is requested than the
+// capacity of the Limit, so that it could never be granted. A Limit with
+// a capacity of zero is treated as paused rather than as too small.
+var ErrExceedsCapacity errors.String = "simultaneous limit request exceeds capacity"
+
+func (l *Limit[T]) exceedsCapacityError(n int64) error {
+	return ErrExceedsCapacity.Errorf("request for %d exceeds the capacity of simultaneous limit (of %d)", n, l.capacity())
+}
+
+// tooLarge returns true if n units of space can never be granted at once
+// in the sub-limit, if it is not nil, at the current capacity, counting
+// any burst. A capacity of zero is treated like a pause: the capacity
+// may be raised again, so nothing is too large for it. Must be called
+// with the lock held.
+func (c *core) tooLarge(n int64, sub *subLimit) bool {
+	largest := c.size.Load()
+	if largest <= 0 {
+		return false
+	}
+	if b := c.burst.Load(); b != nil {
+		largest += b.extra
+	}
+	for s := sub; s != nil; s = s.parent {
+		if s.size <= 0 {
+			return false
+		}
+		if s.size < largest {
+			largest = s.size
+		}
+	}
+	return n > largest
+}
+
+// failTooLarge makes the waiters that want more space than can be granted
+// at once fail. It is called, with the lock held, only after the capacity
+// shrinks, so that raising the capacity from zero does not fail anyone.
+func (c *core) failTooLarge() {
+	for e := c.waiters.Front(); e != nil; {
+		w := e.Value.(*waiter)
+		e = e.Next()
+		if c.tooLarge(w.n, w.sub) {
+			c.remove(w)
+			w.closed = true
+			w.tooLarge = true
+			close(w.ready)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestExceedsCapacity(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](4)
+	ctx := context.Background()
+	done, err := limit.AcquireN(ctx, 5)
+	require.ErrorIs(t, err, simultaneous.ErrExceedsCapacity)
+	assert.Contains(t, err.Error(), "request for 5")
+	assert.Contains(t, err.Error(), "(of 4)")
+	assert.NotPanics(t, done.Done)
+	assert.Equal(t, 0, limit.Waiting())
+	assert.Equal(t, 0, limit.InUse())
+
+	done, err = limit.AcquireN(ctx, 4)
+	require.NoError(t, err, "all of the capacity")
+	done.Done()
+
+	child := limit.Child(2)
+	_, err = child.AcquireN(ctx, 3)
+	assert.ErrorIs(t, err, simultaneous.ErrExceedsCapacity, "capacity of the child")
+}
+
+func TestExceedsCapacityAfterResize(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](4)
+	ctx := context.Background()
+	held, err := limit.AcquireN(ctx, 2)
+	require.NoError(t, err)
+
+	type result struct {
+		done simultaneous.Limited[any]
+		err  error
+	}
+	big := make(chan result)
+	small := make(chan result)
+	go func() {
+		done, err := limit.AcquireN(ctx, 4)
+		big <- result{done: done, err: err}
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	go func() {
+		done, err := limit.AcquireN(ctx, 3)
+		small <- result{done: done, err: err}
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 2 }, time.Second, time.Millisecond)
+
+	limit.SetLimit(3)
+	r := <-big
+	require.ErrorIs(t, r.err, simultaneous.ErrExceedsCapacity)
+	assert.Contains(t, r.err.Error(), "request for 4")
+	assert.NotPanics(t, r.done.Done)
+	assert.Equal(t, 1, limit.Waiting(), "the request that still fits keeps waiting")
+
+	held.Done()
+	r = <-small
+	require.NoError(t, r.err)
+	r.done.Done()
+	assert.Equal(t, 0, limit.InUse())
+}
+
+func TestExceedsCapacityZero(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	ctx := context.Background()
+	limit.SetLimit(0)
+	big := make(chan error, 1)
+	go func() {
+		done, err := limit.AcquireN(ctx, 2)
+		if err == nil {
+			defer done.Done()
+		}
+		big <- err
+	}()
+	forever := make(chan simultaneous.Limited[any], 1)
+	go func() {
+		forever <- limit.Forever(ctx)
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 2 }, time.Second, time.Millisecond)
+	select {
+	case <-forever:
+		require.Fail(t, "Forever returned while the capacity is zero")
+	case err := <-big:
+		require.Failf(t, "AcquireN returned while the capacity is zero", "%v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	limit.SetLimit(1)
+	done := <-forever
+	assert.Equal(t, 1, limit.InUse(), "Forever holds space")
+	assert.Equal(t, 1, limit.Waiting(), "the request for 2 keeps waiting")
+	done.Done()
+
+	limit.SetLimit(2)
+	assert.NoError(t, <-big)
+}
@@ -0,0 +1,22 @@
+package simultaneous
+
+import (
+	"expvar"
+)
+
+// StatsSource is implemented by *Limit[T] and *AdaptiveLimit[T] for any T
+type StatsSource interface {
+	Stats() Stats
+}
+
+// PublishExpvar publishes the Stats of a Limit with expvar under name so
+// that they are served, as JSON, by /debug/vars. The Stats are taken
+// whenever the variable is read. Like expvar.Publish, it panics if name
+// is already in use.
+//
+//	simultaneous.PublishExpvar("alter-limit", limit)
+func PublishExpvar(name string, limit StatsSource) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return limit.Stats()
+	}))
+}
@@ -0,0 +1,38 @@
+package simultaneous_test
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+// expvarNames makes expvar names unique when tests are run more than
+// once in the same process
+var expvarNames atomic.Int64
+
+func TestPublishExpvar(t *testing.T) {
+	t.Parallel()
+
+	name := fmt.Sprintf("%s-%d", t.Name(), expvarNames.Add(1))
+	limit := simultaneous.New[any](4)
+	simultaneous.PublishExpvar(name, limit)
+	held := limit.Forever(context.Background())
+	defer held.Done()
+
+	v := expvar.Get(name)
+	require.NotNil(t, v)
+	var stats map[string]any
+	require.NoError(t, json.Unmarshal([]byte(v.String()), &stats))
+	assert.Equal(t, float64(4), stats["capacity"])
+	assert.Equal(t, float64(1), stats["in_use"])
+
+	assert.Panics(t, func() { simultaneous.PublishExpvar(name, limit) })
+}
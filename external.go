@@ -0,0 +1,88 @@
+package simultaneous
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// External is space held in a limit that is enforced somewhere other than
+// in this process, for example by a database shared by many processes.
+// Adopt turns it into a Limited so that code that takes an Enforced does
+// not need to know where the limit is enforced.
+type External interface {
+	// Release gives up the space
+	Release()
+	// Reacquire waits to get the space back after Release. If it returns
+	// an error, the space is not held.
+	Reacquire(context.Context) error
+}
+
+// Adopt returns a Limited that holds the space held by an External. Done
+// calls Release. Yield calls Release and then Reacquire.
+func Adopt[T any](e External) Limited[T] {
+	return &adopted[T]{
+		external: e,
+		held:     true,
+	}
+}
+
+// NotHeld returns a Limited that does not hold any space: its methods do
+// nothing. It is what this package returns along with an error when
+// space is not obtained, so that callers can always call Done. Limiters
+// outside this package that use Adopt can return it for the same reason.
+func NotHeld[T any]() Limited[T] {
+	return limited[T](nil)
+}
+
+type adopted[T any] struct {
+	external External
+	lock     sync.Mutex
+	held     bool
+}
+
+var _ Limited[any] = &adopted[any]{}
+
+func (a *adopted[T]) privateMethod() {}
+
+// take marks the space as no longer held. It returns false if it was
+// not held.
+func (a *adopted[T]) take() bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	held := a.held
+	a.held = false
+	return held
+}
+
+func (a *adopted[T]) Done() {
+	if a.take() {
+		a.external.Release()
+	}
+}
+
+func (a *adopted[T]) Yield(ctx context.Context) error {
+	if !a.take() {
+		return nil
+	}
+	a.external.Release()
+	if err := a.external.Reacquire(ctx); err != nil {
+		return err
+	}
+	a.lock.Lock()
+	a.held = true
+	a.lock.Unlock()
+	return nil
+}
+
+// WaitDuration is not known for space held outside of this package
+func (a *adopted[T]) WaitDuration() time.Duration { return 0 }
+
+func (a *adopted[T]) Transfer() *TransferTicket[T] {
+	if !a.take() {
+		return &TransferTicket[T]{}
+	}
+	return &TransferTicket[T]{
+		external: a.external,
+	}
+}
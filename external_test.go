@@ -0,0 +1,64 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/memsql/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+type fakeExternal struct {
+	held       int
+	reacquired int
+	fail       error
+}
+
+func (f *fakeExternal) Release() { f.held-- }
+
+func (f *fakeExternal) Reacquire(context.Context) error {
+	if f.fail != nil {
+		return f.fail
+	}
+	f.reacquired++
+	f.held++
+	return nil
+}
+
+func TestAdopt(t *testing.T) {
+	t.Parallel()
+
+	e := &fakeExternal{held: 1}
+	done := simultaneous.Adopt[any](e)
+	var _ simultaneous.Enforced[any] = done
+
+	assert.NoError(t, done.Yield(context.Background()))
+	assert.Equal(t, 1, e.held)
+	assert.Equal(t, 1, e.reacquired)
+
+	ticket := done.Transfer()
+	done.Done()
+	assert.Equal(t, 1, e.held, "Done after Transfer")
+
+	claimed := ticket.Claim()
+	assert.Equal(t, 1, e.held, "claim")
+	claimed.Done()
+	claimed.Done()
+	assert.Equal(t, 0, e.held)
+	ticket.Release()
+	assert.Equal(t, 0, e.held, "ticket already claimed")
+}
+
+func TestAdoptYieldFails(t *testing.T) {
+	t.Parallel()
+
+	errLost := errors.New("lost")
+	e := &fakeExternal{held: 1, fail: errLost}
+	done := simultaneous.Adopt[any](e)
+	assert.ErrorIs(t, done.Yield(context.Background()), errLost)
+	assert.Equal(t, 0, e.held)
+	done.Done()
+	assert.Equal(t, 0, e.held, "Done after failed Yield")
+}
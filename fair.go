@@ -0,0 +1,88 @@
+package simultaneous
+
+import (
+	"sort"
+)
+
+// WithFairShare makes the Limit share its space fairly between its
+// Children when they are waiting for it. Instead of granting space in
+// the order it was asked for, each grant goes to a waiter of the Child
+// that holds the least space relative to its weight (see SetWeight), so
+// a Child with many waiters cannot crowd out the others. Waiters of the
+// same Child are granted space in the order they arrived. Waiters of
+// the Limit itself, rather than of a Child, count as holding nothing.
+//
+// Priorities are still honored first; fair sharing replaces the order
+// from WithFIFO and WithLIFO. KeyedLimits created with NewKeyedTotal
+// share fairly between keys.
+//
+// WithFairShare changes the Limit and all of its copies. It returns the
+// Limit so that it can be chained with New.
+func (l *Limit[T]) WithFairShare() *Limit[T] {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	l.core.fair = true
+	l.core.grant()
+	return l
+}
+
+// WithFairShare shares space fairly between Children. See the
+// WithFairShare method.
+func WithFairShare() Option {
+	return func(s *state) {
+		s.core.fair = true
+	}
+}
+
+// SetWeight sets the weight of a Child for WithFairShare. A Child with
+// a weight of 2 is granted twice as much of the contended space as one
+// with a weight of 1, which is the default. Weights that are not
+// positive are treated as 1. SetWeight does nothing if the Limit is not
+// a Child.
+func (l *Limit[T]) SetWeight(weight float64) {
+	if l.sub == nil {
+		return
+	}
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	l.sub.weight = weight
+	l.core.grant()
+}
+
+// share is how much space the sub-limit holds relative to its weight.
+// Must be called with the lock held.
+func (s *subLimit) share() float64 {
+	if s == nil {
+		return 0
+	}
+	if s.weight <= 0 {
+		return float64(s.used)
+	}
+	return float64(s.used) / s.weight
+}
+
+// grantFair is grant for WithFairShare. After each grant the shares
+// change so the waiters are sorted again. Must be called with the lock
+// held.
+func (c *core) grantFair() {
+	waiters := make([]*waiter, 0, c.waiters.Len())
+	for c.used.Load() < c.sizeNow() {
+		waiters = waiters[:0]
+		for e := c.waiters.Front(); e != nil; e = e.Next() {
+			waiters = append(waiters, e.Value.(*waiter))
+		}
+		sort.SliceStable(waiters, func(i, j int) bool {
+			return waiters[i].sub.share() < waiters[j].sub.share()
+		})
+		granted := false
+		for _, w := range waiters {
+			if c.grantOne(w) {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			return
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestFairShareWeights(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](4, simultaneous.WithFairShare())
+	a := limit.Child(10)
+	a.SetWeight(3)
+	b := limit.Child(10)
+	ctx := context.Background()
+
+	var held []simultaneous.Limited[any]
+	for i := 0; i < 4; i++ {
+		held = append(held, limit.Forever(ctx))
+	}
+	got := make(chan simultaneous.Limited[any], 8)
+	for _, child := range []*simultaneous.Limit[any]{a, b} {
+		for i := 0; i < 4; i++ {
+			go func(child *simultaneous.Limit[any]) { got <- child.Forever(ctx) }(child)
+		}
+		waiting := limit.Waiting() + 4
+		require.Eventually(t, func() bool { return limit.Waiting() == waiting }, time.Second, time.Millisecond)
+	}
+
+	for _, done := range held {
+		done.Done()
+	}
+	held = held[:0]
+	for i := 0; i < 4; i++ {
+		held = append(held, <-got)
+	}
+	assert.Equal(t, 3, a.InUse(), "three quarters of the space")
+	assert.Equal(t, 1, b.InUse())
+
+	for i := 0; i < 4; i++ {
+		held[i].Done()
+		held = append(held, <-got)
+	}
+	for _, done := range held[4:] {
+		done.Done()
+	}
+	assert.Zero(t, limit.InUse())
+}
+
+func TestKeyedLimitFairShare(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.NewKeyedTotal[string, any](10, 2)
+	ctx := context.Background()
+	n1 := limit.Forever(ctx, "noisy")
+	n2 := limit.Forever(ctx, "noisy")
+
+	noisy := make(chan simultaneous.Limited[any], 5)
+	for i := 0; i < 5; i++ {
+		go func() { noisy <- limit.Forever(ctx, "noisy") }()
+	}
+	require.Eventually(t, func() bool { return limit.Total().Waiting() == 5 }, time.Second, time.Millisecond)
+	quiet := make(chan simultaneous.Limited[any])
+	go func() { quiet <- limit.Forever(ctx, "quiet") }()
+	require.Eventually(t, func() bool { return limit.Total().Waiting() == 6 }, time.Second, time.Millisecond)
+
+	n1.Done()
+	q := <-quiet
+	assert.Equal(t, 5, limit.Total().Waiting(), "quiet went ahead of the earlier noisy waiters")
+
+	q.Done()
+	n2.Done()
+	for i := 0; i < 5; i++ {
+		(<-noisy).Done()
+	}
+	assert.Zero(t, limit.Total().InUse())
+}
@@ -0,0 +1,124 @@
+package simultaneous
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FanOutOption configures Map, ForEach, MapChan, MapSeq, and NewStage
+type FanOutOption func(*fanOutConfig)
+
+type fanOutConfig struct {
+	collect   bool
+	unordered bool // only for MapChan and MapSeq
+}
+
+// CollectErrors makes Map and ForEach run fn for every input even if
+// some fail. The errors are returned as FanOutErrors.
+func CollectErrors() FanOutOption {
+	return func(c *fanOutConfig) {
+		c.collect = true
+	}
+}
+
+// FanOutErrors is the error returned by Map and ForEach with
+// CollectErrors when fn failed for any input. It has the error for each
+// input, in the same order, with nil for inputs that succeeded.
+type FanOutErrors []error
+
+func (e FanOutErrors) Error() string {
+	var first error
+	var failed int
+	for _, err := range e {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d of %d failed, the first with: %s", failed, len(e), first)
+}
+
+// Unwrap returns the errors that are not nil so that errors.Is and
+// errors.As look at all of them
+func (e FanOutErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Map runs fn for each input, each in its own goroutine while holding
+// space in the Limit, and returns the outputs in the same order as the
+// inputs. Like Group, the first error cancels the context given to the
+// other calls of fn, inputs that have not started are skipped, and the
+// first error is returned. With CollectErrors, every input is run and
+// the error is a FanOutErrors. Outputs of inputs that failed or were
+// skipped are zero.
+//
+//	sizes, err := simultaneous.Map(ctx, limit, paths, func(ctx context.Context, _ simultaneous.Enforced[fileLimit], path string) (int64, error) {
+//		return fileSize(ctx, path)
+//	})
+func Map[T any, In any, Out any](ctx context.Context, limit *Limit[T], inputs []In, fn func(context.Context, Enforced[T], In) (Out, error), opts ...FanOutOption) ([]Out, error) {
+	outputs := make([]Out, len(inputs))
+	err := fanOut(ctx, limit, len(inputs), func(ctx context.Context, enforced Enforced[T], i int) error {
+		out, err := fn(ctx, enforced, inputs[i])
+		if err == nil {
+			outputs[i] = out
+		}
+		return err
+	}, opts)
+	return outputs, err
+}
+
+// ForEach is like Map for functions that do not produce output
+func ForEach[T any, In any](ctx context.Context, limit *Limit[T], inputs []In, fn func(context.Context, Enforced[T], In) error, opts ...FanOutOption) error {
+	return fanOut(ctx, limit, len(inputs), func(ctx context.Context, enforced Enforced[T], i int) error {
+		return fn(ctx, enforced, inputs[i])
+	}, opts)
+}
+
+// fanOut runs fn for 0 through n-1
+func fanOut[T any](ctx context.Context, limit *Limit[T], n int, fn func(context.Context, Enforced[T], int) error, opts []FanOutOption) error {
+	var config fanOutConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if !config.collect {
+		group, ctx := NewGroup(ctx, limit)
+		for i := 0; i < n; i++ {
+			i := i
+			group.Go(func(enforced Enforced[T]) error {
+				return fn(ctx, enforced, i)
+			})
+		}
+		return group.Wait()
+	}
+	errs := make(FanOutErrors, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		done, err := limit.Acquire(ctx)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer done.Done()
+			errs[i] = fn(ctx, done, i)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return errs
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package simultaneous_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/memsql/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestMap(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](3)
+	var running, most atomic.Int32
+	inputs := []int{5, 4, 3, 2, 1, 0, 9, 8}
+	outputs, err := simultaneous.Map(context.Background(), limit, inputs, func(_ context.Context, _ simultaneous.Enforced[any], in int) (string, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := most.Load()
+			if n <= m || most.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Duration(in) * time.Millisecond)
+		return fmt.Sprint(in), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5", "4", "3", "2", "1", "0", "9", "8"}, outputs, "in input order")
+	assert.LessOrEqual(t, most.Load(), int32(3))
+}
+
+func TestMapFirstError(t *testing.T) {
+	t.Parallel()
+
+	const failed errors.String = "failed"
+	limit := simultaneous.New[any](1)
+	var ran atomic.Int32
+	err := simultaneous.ForEach(context.Background(), limit, []int{0, 1, 2, 3}, func(ctx context.Context, _ simultaneous.Enforced[any], in int) error {
+		ran.Add(1)
+		if in == 1 {
+			return failed.Errorf("input %d", in)
+		}
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, err, failed)
+	assert.Equal(t, int32(2), ran.Load(), "later inputs skipped")
+}
+
+func TestMapCollectErrors(t *testing.T) {
+	t.Parallel()
+
+	const failed errors.String = "failed"
+	limit := simultaneous.New[any](2)
+	outputs, err := simultaneous.Map(context.Background(), limit, []int{0, 1, 2, 3}, func(_ context.Context, _ simultaneous.Enforced[any], in int) (int, error) {
+		if in%2 == 1 {
+			return in, failed.Errorf("input %d", in)
+		}
+		return in * 10, nil
+	}, simultaneous.CollectErrors())
+	assert.ErrorIs(t, err, failed)
+	var errs simultaneous.FanOutErrors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 4)
+	assert.NoError(t, errs[0])
+	assert.Error(t, errs[1])
+	assert.NoError(t, errs[2])
+	assert.Error(t, errs[3])
+	assert.Equal(t, []int{0, 0, 20, 0}, outputs)
+	assert.Contains(t, err.Error(), "2 of 4 failed")
+}
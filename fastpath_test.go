@@ -0,0 +1,107 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestForeverAllocations(t *testing.T) {
+	if debugBuild {
+		t.Skip("simultaneous_debug tracks every holder")
+	}
+	limit := simultaneous.New[any](1)
+	ctx := context.Background()
+	allocs := testing.AllocsPerRun(100, func() {
+		limit.Forever(ctx).Done()
+	})
+	assert.LessOrEqual(t, allocs, float64(1), "one token per acquisition")
+}
+
+func TestForeverAllocatedBytes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs a benchmark")
+	}
+	if debugBuild {
+		t.Skip("simultaneous_debug tracks every holder")
+	}
+	result := testing.Benchmark(BenchmarkForever)
+	assert.LessOrEqual(t, result.AllocedBytesPerOp(), int64(32), "a small token per acquisition")
+}
+
+// countingClock counts how often the time is read
+type countingClock struct {
+	*fakeClock
+	nows atomic.Int64
+}
+
+func (c *countingClock) Now() time.Time {
+	c.nows.Add(1)
+	return c.fakeClock.Now()
+}
+
+func TestFastPathNoClock(t *testing.T) {
+	t.Parallel()
+
+	clock := &countingClock{fakeClock: newFakeClock()}
+	limit := simultaneous.New[any](1, simultaneous.WithClock(clock))
+	ctx := context.Background()
+	limit.Forever(ctx).Done()
+	done, err := limit.Acquire(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, done.WaitDuration())
+	done.Done()
+	done, err = limit.Timeout(ctx, time.Second)
+	require.NoError(t, err)
+	done.Done()
+	done, ok := limit.TryAcquireN(1)
+	require.True(t, ok)
+	done.Done()
+	assert.Zero(t, clock.nows.Load(), "the clock is only read when waiting")
+}
+
+func TestFastPathContention(t *testing.T) {
+	t.Parallel()
+
+	const size = 3
+	limit := simultaneous.New[any](size)
+	ctx := context.Background()
+	var lock sync.Mutex
+	var current, most int
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				done := limit.Forever(ctx)
+				lock.Lock()
+				current++
+				if current > most {
+					most = current
+				}
+				lock.Unlock()
+				lock.Lock()
+				current--
+				lock.Unlock()
+				done.Done()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, most, size)
+	assert.Zero(t, limit.InUse())
+	assert.Zero(t, limit.Waiting())
+	assert.Equal(t, uint64(20*200), limit.Stats().Acquisitions)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	require.NoError(t, limit.WaitForIdle(ctx))
+}
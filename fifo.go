@@ -0,0 +1,23 @@
+package simultaneous
+
+// WithFIFO makes the Limit strictly first-in, first-out: space is granted
+// only in the order that it was asked for. A new arrival waits if anyone
+// else is already waiting, even if there is space for it, and a waiter
+// that wants more space than is available holds up everyone behind it.
+//
+// Without WithFIFO, acquisitions of a single unit are also granted in the
+// order they arrived, but requests from AcquireN that don't fit in the
+// available space are passed by smaller requests that do. That keeps
+// capacity busy at the risk of starving large requests, which
+// WithAccumulatingReservation prevents without making the Limit strictly
+// first-in, first-out.
+//
+// WithFIFO changes the Limit and all of its copies. It returns the Limit
+// so that it can be chained with New.
+func (l *Limit[T]) WithFIFO() *Limit[T] {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	l.core.fifo = true
+	l.core.updateSlow()
+	return l
+}
@@ -0,0 +1,107 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestArrivalOrder(t *testing.T) {
+	t.Parallel()
+
+	for _, fifo := range []bool{false, true} {
+		limit := simultaneous.New[any](1)
+		if fifo {
+			limit = limit.WithFIFO()
+		}
+		held := limit.Forever(context.Background())
+
+		var lock sync.Mutex
+		var order []int
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer limit.Forever(context.Background()).Done()
+				lock.Lock()
+				order = append(order, i)
+				lock.Unlock()
+			}()
+			assert.Eventually(t, func() bool { return limit.Waiting() == i+1 }, time.Second, time.Millisecond)
+		}
+		held.Done()
+		wg.Wait()
+		assert.Equal(t, []int{0, 1, 2, 3, 4}, order, "fifo %v", fifo)
+	}
+}
+
+func TestFIFOWeighted(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](4).WithFIFO()
+	held, err := limit.AcquireN(context.Background(), 3)
+	require.NoError(t, err)
+
+	big := make(chan simultaneous.Limited[any])
+	go func() {
+		done, err := limit.AcquireN(context.Background(), 4)
+		assert.NoError(t, err)
+		big <- done
+	}()
+	assert.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+
+	_, ok := limit.TryAcquireN(1)
+	assert.False(t, ok, "may not pass the big waiter")
+	small := make(chan simultaneous.Limited[any])
+	go func() {
+		done, err := limit.AcquireN(context.Background(), 1)
+		assert.NoError(t, err)
+		small <- done
+	}()
+	assert.Eventually(t, func() bool { return limit.Waiting() == 2 }, time.Second, time.Millisecond)
+
+	held.Done()
+	bigDone := <-big
+	select {
+	case <-small:
+		assert.Fail(t, "small granted while big holds everything")
+	case <-time.After(10 * time.Millisecond):
+	}
+	bigDone.Done()
+	(<-small).Done()
+	assert.Equal(t, 0, limit.InUse())
+}
+
+func TestFIFOCancelledHead(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2).WithFIFO()
+	held, err := limit.AcquireN(context.Background(), 1)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bigErr := make(chan error)
+	go func() {
+		_, err := limit.AcquireN(ctx, 2)
+		bigErr <- err
+	}()
+	assert.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	small := make(chan simultaneous.Limited[any])
+	go func() {
+		small <- limit.Forever(context.Background())
+	}()
+	assert.Eventually(t, func() bool { return limit.Waiting() == 2 }, time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-bigErr, context.Canceled)
+	(<-small).Done()
+	held.Done()
+}
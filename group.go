@@ -0,0 +1,69 @@
+package simultaneous
+
+import (
+	"context"
+	"sync"
+)
+
+// Group runs functions in goroutines, with each goroutine holding space
+// in a Limit while it runs. It is like errgroup.Group: the first error
+// cancels the Group's context and is returned by Wait.
+type Group[T any] struct {
+	limit   *Limit[T]
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+}
+
+// NewGroup returns a Group and a context derived from ctx. The context
+// is cancelled when a function run by the Group returns an error or when
+// Wait returns, whichever comes first.
+func NewGroup[T any](ctx context.Context, limit *Limit[T]) (*Group[T], context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group[T]{
+		limit:  limit,
+		ctx:    ctx,
+		cancel: cancel,
+	}, ctx
+}
+
+// Go waits for space in the Limit and then runs fn in a new goroutine.
+// The space is released when fn returns. If the Group's context is
+// cancelled before space is available, fn is not run; if no function has
+// failed yet, the cancellation becomes the Group's error.
+func (g *Group[T]) Go(fn func(Enforced[T]) error) {
+	if err := g.ctx.Err(); err != nil {
+		g.fail(err)
+		return
+	}
+	done, err := g.limit.Acquire(g.ctx)
+	if err != nil {
+		g.fail(err)
+		return
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer done.Done()
+		if err := fn(done); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+// Wait waits for all functions started by Go to return. It returns the
+// first error, if any.
+func (g *Group[T]) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+func (g *Group[T]) fail(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel()
+	})
+}
@@ -0,0 +1,56 @@
+package simultaneous_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](3)
+	group, ctx := simultaneous.NewGroup(context.Background(), limit)
+	var running, ran atomic.Int32
+	for i := 0; i < 20; i++ {
+		group.Go(func(simultaneous.Enforced[any]) error {
+			assert.LessOrEqual(t, running.Add(1), int32(3))
+			time.Sleep(sleep)
+			running.Add(-1)
+			ran.Add(1)
+			return nil
+		})
+	}
+	assert.NoError(t, group.Wait())
+	assert.Equal(t, int32(20), ran.Load())
+	assert.Error(t, ctx.Err(), "cancelled by Wait")
+	assert.Equal(t, 0, limit.InUse())
+}
+
+func TestGroupError(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	group, ctx := simultaneous.NewGroup(context.Background(), limit)
+	errFirst := fmt.Errorf("first")
+	group.Go(func(simultaneous.Enforced[any]) error {
+		return errFirst
+	})
+	var ran atomic.Int32
+	for i := 0; i < 5; i++ {
+		group.Go(func(simultaneous.Enforced[any]) error {
+			ran.Add(1)
+			<-ctx.Done()
+			return fmt.Errorf("later")
+		})
+	}
+	assert.ErrorIs(t, group.Wait(), errFirst)
+	assert.Zero(t, ran.Load(), "nothing runs after the group is cancelled")
+	assert.Equal(t, 0, limit.InUse())
+}
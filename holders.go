@@ -0,0 +1,118 @@
+package simultaneous
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HolderInfo describes space that is held in a Limit
+type HolderInfo struct {
+	Acquired    time.Time // when the space was obtained
+	Transferred time.Time // when the space was last claimed from a TransferTicket, zero if never
+	Units       int64     // units of space held
+	Label       string    // label given when the space was obtained, if any
+	Stack       []byte    // stack trace of where the space was obtained, or claimed
+}
+
+// holders are the holders of space in a Limit. They are shared by all
+// copies of the Limit.
+type holders struct {
+	lock    sync.Mutex
+	holders map[*HolderInfo]struct{}
+}
+
+// WithHolderTracking returns a modified Limit that keeps track of who is
+// holding space so that Holders can report it. It records a stack trace for
+// every acquisition so it is relatively expensive. Space carried by a
+// TransferTicket is not listed until the ticket is claimed.
+func (l Limit[T]) WithHolderTracking() *Limit[T] {
+	l.holders = &holders{
+		holders: make(map[*HolderInfo]struct{}),
+	}
+	return &l
+}
+
+// WithHolderTracking keeps track of who is holding space. See the
+// WithHolderTracking method.
+func WithHolderTracking() Option {
+	return func(s *state) {
+		s.holders = &holders{
+			holders: make(map[*HolderInfo]struct{}),
+		}
+	}
+}
+
+// Holders returns the current holders of space, oldest first. It returns
+// nil unless the Limit was created with WithHolderTracking.
+func (l *Limit[T]) Holders() []HolderInfo {
+	if l.holders == nil {
+		return nil
+	}
+	l.holders.lock.Lock()
+	all := make([]HolderInfo, 0, len(l.holders.holders))
+	for h := range l.holders.holders {
+		all = append(all, *h)
+	}
+	l.holders.lock.Unlock()
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Acquired.Before(all[j].Acquired)
+	})
+	return all
+}
+
+// DumpHolders writes a description of each current holder of space,
+// including its stack trace, to w
+func (l *Limit[T]) DumpHolders(w io.Writer) error {
+	all := l.Holders()
+	now := l.now()
+	_, err := fmt.Fprintf(w, "%d holders of simultaneous limit %q (%d in use of %d)\n", len(all), l.name, l.InUse(), l.Limit())
+	for _, h := range all {
+		if err != nil {
+			return err
+		}
+		label := ""
+		if h.Label != "" {
+			label = fmt.Sprintf(" %q", h.Label)
+		}
+		claimed := ""
+		if !h.Transferred.IsZero() {
+			claimed = fmt.Sprintf(", claimed from a transfer %s ago", now.Sub(h.Transferred).Round(time.Millisecond))
+		}
+		_, err = fmt.Fprintf(w, "\n%d units%s held for %s%s:\n%s", h.Units, label, now.Sub(h.Acquired).Round(time.Millisecond), claimed, h.Stack)
+	}
+	return err
+}
+
+func (t *token[T]) holderInfo() *HolderInfo {
+	d := t.more()
+	if d.acquired.IsZero() {
+		d.acquired = t.limit.now()
+	}
+	return &HolderInfo{
+		Acquired:    d.acquired,
+		Transferred: d.transferred,
+		Units:       t.n,
+		Label:       d.label,
+		Stack:       d.stack,
+	}
+}
+
+// trackHolder records the token as a holder, if holders are tracked
+func (t *token[T]) trackHolder() {
+	hs := t.limit.holders
+	if hs == nil {
+		return
+	}
+	h := t.holderInfo()
+	hs.lock.Lock()
+	hs.holders[h] = struct{}{}
+	hs.lock.Unlock()
+	t.more().unhold = func() {
+		hs.lock.Lock()
+		delete(hs.holders, h)
+		hs.lock.Unlock()
+	}
+}
@@ -0,0 +1,51 @@
+package simultaneous_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func holdTwo(limit *simultaneous.Limit[any]) simultaneous.Limited[any] {
+	done, _ := limit.AcquireN(context.Background(), 2)
+	return done
+}
+
+func TestHolders(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, simultaneous.New[any](1).Holders(), "not tracking")
+
+	limit := simultaneous.New[any](3, simultaneous.WithName("tracked"), simultaneous.WithHolderTracking())
+	a := limit.Forever(context.Background())
+	b := holdTwo(limit)
+
+	holders := limit.Holders()
+	require.Len(t, holders, 2)
+	assert.Equal(t, int64(1), holders[0].Units)
+	assert.Equal(t, int64(2), holders[1].Units)
+	assert.False(t, holders[1].Acquired.Before(holders[0].Acquired))
+	assert.Contains(t, string(holders[1].Stack), "holdTwo")
+
+	var buf bytes.Buffer
+	require.NoError(t, limit.DumpHolders(&buf))
+	assert.Contains(t, buf.String(), `2 holders of simultaneous limit "tracked" (3 in use of 3)`)
+	assert.Contains(t, buf.String(), "holdTwo")
+
+	ticket := b.Transfer()
+	assert.Len(t, limit.Holders(), 1, "ticket not listed")
+	b = ticket.Claim()
+	assert.Len(t, limit.Holders(), 2, "claimed")
+
+	require.NoError(t, a.Yield(context.Background()))
+	assert.Len(t, limit.Holders(), 2, "after Yield")
+
+	a.Done()
+	b.Done()
+	assert.Empty(t, limit.Holders())
+}
@@ -0,0 +1,173 @@
+/*
+Package lease holds space in a limit that is shared between processes by
+taking a lease in some shared store and renewing it in the background
+until it is released. The store, such as a database table or a Redis
+key, is provided by the package using it.
+*/
+package lease
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/memsql/errors"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+// Store keeps the leases of one limit
+type Store interface {
+	// Take takes a lease for holder if there is space. It returns false
+	// if there is not.
+	Take(ctx context.Context, holder string, ttl time.Duration) (bool, error)
+	// Renew extends the lease of holder. It returns true, with an error,
+	// if the lease has been lost.
+	Renew(ctx context.Context, holder string, ttl time.Duration) (lost bool, err error)
+	// Release gives up the lease of holder
+	Release(ctx context.Context, holder string) error
+}
+
+// MinTTL is the shortest TTL. Leases are renewed every third of the TTL,
+// which must be at least a millisecond for stores that count in
+// milliseconds.
+const MinTTL = 3 * time.Millisecond
+
+// Config says how leases are held
+type Config struct {
+	// TTL is how long a lease lasts without being renewed. Leases are
+	// renewed every third of TTL. A TTL below MinTTL is raised to it.
+	TTL time.Duration
+	// PollInterval is how often a waiter tries to take a lease. Up to
+	// 10% random jitter is added.
+	PollInterval time.Duration
+	// LeaseLost, if not nil, is called if a lease cannot be renewed
+	LeaseLost func(context.Context, error)
+	// Space describes what is waited for, in errors, for example
+	// "redis lease (of 3) for jobs"
+	Space string
+}
+
+// Lease is space held in a Store. It implements simultaneous.External.
+type Lease struct {
+	store  Store
+	config Config
+	holder string
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+var _ simultaneous.External = &Lease{}
+
+// New returns a Lease that does not hold anything yet
+func New(store Store, config Config) *Lease {
+	if config.TTL < MinTTL {
+		config.TTL = MinTTL
+	}
+	return &Lease{
+		store:  store,
+		config: config,
+		holder: newHolderID(),
+	}
+}
+
+// Acquire waits to take the lease and starts renewing it
+func (s *Lease) Acquire(ctx context.Context) error {
+	for {
+		ok, err := s.Try(ctx)
+		if err != nil || ok {
+			return err
+		}
+		timer := time.NewTimer(s.poll())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Wrapf(ctx.Err(), "context cancelled before any %s became available", s.config.Space)
+		case <-timer.C:
+		}
+	}
+}
+
+// Try takes the lease, and starts renewing it, if that can be done
+// without waiting
+func (s *Lease) Try(ctx context.Context) (bool, error) {
+	ok, err := s.store.Take(ctx, s.holder, s.config.TTL)
+	if err != nil || !ok {
+		return false, err
+	}
+	s.stop = make(chan struct{})
+	s.wg.Add(1)
+	go s.heartbeat(s.stop)
+	return true, nil
+}
+
+func (s *Lease) heartbeat(stop chan struct{}) {
+	defer s.wg.Done()
+	ttl := s.config.TTL
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	lastRenewed := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+		lost, err := s.store.Renew(ctx, s.holder, ttl)
+		cancel()
+		switch {
+		case err == nil:
+			lastRenewed = time.Now()
+			continue
+		case lost:
+		case time.Since(lastRenewed) < ttl:
+			// try again, the lease has not yet expired
+			continue
+		}
+		if s.config.LeaseLost != nil {
+			s.config.LeaseLost(context.Background(), err)
+		}
+		return
+	}
+}
+
+// Release stops renewing the lease and gives it up. If that fails, the
+// space becomes available when the lease expires.
+func (s *Lease) Release() {
+	close(s.stop)
+	s.wg.Wait()
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.TTL)
+	defer cancel()
+	_ = s.store.Release(ctx, s.holder)
+}
+
+// Reacquire waits to take the lease again after Release
+func (s *Lease) Reacquire(ctx context.Context) error {
+	return s.Acquire(ctx)
+}
+
+func (s *Lease) poll() time.Duration {
+	interval := s.config.PollInterval
+	if interval <= 0 {
+		return 0
+	}
+	return interval + time.Duration(mathrand.Int63n(int64(interval)/10+1))
+}
+
+var holderPrefix = func() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d:", host, os.Getpid())
+}()
+
+// newHolderID returns an id that is unique to one lease
+func newHolderID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return holderPrefix + hex.EncodeToString(b[:])
+}
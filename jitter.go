@@ -0,0 +1,38 @@
+package simultaneous
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// WithJitter returns a modified Limit that, when a caller had to wait for
+// space, delays granting that space by a random duration of up to max. This
+// spreads out the resumption of waiters so that they don't all hit a
+// recovering downstream at the same instant.
+//
+// The space is held during the delay. Context cancellation and Timeout()
+// expiration are still honored during the delay: in that case the space is
+// released and the acquisition fails as it would have without jitter.
+func (l Limit[T]) WithJitter(max time.Duration) *Limit[T] {
+	l.jitter = max
+	return &l
+}
+
+// jitterWait waits for a random delay up to l.jitter. It returns false if
+// the context is done or the timeout fires before the delay completes.
+func (l *Limit[T]) jitterWait(ctx context.Context, timeout <-chan time.Time) bool {
+	if l.jitter <= 0 {
+		return true
+	}
+	timer := l.newTimer(time.Duration(rand.Int63n(int64(l.jitter))))
+	defer timer.Stop()
+	select {
+	case <-timer.Chan():
+		return true
+	case <-ctx.Done():
+		return false
+	case <-timeout:
+		return false
+	}
+}
@@ -0,0 +1,115 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestJitterStaggers(t *testing.T) {
+	t.Parallel()
+
+	const waiters = 8
+	const jitter = 200 * time.Millisecond
+	limit := simultaneous.New[any](waiters).WithJitter(jitter)
+	ctx := context.Background()
+
+	held := make([]simultaneous.Limited[any], waiters)
+	for i := range held {
+		held[i] = limit.Forever(ctx)
+	}
+
+	var lock sync.Mutex
+	var granted []time.Time
+	var wg sync.WaitGroup
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer limit.Forever(ctx).Done()
+			lock.Lock()
+			granted = append(granted, time.Now())
+			lock.Unlock()
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	start := time.Now()
+	for _, done := range held {
+		done.Done()
+	}
+	wg.Wait()
+
+	require.Len(t, granted, waiters)
+	first, last := granted[0], granted[0]
+	for _, g := range granted {
+		if g.Before(first) {
+			first = g
+		}
+		if g.After(last) {
+			last = g
+		}
+	}
+	t.Logf("first grant after %s, last after %s", first.Sub(start), last.Sub(start))
+	assert.Greater(t, last.Sub(first), 10*time.Millisecond, "grants are staggered")
+	assert.Less(t, last.Sub(start), jitter+time.Second)
+}
+
+func TestJitterHonorsTimeout(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1).WithJitter(time.Hour)
+	done := limit.Forever(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		done.Done()
+	}()
+
+	start := time.Now()
+	_, err := limit.Timeout(context.Background(), 50*time.Millisecond)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// the space held during the jitter delay was released
+	done, err = limit.Timeout(context.Background(), 0)
+	require.NoError(t, err, "space available without waiting")
+	done.Done()
+}
+
+func TestJitterHonorsContext(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1).WithJitter(time.Hour)
+	done := limit.Forever(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		done.Done()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := limit.Timeout(ctx, time.Hour)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	limit.Forever(context.Background()).Done()
+	held := limit.Forever(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		held.Done()
+	}()
+	limit.Forever(ctx).Done()
+	assert.Error(t, ctx.Err(), "Forever returned due to context")
+
+	done, err = limit.Timeout(context.Background(), 0)
+	require.NoError(t, err, "space available without waiting")
+	done.Done()
+}
@@ -0,0 +1,252 @@
+package simultaneous
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// KeyedLimit maintains a separate Limit for each key (like a tenant or a
+// host). The Limit for a key is created when the key is first used. Keys
+// that are not in use (no space is held and nobody is waiting) are
+// forgotten as configured by SetEviction.
+type KeyedLimit[K comparable, T any] struct {
+	limit       int
+	lock        sync.Mutex
+	capacity    func(K) int // nil for limit
+	overrides   map[K]int
+	total       *Limit[T] // nil if there is no overall limit
+	weights     map[K]float64
+	entries     map[K]*keyedEntry[K, T]
+	idle        list.List // of *keyedEntry, most recently used first
+	maxIdle     int
+	idleTimeout time.Duration
+}
+
+type keyedEntry[K comparable, T any] struct {
+	key      K
+	limit    *Limit[T]
+	refs     int
+	lastUsed time.Time
+	idleElem *list.Element
+}
+
+// NewKeyed creates a KeyedLimit where each key has a separate limit of
+// the given size, unless changed by SetCapacityFunc or SetKeyLimit. By
+// default, unused keys are never forgotten.
+func NewKeyed[K comparable, T any](limit int) *KeyedLimit[K, T] {
+	return &KeyedLimit[K, T]{
+		limit:   limit,
+		entries: make(map[K]*keyedEntry[K, T]),
+	}
+}
+
+// NewKeyedTotal creates a KeyedLimit like NewKeyed that also limits the
+// space held across all keys to total. Space for a key is only granted
+// when both the key and the total have room, as a single step, because
+// the limit for each key is a Child of the total. The options configure
+// the total, and through it the limit for each key.
+//
+// When the total is the limit, space is shared fairly between keys (see
+// WithFairShare) rather than going to whoever asked first, so one busy
+// key cannot take all of the total. Use SetKeyWeight to give some keys a
+// bigger share.
+//
+//	// each tenant at most 5, all tenants together at most 50
+//	tenants := simultaneous.NewKeyedTotal[string, tenantQueries](5, 50)
+func NewKeyedTotal[K comparable, T any](limit int, total int, opts ...Option) *KeyedLimit[K, T] {
+	k := NewKeyed[K, T](limit)
+	k.total = New[T](total, opts...).WithFairShare()
+	return k
+}
+
+// SetKeyWeight sets the share of the total that a key gets, relative to
+// other keys, when the total is contended. The default weight is 1. It
+// only matters for KeyedLimits created with NewKeyedTotal. The weight is
+// kept when the key is forgotten.
+func (k *KeyedLimit[K, T]) SetKeyWeight(key K, weight float64) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.weights == nil {
+		k.weights = make(map[K]float64)
+	}
+	k.weights[key] = weight
+	if e, ok := k.entries[key]; ok {
+		e.limit.SetWeight(weight)
+	}
+}
+
+// Total returns the Limit on the space held across all keys, or nil if
+// the KeyedLimit was not created with NewKeyedTotal. Use it for Stats or
+// to change the total with SetLimit.
+func (k *KeyedLimit[K, T]) Total() *Limit[T] { return k.total }
+
+// SetEviction controls when unused keys are forgotten. If maxIdle is
+// positive, at most that many unused keys are remembered: the least
+// recently used are forgotten first. If idleTimeout is positive, keys
+// that have not been used for that long are forgotten. Eviction happens
+// as the KeyedLimit is used; there is no background goroutine.
+func (k *KeyedLimit[K, T]) SetEviction(maxIdle int, idleTimeout time.Duration) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.maxIdle = maxIdle
+	k.idleTimeout = idleTimeout
+	k.evict()
+}
+
+// SetCapacityFunc makes the capacity of each key come from fn, so that
+// for example premium tenants can have a higher limit than others. If
+// fn returns a negative number, the limit given to NewKeyed is used. fn
+// is called when the limit for a key is created and, for keys that are
+// currently remembered, by SetCapacityFunc. It is called with the lock
+// held so it must not use the KeyedLimit. Overrides set with SetKeyLimit
+// take precedence.
+func (k *KeyedLimit[K, T]) SetCapacityFunc(fn func(key K) int) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.capacity = fn
+	for key, e := range k.entries {
+		e.limit.SetLimit(k.capacityFor(key))
+	}
+}
+
+// SetKeyLimit overrides the capacity of one key, taking effect right
+// away even while space is held. The override is kept when the key is
+// forgotten. A negative n removes the override.
+func (k *KeyedLimit[K, T]) SetKeyLimit(key K, n int) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if n < 0 {
+		delete(k.overrides, key)
+	} else {
+		if k.overrides == nil {
+			k.overrides = make(map[K]int)
+		}
+		k.overrides[key] = n
+	}
+	if e, ok := k.entries[key]; ok {
+		e.limit.SetLimit(k.capacityFor(key))
+	}
+}
+
+// KeyLimit returns the capacity of a key
+func (k *KeyedLimit[K, T]) KeyLimit(key K) int {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	return k.capacityFor(key)
+}
+
+// capacityFor must be called with the lock held
+func (k *KeyedLimit[K, T]) capacityFor(key K) int {
+	if n, ok := k.overrides[key]; ok {
+		return n
+	}
+	if k.capacity != nil {
+		if n := k.capacity(key); n >= 0 {
+			return n
+		}
+	}
+	return k.limit
+}
+
+// Forever is like Limit.Forever for the limit of a specific key
+func (k *KeyedLimit[K, T]) Forever(ctx context.Context, key K) Limited[T] {
+	e := k.get(key)
+	return k.track(e, e.limit.Forever(ctx))
+}
+
+// Acquire is like Limit.Acquire for the limit of a specific key
+func (k *KeyedLimit[K, T]) Acquire(ctx context.Context, key K) (Limited[T], error) {
+	e := k.get(key)
+	done, err := e.limit.Acquire(ctx)
+	return k.track(e, done), err
+}
+
+// Timeout is like Limit.Timeout for the limit of a specific key
+func (k *KeyedLimit[K, T]) Timeout(ctx context.Context, key K, timeout time.Duration) (Limited[T], error) {
+	e := k.get(key)
+	done, err := e.limit.Timeout(ctx, timeout)
+	return k.track(e, done), err
+}
+
+// Len returns the number of keys currently remembered
+func (k *KeyedLimit[K, T]) Len() int {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.evict()
+	return len(k.entries)
+}
+
+// get returns the entry for a key, marking it as in use
+func (k *KeyedLimit[K, T]) get(key K) *keyedEntry[K, T] {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.evict()
+	e, ok := k.entries[key]
+	if !ok {
+		e = &keyedEntry[K, T]{
+			key: key,
+		}
+		if k.total != nil {
+			e.limit = k.total.Child(k.capacityFor(key))
+			if weight, ok := k.weights[key]; ok {
+				e.limit.SetWeight(weight)
+			}
+		} else {
+			e.limit = New[T](k.capacityFor(key))
+		}
+		k.entries[key] = e
+	}
+	if e.idleElem != nil {
+		k.idle.Remove(e.idleElem)
+		e.idleElem = nil
+	}
+	e.refs++
+	return e
+}
+
+// put undoes get
+func (k *KeyedLimit[K, T]) put(e *keyedEntry[K, T]) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	e.refs--
+	if e.refs == 0 {
+		e.lastUsed = k.now()
+		e.idleElem = k.idle.PushFront(e)
+	}
+	k.evict()
+}
+
+// track arranges for the entry to be put when the space is released
+func (k *KeyedLimit[K, T]) track(e *keyedEntry[K, T], done Limited[T]) Limited[T] {
+	if t, ok := done.(*token[T]); ok && t.held {
+		t.more().onRelease = func() { k.put(e) }
+	} else {
+		k.put(e)
+	}
+	return done
+}
+
+// evict must be called with the lock held
+func (k *KeyedLimit[K, T]) evict() {
+	now := k.now()
+	for back := k.idle.Back(); back != nil; back = k.idle.Back() {
+		e := back.Value.(*keyedEntry[K, T])
+		if !(k.maxIdle > 0 && k.idle.Len() > k.maxIdle) &&
+			!(k.idleTimeout > 0 && now.Sub(e.lastUsed) > k.idleTimeout) {
+			return
+		}
+		k.idle.Remove(back)
+		delete(k.entries, e.key)
+	}
+}
+
+// now uses the clock given to NewKeyedTotal with the WithClock Option, if
+// any
+func (k *KeyedLimit[K, T]) now() time.Time {
+	if k.total != nil {
+		return k.total.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,168 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestKeyedLimit(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.NewKeyed[string, any](1)
+	ctx := context.Background()
+
+	a := limit.Forever(ctx, "a")
+	_, err := limit.Timeout(ctx, "a", 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "a is full")
+	b, err := limit.Acquire(ctx, "b")
+	require.NoError(t, err, "b is separate from a")
+	assert.Equal(t, 2, limit.Len())
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		a.Done()
+	}()
+	a, err = limit.Timeout(ctx, "a", time.Second)
+	require.NoError(t, err)
+	a.Done()
+	b.Done()
+	assert.Equal(t, 2, limit.Len(), "not forgotten by default")
+}
+
+func TestKeyedLimitMaxIdle(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.NewKeyed[int, any](1)
+	limit.SetEviction(2, 0)
+	ctx := context.Background()
+
+	held := limit.Forever(ctx, 0)
+	for i := 1; i <= 5; i++ {
+		limit.Forever(ctx, i).Done()
+	}
+	assert.Equal(t, 3, limit.Len(), "two idle plus one in use")
+	_, err := limit.Timeout(ctx, 0, 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "key in use is never forgotten")
+
+	held.Done()
+	assert.Equal(t, 2, limit.Len())
+}
+
+func TestKeyedLimitIdleTimeout(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.NewKeyed[string, any](1)
+	limit.SetEviction(0, 10*time.Millisecond)
+	ctx := context.Background()
+
+	held := limit.Forever(ctx, "held")
+	limit.Forever(ctx, "a").Done()
+	limit.Forever(ctx, "b").Done()
+	assert.Equal(t, 3, limit.Len())
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, limit.Len(), "idle keys forgotten")
+
+	// a cancelled acquisition does not leave the key in use
+	ctx2, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := limit.Acquire(ctx2, "held")
+	assert.ErrorIs(t, err, context.Canceled)
+	held.Done()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 0, limit.Len())
+}
+
+func TestKeyedLimitYieldAndTransfer(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.NewKeyed[string, any](1)
+	limit.SetEviction(0, time.Nanosecond)
+	ctx := context.Background()
+
+	done := limit.Forever(ctx, "a")
+	require.NoError(t, done.Yield(ctx))
+	assert.Equal(t, 1, limit.Len(), "still in use after yield")
+
+	ticket := done.Transfer()
+	time.Sleep(time.Millisecond)
+	assert.Equal(t, 1, limit.Len(), "in use by ticket")
+	claimed := ticket.Claim()
+	assert.Equal(t, 1, limit.Len(), "in use by claimed token")
+	claimed.Done()
+	time.Sleep(time.Millisecond)
+	assert.Equal(t, 0, limit.Len())
+}
+
+func TestKeyedLimitCapacity(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.NewKeyed[string, any](1)
+	limit.SetCapacityFunc(func(key string) int {
+		if key == "premium" {
+			return 3
+		}
+		return -1
+	})
+	ctx := context.Background()
+	assert.Equal(t, 3, limit.KeyLimit("premium"))
+	assert.Equal(t, 1, limit.KeyLimit("free"), "default")
+
+	var held []simultaneous.Limited[any]
+	for i := 0; i < 3; i++ {
+		done, err := limit.Timeout(ctx, "premium", 0)
+		require.NoError(t, err)
+		held = append(held, done)
+	}
+	_, err := limit.Timeout(ctx, "premium", 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+
+	limit.SetKeyLimit("premium", 4)
+	done, err := limit.Timeout(ctx, "premium", 0)
+	require.NoError(t, err, "override applies to a remembered key")
+	held = append(held, done)
+
+	limit.SetKeyLimit("free", 0)
+	_, err = limit.Timeout(ctx, "free", 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "override to zero")
+	limit.SetKeyLimit("free", -1)
+	assert.Equal(t, 1, limit.KeyLimit("free"), "override removed")
+
+	for _, done := range held {
+		done.Done()
+	}
+}
+
+func TestKeyedLimitTotal(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.NewKeyedTotal[string, any](2, 3)
+	ctx := context.Background()
+	a1 := limit.Forever(ctx, "a")
+	a2 := limit.Forever(ctx, "a")
+	_, err := limit.Timeout(ctx, "a", 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "a is full")
+	b := limit.Forever(ctx, "b")
+	_, err = limit.Timeout(ctx, "c", 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "total is full")
+	assert.Equal(t, 3, limit.Total().InUse())
+
+	got := make(chan simultaneous.Limited[any])
+	go func() { got <- limit.Forever(ctx, "c") }()
+	require.Eventually(t, func() bool { return limit.Total().Waiting() == 1 }, time.Second, time.Millisecond)
+	a1.Done()
+	c := <-got
+	_, err = limit.Timeout(ctx, "a", 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "a has room but the total does not")
+
+	a2.Done()
+	b.Done()
+	c.Done()
+	assert.Zero(t, limit.Total().InUse())
+	assert.Nil(t, simultaneous.NewKeyed[string, any](1).Total())
+}
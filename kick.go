@@ -0,0 +1,64 @@
+package simultaneous
+
+import (
+	"github.com/memsql/errors"
+)
+
+// ErrWaiterCancelled is returned to a caller whose wait was ended by
+// CancelOldestWaiter
+var ErrWaiterCancelled errors.String = "simultaneous limit waiter cancelled"
+
+// CancelOldestWaiter makes the caller that has been waiting for space in
+// the Limit the longest give up, as if its context had been cancelled.
+// Methods that return an error return one wrapping ErrWaiterCancelled and
+// Forever returns a Limited that does not hold space. It returns false if
+// nobody is waiting. For a Child, only callers waiting for the Child (or
+// its children) are considered.
+//
+// It is an escape hatch for operators, for example to kick a request
+// that is stuck behind others during an incident.
+func (l *Limit[T]) CancelOldestWaiter() bool {
+	return l.core.cancelOldest(l.sub)
+}
+
+func (l *Limit[T]) waiterCancelledError() error {
+	return ErrWaiterCancelled.Errorf("wait for simultaneous limit (of %d) cancelled", l.capacity())
+}
+
+// cancelOldest makes the waiter that has been waiting the longest, for
+// sub if it is not nil, fail
+func (c *core) cancelOldest(sub *subLimit) bool {
+	defer c.notePressure()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// waiters are queued in the order that they arrived
+	var oldest *waiter
+	for e := c.waiters.Front(); e != nil && oldest == nil; e = e.Next() {
+		if w := e.Value.(*waiter); w.under(sub) {
+			oldest = w
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	c.remove(oldest)
+	oldest.closed = true
+	oldest.kicked = true
+	close(oldest.ready)
+	c.grant()
+	return true
+}
+
+// under returns true if the waiter is waiting for sub, or for one of its
+// children. Every waiter is under a nil sub.
+func (w *waiter) under(sub *subLimit) bool {
+	if sub == nil {
+		return true
+	}
+	for s := w.sub; s != nil; s = s.parent {
+		if s == sub {
+			return true
+		}
+	}
+	return false
+}
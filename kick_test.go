@@ -0,0 +1,104 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestCancelOldestWaiter(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	ctx := context.Background()
+	assert.False(t, limit.CancelOldestWaiter(), "nobody waiting")
+	held := limit.Forever(ctx)
+
+	type result struct {
+		i    int
+		done simultaneous.Limited[any]
+		err  error
+	}
+	results := make(chan result)
+	for i := 0; i < 3; i++ {
+		i := i
+		go func() {
+			done, err := limit.Acquire(ctx)
+			results <- result{i: i, done: done, err: err}
+		}()
+		require.Eventually(t, func() bool { return limit.Waiting() == i+1 }, time.Second, time.Millisecond)
+	}
+
+	require.True(t, limit.CancelOldestWaiter())
+	r := <-results
+	assert.Equal(t, 0, r.i, "the oldest waiter")
+	assert.ErrorIs(t, r.err, simultaneous.ErrWaiterCancelled)
+	assert.NotPanics(t, r.done.Done)
+	assert.Equal(t, 2, limit.Waiting())
+	assert.Equal(t, 1, limit.InUse())
+
+	require.True(t, limit.CancelOldestWaiter())
+	r = <-results
+	assert.Equal(t, 1, r.i)
+	assert.ErrorIs(t, r.err, simultaneous.ErrWaiterCancelled)
+
+	held.Done()
+	r = <-results
+	assert.Equal(t, 2, r.i)
+	require.NoError(t, r.err)
+	r.done.Done()
+	assert.False(t, limit.CancelOldestWaiter(), "nobody left")
+}
+
+func TestCancelOldestWaiterForever(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	ctx := context.Background()
+	held := limit.Forever(ctx)
+	defer held.Done()
+
+	got := make(chan simultaneous.Limited[any])
+	go func() {
+		got <- limit.Forever(ctx)
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	require.True(t, limit.CancelOldestWaiter())
+	done := <-got
+	assert.NotPanics(t, done.Done, "does not hold space")
+	assert.Equal(t, 1, limit.InUse())
+}
+
+func TestCancelOldestWaiterChild(t *testing.T) {
+	t.Parallel()
+
+	parent := simultaneous.New[any](1)
+	child := parent.Child(1)
+	ctx := context.Background()
+	held := parent.Forever(ctx)
+	defer held.Done()
+
+	results := make(chan error, 2)
+	go func() {
+		_, err := parent.Acquire(ctx)
+		results <- err
+	}()
+	require.Eventually(t, func() bool { return parent.Waiting() == 1 }, time.Second, time.Millisecond)
+	go func() {
+		_, err := child.Acquire(ctx)
+		results <- err
+	}()
+	require.Eventually(t, func() bool { return parent.Waiting() == 2 }, time.Second, time.Millisecond)
+
+	require.True(t, child.CancelOldestWaiter(), "the child's waiter though it is not the oldest")
+	assert.ErrorIs(t, <-results, simultaneous.ErrWaiterCancelled)
+	assert.Equal(t, 1, parent.Waiting())
+	assert.False(t, child.CancelOldestWaiter())
+	require.True(t, parent.CancelOldestWaiter())
+	assert.ErrorIs(t, <-results, simultaneous.ErrWaiterCancelled)
+}
@@ -0,0 +1,29 @@
+package simultaneous
+
+import (
+	"context"
+)
+
+type labelKey struct{}
+
+// ContextWithLabel returns a context that carries a label describing the
+// work that wants space, like "compact shard 7". Space obtained with the
+// context is labeled: the label appears in Holders, in the HolderInfo given
+// to WithMaxHold callbacks, and can be retrieved with LabelFromContext by
+// stuck callbacks and Observers, which are passed the context.
+func ContextWithLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, labelKey{}, label)
+}
+
+// LabelFromContext returns the label set with ContextWithLabel, or "" if
+// there is none
+func LabelFromContext(ctx context.Context) string {
+	label, _ := ctx.Value(labelKey{}).(string)
+	return label
+}
+
+// ForeverLabeled is like Forever except that the space is labeled. See
+// ContextWithLabel.
+func (l *Limit[T]) ForeverLabeled(ctx context.Context, label string) Limited[T] {
+	return l.Forever(ContextWithLabel(ctx, label))
+}
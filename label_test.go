@@ -0,0 +1,43 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestLabels(t *testing.T) {
+	t.Parallel()
+
+	stuckLabels := make(chan string, 1)
+	limit := simultaneous.New[any](1,
+		simultaneous.WithHolderTracking(),
+		simultaneous.WithStuckMessaging(time.Millisecond, func(ctx context.Context) {
+			stuckLabels <- simultaneous.LabelFromContext(ctx)
+		}, nil),
+	)
+	assert.Empty(t, simultaneous.LabelFromContext(context.Background()))
+
+	done := limit.ForeverLabeled(context.Background(), "compact shard 7")
+	holders := limit.Holders()
+	require.Len(t, holders, 1)
+	assert.Equal(t, "compact shard 7", holders[0].Label)
+
+	ctx, cancel := context.WithTimeout(simultaneous.ContextWithLabel(context.Background(), "backup"), 20*time.Millisecond)
+	defer cancel()
+	_, err := limit.Acquire(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, "backup", <-stuckLabels)
+
+	require.NoError(t, done.Yield(context.Background()))
+	assert.Equal(t, "compact shard 7", limit.Holders()[0].Label, "kept by Yield")
+
+	done = done.Transfer().Claim()
+	assert.Equal(t, "compact shard 7", limit.Holders()[0].Label, "carried by transfer")
+	done.Done()
+}
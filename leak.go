@@ -0,0 +1,64 @@
+package simultaneous
+
+import (
+	"context"
+	"runtime"
+)
+
+// leakDetection is configured by WithLeakDetection
+type leakDetection struct {
+	callback func(stack []byte)
+	reclaim  bool
+}
+
+// WithLeakDetection returns a modified Limit that notices when a Limited
+// that still holds space is garbage collected, which means that Done was
+// never called. The callback is given the stack trace of where the space
+// was obtained. If reclaim is true, the space is then released.
+//
+// Leak detection records a stack trace for every acquisition and relies
+// on finalizers, so it is relatively expensive and leaks are only noticed
+// when the garbage collector runs. The callback is called from the
+// finalizer goroutine and should not block.
+func (l Limit[T]) WithLeakDetection(callback func(stack []byte), reclaim bool) *Limit[T] {
+	l.leak = &leakDetection{
+		callback: callback,
+		reclaim:  reclaim,
+	}
+	return &l
+}
+
+// WithLeakDetection notices Limiteds that are never Done. See the
+// WithLeakDetection method.
+func WithLeakDetection(callback func(stack []byte), reclaim bool) Option {
+	return func(s *state) {
+		s.leak = &leakDetection{
+			callback: callback,
+			reclaim:  reclaim,
+		}
+	}
+}
+
+// watchLeak arranges for leaked to be called if the token is garbage
+// collected
+func (t *token[T]) watchLeak() {
+	if t.limit.leak == nil {
+		return
+	}
+	runtime.SetFinalizer(t, (*token[T]).leaked)
+}
+
+func (t *token[T]) leaked() {
+	if !t.held {
+		return
+	}
+	leak := t.limit.leak
+	stack := t.get().stack
+	t.limit.logEvent(context.Background(), logError, "simultaneous limit leak", "stack", string(stack))
+	if leak.callback != nil {
+		callObserver(func() { leak.callback(stack) })
+	}
+	if leak.reclaim {
+		t.Done()
+	}
+}
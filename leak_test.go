@@ -0,0 +1,66 @@
+package simultaneous_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+//go:noinline
+func leakOne(limit *simultaneous.Limit[any]) {
+	_ = limit.Forever(context.Background())
+}
+
+func TestLeakDetection(t *testing.T) {
+	t.Parallel()
+
+	leaks := make(chan []byte, 10)
+	limit := simultaneous.New[any](2, simultaneous.WithLeakDetection(func(stack []byte) {
+		leaks <- stack
+	}, true))
+
+	// Done and Yield are not leaks
+	done := limit.Forever(context.Background())
+	require.NoError(t, done.Yield(context.Background()))
+	done.Done()
+
+	leakOne(limit)
+	assert.Equal(t, 1, limit.InUse())
+	var stack []byte
+	require.Eventually(t, func() bool {
+		runtime.GC()
+		select {
+		case stack = <-leaks:
+			return true
+		default:
+			return false
+		}
+	}, 5*time.Second, time.Millisecond)
+	assert.Contains(t, string(stack), "leakOne")
+	assert.Eventually(t, func() bool { return limit.InUse() == 0 }, time.Second, time.Millisecond, "reclaimed")
+
+	runtime.GC()
+	runtime.GC()
+	assert.Empty(t, leaks, "only one leak")
+}
+
+func TestLeakDetectionNoReclaim(t *testing.T) {
+	t.Parallel()
+
+	leaked := make(chan struct{}, 1)
+	limit := simultaneous.New[any](1).WithLeakDetection(func([]byte) {
+		leaked <- struct{}{}
+	}, false)
+	leakOne(limit)
+	require.Eventually(t, func() bool {
+		runtime.GC()
+		return len(leaked) == 1
+	}, 5*time.Second, time.Millisecond)
+	assert.Equal(t, 1, limit.InUse(), "not reclaimed")
+}
@@ -0,0 +1,34 @@
+package simultaneous
+
+// WithLIFO makes the Limit grant space to the most recent arrivals first
+// whenever more than backlog callers are waiting. With a small backlog,
+// waiters are served in the order they arrived as usual. Under a heavy
+// backlog, the callers that have waited the longest are the ones most
+// likely to have given up already, so serving the newest first keeps
+// latency low for most callers while the oldest are left to time out.
+// This is the adaptive LIFO used by many RPC servers to protect tail
+// latency for interactive workloads. A backlog of zero means always
+// newest first.
+//
+// While the backlog is exceeded, the ordering of WithFIFO is reversed
+// too: space goes to the newest waiter that fits. Waiters with a
+// priority are still served before those with a lower priority.
+//
+// WithLIFO changes the Limit and all of its copies. It returns the Limit
+// so that it can be chained with New.
+func (l *Limit[T]) WithLIFO(backlog int) *Limit[T] {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	l.core.lifo = true
+	l.core.lifoBacklog = backlog
+	return l
+}
+
+// WithLIFO grants space to the newest waiters first when more than
+// backlog callers are waiting. See the WithLIFO method.
+func WithLIFO(backlog int) Option {
+	return func(s *state) {
+		s.core.lifo = true
+		s.core.lifoBacklog = backlog
+	}
+}
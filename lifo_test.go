@@ -0,0 +1,74 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestLIFO(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		backlog int
+		want    []int
+	}{
+		{backlog: 0, want: []int{4, 3, 2, 1, 0}},
+		{backlog: 2, want: []int{4, 3, 2, 0, 1}},
+		{backlog: 10, want: []int{0, 1, 2, 3, 4}},
+	}
+	for _, tc := range cases {
+		limit := simultaneous.New[any](1, simultaneous.WithLIFO(tc.backlog))
+		held := limit.Forever(context.Background())
+
+		var lock sync.Mutex
+		var order []int
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer limit.Forever(context.Background()).Done()
+				lock.Lock()
+				order = append(order, i)
+				lock.Unlock()
+			}()
+			assert.Eventually(t, func() bool { return limit.Waiting() == i+1 }, time.Second, time.Millisecond)
+		}
+		held.Done()
+		wg.Wait()
+		assert.Equal(t, tc.want, order, "backlog %d", tc.backlog)
+	}
+}
+
+func TestLIFOPriority(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1).WithLIFO(0)
+	held := limit.Forever(context.Background())
+
+	var lock sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i, prio := range []int{1, 0, 1, 0} {
+		i, prio := i, prio
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer limit.ForeverPriority(context.Background(), prio).Done()
+			lock.Lock()
+			order = append(order, i)
+			lock.Unlock()
+		}()
+		assert.Eventually(t, func() bool { return limit.Waiting() == i+1 }, time.Second, time.Millisecond)
+	}
+	held.Done()
+	wg.Wait()
+	assert.Equal(t, []int{2, 0, 3, 1}, order)
+}
@@ -6,6 +6,9 @@ package simultaneous
 
 import (
 	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
 	"time"
 
 	"github.com/memsql/errors"
@@ -15,7 +18,30 @@ import (
 // that a reservation has been taken and limits are obeyed.
 type Limited[T any] interface {
 	Enforced[T]
+	// Done releases the space. It may be called from any goroutine, not
+	// just the one that obtained the space, and more than once, even
+	// concurrently: only the first call releases the space. Done must
+	// not be called concurrently with Yield or Transfer.
 	Done()
+	// Yield releases the space and then waits to get it back so that
+	// others have a chance to run. If that is cancelled, Yield returns
+	// an error and the space is no longer held.
+	Yield(context.Context) error
+	// Transfer gives up the space without releasing it. The returned
+	// ticket can be claimed by another goroutine to get a Limited that
+	// holds the space. After Transfer, Done and Yield do nothing.
+	Transfer() *TransferTicket[T]
+	// WaitDuration returns how long the caller waited to obtain the
+	// space. After Yield, it is the wait to get the space back. It is
+	// zero if no space was obtained.
+	WaitDuration() time.Duration
+	// ShouldYield returns true if someone more important is waiting for
+	// the space: a waiter with a higher priority than the space was
+	// obtained at. Long-running holders can poll it at checkpoints and
+	// Yield, or finish early and call Done, so that the waiter gets the
+	// space sooner. It is false once Done or Transfer has been called.
+	// ShouldYield must not be called concurrently with Yield or Transfer.
+	ShouldYield() bool
 }
 
 // Enforced is a type that exists just to signal that a simultaneous limit
@@ -28,20 +54,92 @@ type Enforced[T any] interface {
 // Limit implements Enforced so it can be used to fulfill the Enforced
 // contract.
 type Limit[T any] struct {
-	queue           chan struct{}
-	stuckCallback   func(context.Context)
-	unstuckCallback func(context.Context)
-	stuckTimeout    time.Duration
+	state
+}
+
+// state holds the state and configuration of a Limit. Nothing in it
+// depends on the type parameter so that it can be shared by Retype.
+type state struct {
+	name                string
+	core                *core
+	stuckCallback       func(context.Context)
+	unstuckCallback     func(context.Context)
+	stuckInfoCallback   func(context.Context, StuckInfo)
+	unstuckInfoCallback func(context.Context, StuckInfo)
+	stuckTimeout        time.Duration
+	deadlockCallback    func(context.Context, error)
+	jitter              time.Duration
+	repanic             bool
+	observers           *observers
+	trace               *eventTrace
+	leak                *leakDetection
+	maxHold             *maxHold
+	misuse              *misuseDetection
+	holders             *holders
+	sub                 *subLimit // if not nil, the Limit is a Child
+	clock               Clock     // nil means real time
+	logger              eventLogger
+	reentrancy          *reentrancyDetection
+	cycleCallback       func(context.Context, error)
 }
 
 // New takes both a type and a count. The type is so that if the limit is passed
 // around it can be done so with type safety so that a limit of one kind of thing
 // cannot be used as limit of another kind of thing. If you're not passing the
 // resulting limit around, then the type argument can be anything. Like "string".
-func New[T any](limit int) *Limit[T] {
-	return &Limit[T]{
-		queue: make(chan struct{}, limit),
+//
+// Options configure the Limit:
+//
+//	limit := simultaneous.New[string](10, simultaneous.WithName("alter"), simultaneous.WithFIFO())
+func New[T any](limit int, opts ...Option) *Limit[T] {
+	l := &Limit[T]{
+		state: state{
+			core:      newCore(limit),
+			observers: &observers{},
+		},
+	}
+	for _, opt := range opts {
+		opt(&l.state)
 	}
+	return l
+}
+
+// Retype returns a view of a Limit with a different type. The view shares
+// everything with the original: acquiring space through either one counts
+// against both. This allows two subsystems to share one underlying limit
+// while each keeps its own type-safe Enforced.
+func Retype[T any, U any](l *Limit[T]) *Limit[U] {
+	return &Limit[U]{
+		state: l.state,
+	}
+}
+
+// SetLimit changes the capacity of the Limit. Outstanding Limiteds remain
+// valid. Raising the limit immediately grants space to waiters. Lowering
+// the limit takes effect as space is released: no new space is granted
+// until the space in use is below the new limit. Callers waiting for more
+// space than a new limit above zero fail with ErrExceedsCapacity; a limit
+// of zero pauses the Limit and they keep waiting. All copies of the Limit
+// (including those made by Retype) are affected.
+func (l *Limit[T]) SetLimit(n int) {
+	from := l.Capacity()
+	l.core.resize(int64(n), l.sub)
+	if from != n {
+		l.logEvent(context.Background(), logDebug, "simultaneous limit resize", "from", from)
+	}
+}
+
+// Limit returns the current capacity of the Limit
+func (l *Limit[T]) Limit() int {
+	return int(l.capacity())
+}
+
+// capacity is the capacity of the Limit, or of the Child
+func (l *Limit[T]) capacity() int64 {
+	if l.sub != nil {
+		return l.core.subCapacity(l.sub)
+	}
+	return l.core.capacity()
 }
 
 // Unlimited provides a way to bypass enforcement
@@ -58,78 +156,344 @@ func Unlimited[T any]() Enforced[T] {
 //
 // If the context is cancelled, Forever returns regardless of space
 // in the Limit.
+//
+// Forever also returns without space, with a Done method that is a
+// no-op, whenever the Limit turns the caller away: once the Limit is
+// closed (Close), when too many callers are waiting (WithMaxWaiters),
+// while it is shedding load (WithShedding), when CancelOldestWaiter picks
+// the caller, and when more space is asked for than the capacity. The
+// caller then runs without a limit, which is exactly when the Limit is
+// overloaded. Code that must not run without space should use Acquire,
+// which returns the reason as an error.
 func (l *Limit[T]) Forever(ctx context.Context) Limited[T] {
-	if l.stuckTimeout == 0 {
-		select {
-		case l.queue <- struct{}{}:
-		case <-ctx.Done():
-			return limited[T](func() {})
+	done, _, _ := l.forever(ctx, l.stuckTimeout, 1, 0, "")
+	return done
+}
+
+// ForeverNoStuck is like Forever except that it never calls the stuck
+// callbacks set with SetForeverMessaging or AddStuckObserver, no matter how
+// long it waits. Use it for acquisitions that are expected to wait a long
+// time.
+func (l *Limit[T]) ForeverNoStuck(ctx context.Context) Limited[T] {
+	done, _, _ := l.forever(ctx, 0, 1, 0, "")
+	return done
+}
+
+// forever implements Forever for n units of space at priority prio for
+// the class. It also returns true if it had to wait and, if the space was
+// not obtained, why not. A stuckTimeout of zero disables stuck callbacks.
+func (l *Limit[T]) forever(ctx context.Context, stuckTimeout time.Duration, n int64, prio int, class string) (Limited[T], bool, error) {
+	var start time.Time // the clock is only read if the caller has to wait
+	l.record(EventAcquireStart)
+	l.checkLockOrder(ctx)
+	if err := l.checkReentrant(ctx); err != nil {
+		return l.cancelled(ctx, start), false, err
+	}
+	w, class := l.core.acquire(n, prio, class, l.sub)
+	if w == nil {
+		return l.acquired(ctx, n, prio, class, start), false, nil
+	}
+	switch w {
+	case closedWaiter:
+		return l.cancelled(ctx, start), false, l.closedError()
+	case fullWaiter:
+		return l.cancelled(ctx, start), false, l.queueFullError()
+	case sheddingWaiter:
+		return l.cancelled(ctx, start), false, l.sheddingError()
+	case tooLargeWaiter:
+		return l.cancelled(ctx, start), false, l.exceedsCapacityError(n)
+	}
+	start = l.now()
+	noteWaiter(ctx, w)
+	if callback := l.cycleDetection(); callback != nil {
+		defer l.trackWaiting(ctx, callback)()
+	}
+	l.waitStart(ctx)
+	if !l.await(ctx, w, stuckTimeout, true, nil) {
+		if w.kicked {
+			return l.cancelled(ctx, start), true, l.waiterCancelledError()
 		}
-	} else {
-		timer := time.NewTimer(l.stuckTimeout)
-		select {
-		case l.queue <- struct{}{}:
-			timer.Stop()
-		case <-ctx.Done():
-			timer.Stop()
-			return limited[T](func() {})
-		case <-timer.C:
-			if l.stuckCallback != nil {
-				l.stuckCallback(ctx)
-			}
-			select {
-			case l.queue <- struct{}{}:
-				if l.unstuckCallback != nil {
-					l.unstuckCallback(ctx)
-				}
-			case <-ctx.Done():
-				if l.unstuckCallback != nil {
-					l.unstuckCallback(ctx)
-				}
-				return limited[T](func() {})
-			}
+		if w.tooLarge {
+			return l.cancelled(ctx, start), true, l.exceedsCapacityError(n)
+		}
+		if w.closed {
+			return l.cancelled(ctx, start), true, l.closedError()
 		}
+		return l.cancelled(ctx, start), true, l.cancelledError(ctx)
 	}
-	return limited[T](func() {
-		<-l.queue
-	})
+	if !l.jitterWait(ctx, nil) {
+		l.core.release(n, class, l.sub)
+		return l.cancelled(ctx, start), true, l.cancelledError(ctx)
+	}
+	return l.acquired(ctx, n, prio, class, start), true, nil
+}
+
+// await waits for space to be granted to the waiter. It returns true if
+// the space was granted. After waiting for stuckTimeout (unless it is
+// zero), the stuck observers are called. If messaging is true, so are the
+// callbacks set with SetForeverMessaging and AddStuckObserver.
+func (l *Limit[T]) await(ctx context.Context, w *waiter, stuckTimeout time.Duration, messaging bool, timeout <-chan time.Time) bool {
+	if stuckTimeout == 0 {
+		return l.core.wait(ctx, w, timeout)
+	}
+	timer := l.newTimer(stuckTimeout)
+	select {
+	case <-w.ready:
+		timer.Stop()
+		return !w.closed
+	case <-ctx.Done():
+		timer.Stop()
+		return !l.core.cancel(w)
+	case <-timeout:
+		timer.Stop()
+		return !l.core.cancel(w)
+	case <-timer.Chan():
+	}
+	stuckAt := l.now()
+	l.stuck(ctx, messaging, stuckTimeout)
+	granted := l.core.wait(ctx, w, timeout)
+	if messaging {
+		l.unstuck(ctx, stuckTimeout+l.since(stuckAt), granted)
+	}
+	return granted
+}
+
+// ForeverBackground waits, without any possibility of cancellation, until
+// there is space in the Limit. It is for background tasks that are never
+// cancelled. Code that has a context should use Forever so that waiting stops
+// when the context is cancelled.
+//
+//	defer limit.ForeverBackground().Done()
+func (l *Limit[T]) ForeverBackground() Limited[T] {
+	return l.Forever(context.Background())
+}
+
+// Acquire waits until there is space in the Limit or the context is
+// cancelled. If the context is cancelled first, an error wrapping ctx.Err()
+// is returned and the Done method is a no-op. Otherwise, the Done method
+// must be called to release the space.
+//
+//	done, err := limit.Acquire(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer done.Done()
+func (l *Limit[T]) Acquire(ctx context.Context) (Limited[T], error) {
+	done, _, err := l.acquire(ctx, 1, 0, "")
+	return done, err
+}
+
+// Forever2 is like Forever except that it also reports if the caller had to
+// wait (queued is true) and returns an error wrapping ctx.Err() if the context
+// is cancelled before space becomes available. In the case of an error the
+// Done method is a no-op.
+func (l *Limit[T]) Forever2(ctx context.Context) (_ Limited[T], queued bool, _ error) {
+	return l.acquire(ctx, 1, 0, "")
+}
+
+// acquire implements Forever2 for n units of space at priority prio for
+// the class
+func (l *Limit[T]) acquire(ctx context.Context, n int64, prio int, class string) (Limited[T], bool, error) {
+	done, queued, err := l.forever(ctx, l.stuckTimeout, n, prio, class)
+	if err == nil && queued && ctx.Err() != nil {
+		done.Done()
+		return limited[T](nil), true, l.cancelledError(ctx)
+	}
+	return done, queued, err
+}
+
+// acquired returns the Limited for n units of space that have been obtained
+// at priority prio, and accounted to the class, after waiting since start
+func (l *Limit[T]) acquired(ctx context.Context, n int64, prio int, class string, start time.Time) Limited[T] {
+	l.record(EventAcquireGrant)
+	waited := l.waitedSince(start)
+	l.core.count(l.sub, outcomeAcquired, waited)
+	l.waited(ctx, waited)
+	t := &token[T]{
+		limit: l,
+		n:     n,
+		held:  true,
+	}
+	if label := LabelFromContext(ctx); prio != 0 || class != "" || waited != 0 || label != "" {
+		t.details = &tokenDetails{
+			prio:   prio,
+			class:  class,
+			waited: waited,
+			label:  label,
+		}
+	}
+	t.startTracking()
+	return t
+}
+
+// cancelled returns the Limited for when Forever gives up
+func (l *Limit[T]) cancelled(ctx context.Context, start time.Time) Limited[T] {
+	l.record(EventCancel)
+	if ctx.Err() != nil {
+		l.core.count(l.sub, outcomeCancelled, 0)
+	}
+	l.gaveUp(ctx, start)
+	return limited[T](nil)
 }
 
 var ErrTimeout errors.String = "could not get permission to run before timeout"
 
 // Timeout waits for a limited time for there to be space for another
-// simultaneous runner. In the case of a timeout, ErrTimeout is returned
-// and the Done method is a no-op. If there is room, the Done method must
-// be invoked to make room for another runner. If the provided context is
+// simultaneous runner. In the case of a timeout, a *TimeoutError (which
+// matches ErrTimeout) is returned and the Done method is a no-op. If there
+// is room, the Done method must be invoked to make room for another
+// runner. If the provided context is
 // cancelled before space becomes available or the timeout elapses, Timeout
 // will return early with an error wrapping ctx.Err(), and the returned
 // Limited's Done method will also be a no-op.
+//
+// Waiting ends at the context's deadline if that is sooner than the
+// timeout. That is reported as the context ending, not as ErrTimeout, so
+// that a caller can tell a parent giving up apart from a Limit that is
+// full. Errors from the context also wrap context.Cause(ctx), if it is
+// different from ctx.Err().
+//
+// A timeout of zero makes a single non-blocking attempt to get space. A
+// negative timeout, as computed by time.Until for a deadline that has
+// already passed, is treated as already expired: ErrTimeout is returned
+// without attempting to get space.
 func (l *Limit[T]) Timeout(ctx context.Context, timeout time.Duration) (Limited[T], error) {
-	if timeout <= 0 {
-		select {
-		case l.queue <- struct{}{}:
-			return limited[T](func() {
-				<-l.queue
-			}), nil
-		case <-ctx.Done():
-			return limited[T](nil), errors.Wrapf(ctx.Err(), "context cancelled before any simultaneous runner (of %d) became available", cap(l.queue))
-		default:
-			return limited[T](nil), ErrTimeout.Errorf("timeout (%s) expired before any simultaneous runner (of %d) became available", timeout, cap(l.queue))
+	return l.timeout(ctx, 1, timeout)
+}
+
+// timeout implements Timeout for n units of space
+func (l *Limit[T]) timeout(ctx context.Context, n int64, timeout time.Duration) (Limited[T], error) {
+	var start time.Time // the clock is only read if the caller has to wait
+	l.record(EventAcquireStart)
+	if l.core.isClosed() {
+		return l.cancelled(ctx, start), l.closedError()
+	}
+	if timeout < 0 {
+		return l.timedOut(ctx, timeout, start)
+	}
+	if timeout == 0 {
+		if l.core.tryAcquire(n, l.sub) {
+			return l.acquired(ctx, n, 0, "", start), nil
+		}
+		if ctx.Err() != nil {
+			return l.cancelledTimeout(ctx, start)
 		}
+		return l.triedOut(ctx, start)
 	}
-	timer := time.NewTimer(timeout)
-	select {
-	case l.queue <- struct{}{}:
-		timer.Stop()
-		return limited[T](func() {
-			<-l.queue
-		}), nil
-	case <-ctx.Done():
-		timer.Stop()
-		return limited[T](nil), errors.Wrapf(ctx.Err(), "context cancelled before any simultaneous runner (of %d) became available", cap(l.queue))
-	case <-timer.C:
-		return limited[T](nil), ErrTimeout.Errorf("timeout (%s) expired before any simultaneous runner (of %d) became available", timeout, cap(l.queue))
+	l.checkLockOrder(ctx)
+	if err := l.checkReentrant(ctx); err != nil {
+		return l.cancelled(ctx, start), err
+	}
+	if w, _ := l.core.acquire(n, 0, "", l.sub); w != nil {
+		switch w {
+		case closedWaiter:
+			return l.cancelled(ctx, start), l.closedError()
+		case fullWaiter:
+			return l.cancelled(ctx, start), l.queueFullError()
+		case sheddingWaiter:
+			return l.cancelled(ctx, start), l.sheddingError()
+		case tooLargeWaiter:
+			return l.cancelled(ctx, start), l.exceedsCapacityError(n)
+		}
+		start = l.now()
+		if callback := l.cycleDetection(); callback != nil {
+			defer l.trackWaiting(ctx, callback)()
+		}
+		l.waitStart(ctx)
+		timer := l.newTimer(timeout)
+		defer timer.Stop()
+		if !l.await(ctx, w, l.stuckTimeout, false, timer.Chan()) {
+			if w.kicked {
+				return l.cancelled(ctx, start), l.waiterCancelledError()
+			}
+			if w.tooLarge {
+				return l.cancelled(ctx, start), l.exceedsCapacityError(n)
+			}
+			if w.closed {
+				return l.cancelled(ctx, start), l.closedError()
+			}
+			if ctx.Err() != nil {
+				return l.cancelledTimeout(ctx, start)
+			}
+			return l.timedOut(ctx, timeout, start)
+		}
+		if !l.jitterWait(ctx, timer.Chan()) {
+			l.core.release(n, "", l.sub)
+			if ctx.Err() != nil {
+				return l.cancelledTimeout(ctx, start)
+			}
+			return l.timedOut(ctx, timeout, start)
+		}
+	}
+	return l.acquired(ctx, n, 0, "", start), nil
+}
+
+func (l *Limit[T]) timedOut(ctx context.Context, timeout time.Duration, start time.Time) (Limited[T], error) {
+	l.record(EventTimeout)
+	l.core.count(l.sub, outcomeTimeout, 0)
+	l.gaveUp(ctx, start)
+	if timeout > 0 {
+		l.logEvent(ctx, logInfo, "simultaneous limit timeout", "timeout", timeout)
+	}
+	return limited[T](nil), l.timeoutError(timeout)
+}
+
+// triedOut is timedOut for a timeout of zero, which is a try
+func (l *Limit[T]) triedOut(ctx context.Context, start time.Time) (Limited[T], error) {
+	l.record(EventTimeout)
+	l.core.countTryFailure(l.sub)
+	l.gaveUp(ctx, start)
+	return limited[T](nil), l.timeoutError(0)
+}
+
+func (l *Limit[T]) cancelledTimeout(ctx context.Context, start time.Time) (Limited[T], error) {
+	l.record(EventCancel)
+	l.core.count(l.sub, outcomeCancelled, 0)
+	l.gaveUp(ctx, start)
+	return limited[T](nil), l.cancelledError(ctx)
+}
+
+func (l *Limit[T]) cancelledError(ctx context.Context) error {
+	return errors.Wrapf(contextError(ctx), "context cancelled before any simultaneous runner (of %d) became available", l.capacity())
+}
+
+// contextError returns ctx.Err() combined with the cause of the
+// cancellation, if it is different, so that errors.Is matches both
+func contextError(ctx context.Context) error {
+	err := ctx.Err()
+	if cause := context.Cause(ctx); cause != nil && cause != err {
+		return fmt.Errorf("%w: %w", err, cause)
 	}
+	return err
+}
+
+func (l *Limit[T]) timeoutError(timeout time.Duration) error {
+	stats := l.Stats()
+	return errors.WithStack(&TimeoutError{
+		Timeout:  timeout,
+		Capacity: stats.Capacity,
+		InUse:    stats.InUse,
+		Waiters:  stats.Waiters,
+	})
+}
+
+// TimeoutError is the error returned by Timeout when the timeout expires.
+// It records the state of the Limit at the time. Use errors.As to get at
+// it. errors.Is(err, ErrTimeout) is true for a TimeoutError.
+type TimeoutError struct {
+	Timeout  time.Duration
+	Capacity int // capacity of the Limit
+	InUse    int // units of space held by others
+	Waiters  int // callers that were still waiting for space
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timeout (%s) expired before any simultaneous runner (of %d) became available", e.Timeout, e.Capacity)
+}
+
+// Unwrap returns ErrTimeout
+func (e *TimeoutError) Unwrap() error {
+	return ErrTimeout
 }
 
 // SetForeverMessaging returns a modified Limit that changes the behavior of Forever() so that
@@ -137,6 +501,10 @@ func (l *Limit[T]) Timeout(ctx context.Context, timeout time.Duration) (Limited[
 // and it will call unstuckCallback() (if set) when it finally gets a limit or if the context
 // is cancelled.
 //
+// WithStuckMessaging does the same thing when passed to New. For callbacks
+// that are told the name, capacity, waiters, and how long the caller has
+// waited, use WithStuckInfo.
+//
 // The anticipated use of the callbacks is logging. They don't return error and if they panic,
 // it won't be caught by the simultaneous package.
 func (l Limit[T]) SetForeverMessaging(stuckTimeout time.Duration, stuckCallback func(context.Context), unstuckCallback func(context.Context)) *Limit[T] {
@@ -149,9 +517,183 @@ func (l Limit[T]) SetForeverMessaging(stuckTimeout time.Duration, stuckCallback
 var (
 	_ Limited[any]  = limited[any](nil)
 	_ Enforced[any] = limited[any](nil)
+	_ Limited[any]  = &token[any]{}
 	_ Enforced[any] = unlimited[any]{}
 )
 
+// token is the Limited for space that has been obtained. One is
+// allocated for every acquisition so it only has what is needed to
+// release the space; everything else is in tokenDetails, which is only
+// allocated when something in it is needed.
+type token[T any] struct {
+	limit   *Limit[T]
+	n       int64
+	done    uint32 // set atomically by markDone
+	held    bool
+	details *tokenDetails
+}
+
+type tokenDetails struct {
+	prio        int
+	class       string // the class the space is accounted to
+	waited      time.Duration
+	stack       []byte // where the space was obtained, for leak detection
+	doneStack   []byte // where Done was first called, for misuse detection
+	label       string
+	acquired    time.Time // set when first needed by holder tracking
+	transferred time.Time // when claimed from a TransferTicket
+	untrack     func()
+	disown      func()
+	unlink      func() // undoes trackHolding
+	unhold      func()
+	watchdog    *watchdog
+	onRelease   func() // called after the space is released, but not by Yield
+}
+
+// noDetails is what a token without details has
+var noDetails tokenDetails
+
+// more returns the details of the token, allocating them if there are
+// none. It must only be called before the token is handed out or by
+// its holder.
+func (t *token[T]) more() *tokenDetails {
+	if t.details == nil {
+		t.details = &tokenDetails{}
+	}
+	return t.details
+}
+
+// get returns the details of the token without allocating. They must
+// not be modified.
+func (t *token[T]) get() *tokenDetails {
+	if t.details == nil {
+		return &noDetails
+	}
+	return t.details
+}
+
+func (t *token[T]) privateMethod() {}
+func (t *token[T]) Done() {
+	if !t.markDone() {
+		return
+	}
+	if onRelease := t.get().onRelease; t.release() && onRelease != nil {
+		onRelease()
+	}
+}
+
+func (t *token[T]) WaitDuration() time.Duration { return t.get().waited }
+
+// release releases the space. It returns false if the space was not held.
+func (t *token[T]) release() bool {
+	if !t.held {
+		return false
+	}
+	t.held = false
+	t.stopTracking()
+	t.limit.record(EventRelease)
+	t.limit.core.count(t.limit.sub, outcomeReleased, 0)
+	d := t.get()
+	if d.watchdog == nil || d.watchdog.release() {
+		t.limit.core.release(t.n, d.class, t.limit.sub)
+	}
+	return true
+}
+
+// startTracking starts whatever tracking of the token as holding space
+// has been configured
+func (t *token[T]) startTracking() {
+	l := t.limit
+	if !l.tracksTokens() {
+		return
+	}
+	d := t.more()
+	if l.leak != nil || l.holders != nil || l.maxHold != nil {
+		d.stack = debug.Stack()
+	}
+	t.watchLeak()
+	t.trackHolder()
+	t.watchHold()
+	if l.deadlockCallback != nil {
+		d.untrack = l.trackHeld()
+	}
+	if l.reentrancy != nil {
+		d.disown = l.trackOwner()
+	}
+	if l.cycleDetection() != nil {
+		d.unlink = l.trackHolding()
+	}
+}
+
+// tracksTokens returns true if tokens need details for tracking the
+// space that they hold or for misuse detection
+func (l *Limit[T]) tracksTokens() bool {
+	return l.leak != nil || l.holders != nil || l.maxHold != nil || l.misuse != nil ||
+		l.deadlockCallback != nil || l.reentrancy != nil || l.cycleDetection() != nil
+}
+
+// stopTracking stops tracking the token as holding space
+func (t *token[T]) stopTracking() {
+	d := t.details
+	if d == nil {
+		return
+	}
+	if d.untrack != nil {
+		d.untrack()
+		d.untrack = nil
+	}
+	if d.disown != nil {
+		d.disown()
+		d.disown = nil
+	}
+	if d.unlink != nil {
+		d.unlink()
+		d.unlink = nil
+	}
+	if d.unhold != nil {
+		d.unhold()
+		d.unhold = nil
+	}
+	if d.watchdog != nil {
+		d.watchdog.timer.Stop()
+	}
+}
+
+// Yield releases the space and then waits to get it back, giving
+// other waiters a chance to run. If the context is cancelled before
+// the space is obtained again, an error wrapping ctx.Err() is returned
+// and the Limited no longer holds space: Done becomes a no-op.
+func (t *token[T]) Yield(ctx context.Context) error {
+	if !t.held {
+		return nil
+	}
+	d := t.get()
+	onRelease := d.onRelease
+	t.release()
+	if d.label != "" && LabelFromContext(ctx) == "" {
+		ctx = ContextWithLabel(ctx, d.label)
+	}
+	done, _, err := t.limit.acquire(ctx, t.n, d.prio, d.class)
+	if err != nil {
+		if onRelease != nil {
+			onRelease()
+		}
+		return err
+	}
+	reacquired := done.(*token[T])
+	*t = *reacquired
+	if onRelease != nil {
+		t.more().onRelease = onRelease
+	}
+	if t.limit.leak != nil {
+		// t is now the owner and is already watched for leaks
+		reacquired.held = false
+		runtime.SetFinalizer(reacquired, nil)
+	}
+	return nil
+}
+
+// limited is the Limited for when space was not obtained
 type limited[T any] func()
 
 func (l limited[T]) privateMethod() {}
@@ -160,6 +702,8 @@ func (l limited[T]) Done() {
 		l()
 	}
 }
+func (l limited[T]) Yield(context.Context) error { return nil }
+func (l limited[T]) WaitDuration() time.Duration { return 0 }
 
 type unlimited[T any] struct{}
 
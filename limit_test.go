@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/singlestore-labs/simultaneous"
 )
@@ -111,3 +112,252 @@ func testLimit(t *testing.T, withStuck bool) {
 	assert.NotZero(t, fail.Load(), "fail")
 	assert.NotZero(t, success.Load(), "succeed")
 }
+
+func TestForever2(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	done, queued, err := limit.Forever2(context.Background())
+	require.NoError(t, err)
+	assert.False(t, queued, "immediate")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		done.Done()
+	}()
+	done, queued, err = limit.Forever2(context.Background())
+	require.NoError(t, err)
+	assert.True(t, queued, "had to wait")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, queued, err = limit.Forever2(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, queued)
+
+	done.Done()
+	done, err = limit.Timeout(context.Background(), 0)
+	require.NoError(t, err, "cancelled Forever2 did not hold space")
+	done.Done()
+}
+
+func TestRetype(t *testing.T) {
+	t.Parallel()
+
+	type readers struct{}
+	type writers struct{}
+	readLimit := simultaneous.New[readers](2)
+	writeLimit := simultaneous.Retype[readers, writers](readLimit)
+
+	requireWriter := func(simultaneous.Enforced[writers]) {}
+	done := writeLimit.Forever(context.Background())
+	requireWriter(done)
+
+	read, err := readLimit.Timeout(context.Background(), 0)
+	require.NoError(t, err)
+	_, err = readLimit.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "the writer holds space in the reader limit")
+
+	done.Done()
+	read2, err := readLimit.Timeout(context.Background(), 0)
+	require.NoError(t, err, "the writer released space in the reader limit")
+	_, err = writeLimit.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "readers hold space in the writer limit")
+	read.Done()
+	read2.Done()
+}
+
+func TestTimeoutBoundaries(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	ctx := context.Background()
+
+	_, err := limit.Timeout(ctx, -time.Nanosecond)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "negative is already expired even with space available")
+
+	done, err := limit.Timeout(ctx, 0)
+	require.NoError(t, err, "zero is a non-blocking attempt")
+
+	start := time.Now()
+	_, err = limit.Timeout(ctx, 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "zero does not wait")
+	assert.Less(t, time.Since(start), 10*time.Millisecond)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		done.Done()
+	}()
+	done, err = limit.Timeout(ctx, time.Second)
+	require.NoError(t, err, "positive waits for space")
+	done.Done()
+}
+
+func TestForeverBackground(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	done := limit.ForeverBackground()
+
+	acquired := make(chan simultaneous.Limited[any])
+	go func() {
+		acquired <- limit.ForeverBackground()
+	}()
+	select {
+	case <-acquired:
+		assert.Fail(t, "acquired while limit was full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	done.Done()
+	select {
+	case done = <-acquired:
+	case <-time.After(time.Second):
+		require.Fail(t, "not acquired after release")
+	}
+	done.Done()
+
+	done, err := limit.Timeout(context.Background(), 0)
+	require.NoError(t, err, "released")
+	done.Done()
+}
+
+func TestYield(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	done := limit.Forever(context.Background())
+
+	var order []string
+	var lock sync.Mutex
+	note := func(s string) {
+		lock.Lock()
+		defer lock.Unlock()
+		order = append(order, s)
+	}
+
+	waiterDone := make(chan struct{})
+	go func() {
+		defer close(waiterDone)
+		defer limit.Forever(context.Background()).Done()
+		note("waiter")
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	note("before yield")
+	require.NoError(t, done.Yield(context.Background()))
+	note("after yield")
+	<-waiterDone
+
+	_, err := limit.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "yield got the space back")
+	done.Done()
+	assert.Equal(t, []string{"before yield", "waiter", "after yield"}, order)
+
+	done, err = limit.Timeout(context.Background(), 0)
+	require.NoError(t, err)
+	done.Done()
+}
+
+func TestYieldCancelled(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	done := limit.Forever(context.Background())
+
+	other := make(chan simultaneous.Limited[any])
+	go func() {
+		other <- limit.Forever(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, done.Yield(ctx), context.DeadlineExceeded)
+	done.Done()
+	done.Done()
+
+	(<-other).Done()
+	done, err := limit.Timeout(context.Background(), 0)
+	require.NoError(t, err)
+	_, err = limit.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "the failed yield did not release extra space")
+	done.Done()
+}
+
+func TestAcquire(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	done, err := limit.Acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limit.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err = limit.Acquire(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		done.Done()
+	}()
+	done, err = limit.Acquire(context.Background())
+	require.NoError(t, err, "acquired after release")
+	done.Done()
+}
+
+func TestWaitDuration(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	held := limit.Forever(context.Background())
+	assert.Less(t, held.WaitDuration(), 10*time.Millisecond, "no wait")
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		held.Done()
+	}()
+	done := limit.Forever(context.Background())
+	assert.GreaterOrEqual(t, done.WaitDuration(), 20*time.Millisecond)
+
+	failed, err := limit.Timeout(context.Background(), 0)
+	assert.Error(t, err)
+	assert.Zero(t, failed.WaitDuration())
+
+	claimed := done.Transfer().Claim()
+	assert.GreaterOrEqual(t, claimed.WaitDuration(), 20*time.Millisecond, "carried by transfer")
+	claimed.Done()
+}
+
+func TestTimeoutError(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	held, err := limit.AcquireN(context.Background(), 2)
+	require.NoError(t, err)
+	defer held.Done()
+	go func() {
+		_, _ = limit.Timeout(context.Background(), time.Second)
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+
+	_, err = limit.Timeout(context.Background(), time.Millisecond)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+	var timeoutErr *simultaneous.TimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, simultaneous.TimeoutError{
+		Timeout:  time.Millisecond,
+		Capacity: 2,
+		InUse:    2,
+		Waiters:  1,
+	}, *timeoutErr)
+	assert.Contains(t, err.Error(), "timeout (1ms) expired")
+}
@@ -0,0 +1,140 @@
+package simultaneous
+
+import (
+	"context"
+	"time"
+)
+
+// Limiter is what can be acquired. It is implemented by *Limit and by the
+// limits in the distributed and simultaneousredis packages. Adapters
+// return a Limiter for the other kinds of limit: KeyedLimit.Limiter,
+// Limit.LimiterN, AdaptiveLimit.Limiter, and UnlimitedLimiter. A library
+// can take a Limiter to accept any of them.
+type Limiter[T any] interface {
+	// Acquire waits for space or for the context to be cancelled
+	Acquire(ctx context.Context) (Limited[T], error)
+	// TryAcquire takes space only if it is available right away. It
+	// returns false if it is not. An error is returned for reasons other
+	// than a lack of space, like a closed Limit.
+	TryAcquire(ctx context.Context) (Limited[T], bool, error)
+	// AcquireTimeout waits for space for at most timeout. If the timeout
+	// expires, the error matches ErrTimeout.
+	AcquireTimeout(ctx context.Context, timeout time.Duration) (Limited[T], error)
+}
+
+var _ Limiter[any] = &Limit[any]{}
+
+// TryAcquire takes space in the Limit if it is available without waiting.
+// It returns false if it is not. The error is not nil only if the Limit
+// is closed.
+func (l *Limit[T]) TryAcquire(ctx context.Context) (Limited[T], bool, error) {
+	return l.tryAcquire(ctx, 1)
+}
+
+func (l *Limit[T]) tryAcquire(ctx context.Context, n int64) (Limited[T], bool, error) {
+	done, ok := l.try(ctx, n)
+	if !ok && l.core.isClosed() {
+		return done, false, l.closedError()
+	}
+	return done, ok, nil
+}
+
+// AcquireTimeout is the same as Timeout
+func (l *Limit[T]) AcquireTimeout(ctx context.Context, timeout time.Duration) (Limited[T], error) {
+	return l.Timeout(ctx, timeout)
+}
+
+// UnlimitedLimiter returns a Limiter that always grants space right away.
+// It is Unlimited for code that takes a Limiter.
+func UnlimitedLimiter[T any]() Limiter[T] {
+	return unlimited[T]{}
+}
+
+func (u unlimited[T]) Acquire(context.Context) (Limited[T], error) {
+	return limited[T](nil), nil
+}
+
+func (u unlimited[T]) TryAcquire(context.Context) (Limited[T], bool, error) {
+	return limited[T](nil), true, nil
+}
+
+func (u unlimited[T]) AcquireTimeout(context.Context, time.Duration) (Limited[T], error) {
+	return limited[T](nil), nil
+}
+
+// LimiterN returns a Limiter that takes n units of space in the Limit for
+// each acquisition, like AcquireN. It panics if n is less than one.
+func (l *Limit[T]) LimiterN(n int64) Limiter[T] {
+	checkAmount(n)
+	return weightedLimiter[T]{
+		limit: l,
+		n:     n,
+	}
+}
+
+type weightedLimiter[T any] struct {
+	limit *Limit[T]
+	n     int64
+}
+
+func (w weightedLimiter[T]) Acquire(ctx context.Context) (Limited[T], error) {
+	return w.limit.AcquireN(ctx, w.n)
+}
+
+func (w weightedLimiter[T]) TryAcquire(ctx context.Context) (Limited[T], bool, error) {
+	return w.limit.tryAcquire(ctx, w.n)
+}
+
+func (w weightedLimiter[T]) AcquireTimeout(ctx context.Context, timeout time.Duration) (Limited[T], error) {
+	return w.limit.timeout(ctx, w.n, timeout)
+}
+
+// Limiter returns a Limiter for the limit of a specific key
+func (k *KeyedLimit[K, T]) Limiter(key K) Limiter[T] {
+	return keyedLimiter[K, T]{
+		keyed: k,
+		key:   key,
+	}
+}
+
+type keyedLimiter[K comparable, T any] struct {
+	keyed *KeyedLimit[K, T]
+	key   K
+}
+
+func (k keyedLimiter[K, T]) Acquire(ctx context.Context) (Limited[T], error) {
+	return k.keyed.Acquire(ctx, k.key)
+}
+
+func (k keyedLimiter[K, T]) TryAcquire(ctx context.Context) (Limited[T], bool, error) {
+	e := k.keyed.get(k.key)
+	done, ok, err := e.limit.TryAcquire(ctx)
+	return k.keyed.track(e, done), ok, err
+}
+
+func (k keyedLimiter[K, T]) AcquireTimeout(ctx context.Context, timeout time.Duration) (Limited[T], error) {
+	return k.keyed.Timeout(ctx, k.key, timeout)
+}
+
+// Limiter returns a Limiter for the AdaptiveLimit. The Limited values it
+// returns are AdaptiveLimited: use a type assertion to Report.
+func (a *AdaptiveLimit[T]) Limiter() Limiter[T] {
+	return adaptiveLimiter[T]{a}
+}
+
+type adaptiveLimiter[T any] struct {
+	adaptive *AdaptiveLimit[T]
+}
+
+func (a adaptiveLimiter[T]) Acquire(ctx context.Context) (Limited[T], error) {
+	return a.adaptive.Acquire(ctx)
+}
+
+func (a adaptiveLimiter[T]) TryAcquire(ctx context.Context) (Limited[T], bool, error) {
+	done, ok, err := a.adaptive.limit.TryAcquire(ctx)
+	return a.adaptive.wrap(done), ok, err
+}
+
+func (a adaptiveLimiter[T]) AcquireTimeout(ctx context.Context, timeout time.Duration) (Limited[T], error) {
+	return a.adaptive.Timeout(ctx, timeout)
+}
@@ -0,0 +1,66 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Parallel()
+
+	keyed := simultaneous.NewKeyed[string, any](1)
+	for name, limiter := range map[string]simultaneous.Limiter[any]{
+		"limit":    simultaneous.New[any](1),
+		"weighted": simultaneous.New[any](3).LimiterN(2),
+		"keyed":    keyed.Limiter("a"),
+		"adaptive": simultaneous.NewAdaptive[any](1, simultaneous.AIMD{}).Limiter(),
+	} {
+		done, err := limiter.Acquire(context.Background())
+		require.NoError(t, err, name)
+
+		_, ok, err := limiter.TryAcquire(context.Background())
+		assert.NoError(t, err, name)
+		assert.False(t, ok, name)
+		_, err = limiter.AcquireTimeout(context.Background(), time.Millisecond)
+		assert.ErrorIs(t, err, simultaneous.ErrTimeout, name)
+
+		done.Done()
+		done, ok, err = limiter.TryAcquire(context.Background())
+		assert.NoError(t, err, name)
+		assert.True(t, ok, name)
+		done.Done()
+		done, err = limiter.AcquireTimeout(context.Background(), time.Millisecond)
+		assert.NoError(t, err, name)
+		done.Done()
+	}
+}
+
+func TestUnlimitedLimiter(t *testing.T) {
+	t.Parallel()
+
+	limiter := simultaneous.UnlimitedLimiter[any]()
+	for i := 0; i < 3; i++ {
+		_, ok, err := limiter.TryAcquire(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+	done, err := limiter.AcquireTimeout(context.Background(), 0)
+	require.NoError(t, err)
+	done.Done()
+}
+
+func TestLimiterClosed(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	limit.Close()
+	_, ok, err := limit.TryAcquire(context.Background())
+	assert.False(t, ok)
+	assert.ErrorIs(t, err, simultaneous.ErrClosed)
+}
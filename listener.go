@@ -0,0 +1,85 @@
+package simultaneous
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// LimitListener returns a Listener that accepts at most as many
+// simultaneous connections as the Limit allows. Accept waits for space
+// in the Limit before accepting a connection, and the space is released
+// when the connection is closed. Waiting uses Acquire so the stuck
+// callbacks and observers of the Limit apply. Closing the Listener stops
+// any Accept that is waiting for space.
+//
+// When the Limit turns a connection away without space, for example
+// because of WithMaxWaiters or WithShedding, the connection is accepted
+// and closed at once and Accept goes on to the next one. Once the Limit
+// is closed, Accept returns an error wrapping ErrClosed.
+//
+// It can be used with http.Server:
+//
+//	server.Serve(simultaneous.LimitListener(listener, limit))
+func LimitListener[T any](listener net.Listener, limit *Limit[T]) net.Listener {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &limitListener[T]{
+		Listener: listener,
+		limit:    limit,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+type limitListener[T any] struct {
+	net.Listener
+	limit  *Limit[T]
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (l *limitListener[T]) Accept() (net.Conn, error) {
+	for {
+		done, err := l.limit.Acquire(l.ctx)
+		if l.ctx.Err() != nil {
+			done.Done()
+			return nil, net.ErrClosed
+		}
+		if err != nil && l.limit.core.isClosed() {
+			return nil, err
+		}
+		conn, acceptErr := l.Listener.Accept()
+		if acceptErr != nil {
+			done.Done()
+			return nil, acceptErr
+		}
+		if err != nil {
+			// turned away: shed the connection rather than serve it
+			// without space
+			_ = conn.Close()
+			continue
+		}
+		return &limitConn[T]{
+			Conn: conn,
+			done: done,
+		}, nil
+	}
+}
+
+func (l *limitListener[T]) Close() error {
+	err := l.Listener.Close()
+	l.cancel()
+	return err
+}
+
+type limitConn[T any] struct {
+	net.Conn
+	once sync.Once
+	done Limited[T]
+}
+
+func (c *limitConn[T]) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.done.Done)
+	return err
+}
@@ -0,0 +1,107 @@
+package simultaneous_test
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestLimitListener(t *testing.T) {
+	t.Parallel()
+
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	limit := simultaneous.New[any](1)
+	listener := simultaneous.LimitListener(inner, limit)
+
+	accepted := make(chan net.Conn)
+	acceptErr := make(chan error, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				acceptErr <- err
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	client1, err := net.Dial("tcp", inner.Addr().String())
+	require.NoError(t, err)
+	defer client1.Close()
+	conn1 := <-accepted
+	assert.Equal(t, 1, limit.InUse())
+
+	client2, err := net.Dial("tcp", inner.Addr().String())
+	require.NoError(t, err)
+	defer client2.Close()
+	select {
+	case <-accepted:
+		t.Fatal("accepted over the limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+	assert.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+
+	require.NoError(t, conn1.Close())
+	_ = conn1.Close() // closing twice releases once
+	conn2 := <-accepted
+	assert.Equal(t, 1, limit.InUse())
+
+	// Accept is waiting for space again; Close stops it
+	assert.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, listener.Close())
+	assert.ErrorIs(t, <-acceptErr, net.ErrClosed)
+	require.NoError(t, conn2.Close())
+	assert.Equal(t, 0, limit.InUse())
+}
+
+func TestLimitListenerTurnedAway(t *testing.T) {
+	t.Parallel()
+
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	limit := simultaneous.New[any](1)
+	listener := simultaneous.LimitListener(inner, limit)
+	defer listener.Close()
+
+	accepted := make(chan net.Conn)
+	acceptErr := make(chan error, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				acceptErr <- err
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	client1, err := net.Dial("tcp", inner.Addr().String())
+	require.NoError(t, err)
+	defer client1.Close()
+	conn1 := <-accepted
+	defer conn1.Close()
+
+	// a connection that arrives after the wait is ended is shed
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	require.True(t, limit.CancelOldestWaiter())
+	client2, err := net.Dial("tcp", inner.Addr().String())
+	require.NoError(t, err)
+	defer client2.Close()
+	require.NoError(t, client2.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err = client2.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF, "closed by the listener")
+	assert.Equal(t, 1, limit.InUse(), "not served without space")
+
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	limit.Close()
+	assert.ErrorIs(t, <-acceptErr, simultaneous.ErrClosed)
+}
@@ -0,0 +1,36 @@
+package simultaneous
+
+import (
+	"context"
+)
+
+// eventLogger receives the events logged by WithLogger. It is an interface
+// so that only the code for WithLogger, which requires go1.21, depends on
+// log/slog.
+type eventLogger interface {
+	log(ctx context.Context, level logLevel, msg string, args ...any)
+}
+
+type logLevel int
+
+const (
+	logDebug logLevel = iota
+	logInfo
+	logWarn
+	logError
+)
+
+// logEvent logs an event, if there is a logger, along with the name and
+// the state of the Limit. args are alternating keys and values.
+func (l *Limit[T]) logEvent(ctx context.Context, level logLevel, msg string, args ...any) {
+	if l.logger == nil {
+		return
+	}
+	stats := l.Stats()
+	l.logger.log(ctx, level, msg, append([]any{
+		"limit", l.name,
+		"capacity", stats.Capacity,
+		"in_use", stats.InUse,
+		"waiters", stats.Waiters,
+	}, args...)...)
+}
@@ -0,0 +1,36 @@
+package simultaneous
+
+import (
+	"github.com/memsql/errors"
+)
+
+// ErrQueueFull is returned when space is not available and the Limit
+// already has as many waiters as WithMaxWaiters allows
+var ErrQueueFull errors.String = "too many waiting for simultaneous limit"
+
+// WithMaxWaiters limits the number of callers that may wait for space at
+// once. When that many are already waiting, callers that can't get space
+// right away fail immediately: methods that return an error return one
+// wrapping ErrQueueFull and Forever returns a Limited that does not hold
+// space. Zero means no limit.
+//
+// WithMaxWaiters changes the Limit and all of its copies. It returns the
+// Limit so that it can be chained with New.
+func (l *Limit[T]) WithMaxWaiters(max int) *Limit[T] {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	l.core.maxWaiters = max
+	return l
+}
+
+// WithMaxWaiters limits the number of callers that may wait for space.
+// See the WithMaxWaiters method.
+func WithMaxWaiters(max int) Option {
+	return func(s *state) {
+		s.core.maxWaiters = max
+	}
+}
+
+func (l *Limit[T]) queueFullError() error {
+	return ErrQueueFull.Errorf("%d already waiting for simultaneous limit (of %d)", l.Waiting(), l.capacity())
+}
@@ -0,0 +1,51 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestMaxWaiters(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1, simultaneous.WithMaxWaiters(2))
+	held := limit.Forever(context.Background())
+
+	granted := make(chan simultaneous.Limited[any], 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			granted <- limit.Forever(context.Background())
+		}()
+	}
+	require.Eventually(t, func() bool { return limit.Waiting() == 2 }, time.Second, time.Millisecond)
+
+	_, err := limit.Acquire(context.Background())
+	assert.ErrorIs(t, err, simultaneous.ErrQueueFull, "Acquire")
+	_, err = limit.Timeout(context.Background(), time.Second)
+	assert.ErrorIs(t, err, simultaneous.ErrQueueFull, "Timeout")
+	limit.Forever(context.Background()).Done()
+	assert.Equal(t, 2, limit.Waiting())
+
+	held.Done()
+	(<-granted).Done()
+	(<-granted).Done()
+
+	limit.WithMaxWaiters(0)
+	held = limit.Forever(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, _ = limit.Acquire(ctx)
+		}()
+	}
+	assert.Eventually(t, func() bool { return limit.Waiting() == 3 }, time.Second, time.Millisecond, "no limit")
+	cancel()
+	held.Done()
+}
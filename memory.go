@@ -0,0 +1,137 @@
+package simultaneous
+
+import (
+	"context"
+	"math"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/memsql/errors"
+)
+
+// ErrOverBudget is returned when an acquisition asks for more than could
+// ever be granted: more bytes than the whole budget of a MemoryBudget, or
+// more of a resource than the capacity of a MultiLimit
+var ErrOverBudget errors.String = "more than the whole budget"
+
+// ErrNoMemoryLimit is returned by NewMemoryFraction when there is no
+// memory limit to take a fraction of
+var ErrNoMemoryLimit errors.String = "no memory limit found"
+
+// MemoryBudget is a Limit measured in bytes. Each acquisition declares
+// how many bytes it expects to use and waits until the total of what is
+// held fits in the budget. It is for bounding work, like materializing
+// result sets, where the number of runners is the wrong unit.
+type MemoryBudget[T any] struct {
+	limit *Limit[T]
+}
+
+// NewMemoryBudget creates a MemoryBudget of bytes
+func NewMemoryBudget[T any](bytes int64, opts ...Option) *MemoryBudget[T] {
+	return &MemoryBudget[T]{
+		limit: New[T](budgetSize(bytes), opts...),
+	}
+}
+
+// NewMemoryFraction creates a MemoryBudget that is a fraction of the
+// memory the process may use: the soft limit set by GOMEMLIMIT or
+// debug.SetMemoryLimit if there is one, otherwise the memory limit of
+// the process's cgroup (v1 or v2). If neither is set, it returns an
+// error wrapping ErrNoMemoryLimit.
+//
+//	// result sets may use up to half of the container's memory
+//	results, err := simultaneous.NewMemoryFraction[resultSets](0.5)
+func NewMemoryFraction[T any](fraction float64, opts ...Option) (*MemoryBudget[T], error) {
+	limit, ok := memoryLimit()
+	if !ok {
+		return nil, ErrNoMemoryLimit.Errorf("cannot size a simultaneous memory budget as %g of memory", fraction)
+	}
+	return NewMemoryBudget[T](int64(float64(limit)*fraction), opts...), nil
+}
+
+// Budget returns the size of the budget in bytes
+func (m *MemoryBudget[T]) Budget() int64 { return int64(m.limit.Limit()) }
+
+// SetBudget changes the size of the budget
+func (m *MemoryBudget[T]) SetBudget(bytes int64) { m.limit.SetLimit(budgetSize(bytes)) }
+
+// InUse returns the number of bytes currently held
+func (m *MemoryBudget[T]) InUse() int64 { return int64(m.limit.InUse()) }
+
+// Stats is like Limit.Stats with all of the amounts in bytes
+func (m *MemoryBudget[T]) Stats() Stats { return m.limit.Stats() }
+
+// Base returns the underlying Limit, where each unit is a byte
+func (m *MemoryBudget[T]) Base() *Limit[T] { return m.limit }
+
+// Acquire waits until bytes fit in the budget (or for the context to be
+// cancelled) and then holds them until Done is called. A request for
+// more than the whole budget fails right away with an error wrapping both
+// ErrOverBudget and ErrExceedsCapacity, as does a waiting request once
+// SetBudget lowers the budget below it. If the context is cancelled
+// first, an error wrapping ctx.Err() is returned. Like AcquireN, it
+// panics if bytes is less than one.
+func (m *MemoryBudget[T]) Acquire(ctx context.Context, bytes int64) (Limited[T], error) {
+	if err := m.check(bytes); err != nil {
+		return limited[T](nil), err
+	}
+	done, err := m.limit.AcquireN(ctx, bytes)
+	if errors.Is(err, ErrExceedsCapacity) {
+		return done, ErrOverBudget.Errorf("asked for %d bytes from a simultaneous memory budget that was lowered: %w", bytes, err)
+	}
+	return done, err
+}
+
+// TryAcquire holds bytes if they fit in the budget now. It returns false
+// if they do not. A request for more than the whole budget returns an
+// error wrapping both ErrOverBudget and ErrExceedsCapacity. It panics if
+// bytes is less than one.
+func (m *MemoryBudget[T]) TryAcquire(bytes int64) (Limited[T], bool, error) {
+	if err := m.check(bytes); err != nil {
+		return limited[T](nil), false, err
+	}
+	done, ok := m.limit.TryAcquireN(bytes)
+	return done, ok, nil
+}
+
+// check rejects a request for more than the whole budget. As for a Limit,
+// a budget of zero is treated as paused rather than as too small.
+func (m *MemoryBudget[T]) check(bytes int64) error {
+	if budget := m.Budget(); budget > 0 && bytes > budget {
+		return ErrOverBudget.Errorf("asked for %d bytes from a simultaneous memory budget of %d: %w", bytes, budget, m.limit.exceedsCapacityError(bytes))
+	}
+	return nil
+}
+
+// budgetSize converts bytes to the size of a Limit
+func budgetSize(bytes int64) int {
+	if bytes > math.MaxInt {
+		return math.MaxInt
+	}
+	return int(bytes)
+}
+
+// memoryLimit returns the memory limit of the process, if there is one
+func memoryLimit() (int64, bool) {
+	if limit := debug.SetMemoryLimit(-1); limit != math.MaxInt64 {
+		return limit, true
+	}
+	for _, file := range []string{
+		"/sys/fs/cgroup/memory.max",                   // v2
+		"/sys/fs/cgroup/memory/memory.limit_in_bytes", // v1
+	} {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		limit, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+		// v1 reports a huge number rather than "max" for no limit
+		if err != nil || limit <= 0 || limit >= math.MaxInt64/2 {
+			return 0, false
+		}
+		return limit, true
+	}
+	return 0, false
+}
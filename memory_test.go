@@ -0,0 +1,79 @@
+package simultaneous_test
+
+import (
+	"context"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	t.Parallel()
+
+	budget := simultaneous.NewMemoryBudget[any](1 << 20)
+	ctx := context.Background()
+	big, err := budget.Acquire(ctx, 768<<10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(768<<10), budget.InUse())
+
+	_, ok, err := budget.TryAcquire(512 << 10)
+	require.NoError(t, err)
+	assert.False(t, ok, "does not fit")
+	small, ok, err := budget.TryAcquire(256 << 10)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = budget.Acquire(ctx, 2<<20)
+	assert.ErrorIs(t, err, simultaneous.ErrOverBudget, "could never fit")
+	assert.ErrorIs(t, err, simultaneous.ErrExceedsCapacity, "could never fit")
+
+	got := make(chan simultaneous.Limited[any])
+	go func() {
+		done, err := budget.Acquire(ctx, 512<<10)
+		assert.NoError(t, err)
+		got <- done
+	}()
+	require.Eventually(t, func() bool { return budget.Stats().Waiters == 1 }, time.Second, time.Millisecond)
+	big.Done()
+	(<-got).Done()
+	small.Done()
+	assert.Zero(t, budget.InUse())
+}
+
+// TestMemoryFraction sets the memory limit so it does not run in parallel
+func TestMemoryFraction(t *testing.T) {
+	previous := debug.SetMemoryLimit(1 << 30)
+	defer debug.SetMemoryLimit(previous)
+
+	budget, err := simultaneous.NewMemoryFraction[any](0.25)
+	require.NoError(t, err)
+	assert.Equal(t, int64(256<<20), budget.Budget())
+}
+
+func TestMemoryBudgetLowered(t *testing.T) {
+	t.Parallel()
+
+	budget := simultaneous.NewMemoryBudget[any](1 << 10)
+	ctx := context.Background()
+	held, err := budget.Acquire(ctx, 512)
+	require.NoError(t, err)
+	result := make(chan error)
+	go func() {
+		_, err := budget.Acquire(ctx, 1<<10)
+		result <- err
+	}()
+	require.Eventually(t, func() bool { return budget.Stats().Waiters == 1 }, time.Second, time.Millisecond)
+
+	budget.SetBudget(768)
+	err = <-result
+	assert.ErrorIs(t, err, simultaneous.ErrOverBudget)
+	assert.ErrorIs(t, err, simultaneous.ErrExceedsCapacity)
+	_, _, err = budget.TryAcquire(1 << 10)
+	assert.ErrorIs(t, err, simultaneous.ErrExceedsCapacity)
+	held.Done()
+}
@@ -0,0 +1,82 @@
+package simultaneous
+
+import (
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+
+	"github.com/memsql/errors"
+)
+
+// ErrDoubleDone is reported when Done is called more than once on the
+// same Limited
+var ErrDoubleDone errors.String = "Done called more than once"
+
+// misuseDetection is configured by WithMisuseDetection
+type misuseDetection struct {
+	callback func(error)
+}
+
+// WithMisuseDetection returns a modified Limit that reports misuse of the
+// Limiteds it returns. Calling Done twice on the same Limited is always
+// harmless: the second call does nothing. With misuse detection, the
+// second call also reports an error wrapping ErrDoubleDone that includes
+// the stack trace of the first call. If callback is nil, the second call
+// panics with the error instead.
+//
+// Done after Transfer, or after a Yield that failed, is not misuse.
+func (l Limit[T]) WithMisuseDetection(callback func(error)) *Limit[T] {
+	l.misuse = &misuseDetection{
+		callback: callback,
+	}
+	return &l
+}
+
+// WithMisuseDetection reports misuse of Limiteds. See the
+// WithMisuseDetection method.
+func WithMisuseDetection(callback func(error)) Option {
+	return func(s *state) {
+		s.misuse = &misuseDetection{
+			callback: callback,
+		}
+	}
+}
+
+// doneStackLock protects token.doneStack, which is only recorded with
+// misuse detection
+var doneStackLock sync.Mutex
+
+// markDone records that Done has been called. It returns false, after
+// reporting misuse, if Done had already been called. Only one of any
+// number of concurrent calls returns true.
+func (t *token[T]) markDone() bool {
+	misuse := t.limit.misuse
+	if misuse == nil {
+		return atomic.CompareAndSwapUint32(&t.done, 0, 1)
+	}
+	doneStackLock.Lock()
+	if atomic.CompareAndSwapUint32(&t.done, 0, 1) {
+		t.more().doneStack = debug.Stack()
+		doneStackLock.Unlock()
+		return true
+	}
+	err := ErrDoubleDone.Errorf("Done called twice on space in a simultaneous limit (of %d); first called from:\n%s", t.limit.capacity(), t.get().doneStack)
+	doneStackLock.Unlock()
+	if misuse.callback == nil {
+		panic(err)
+	}
+	misuse.callback(err)
+	return false
+}
+
+// DoneOnce returns a function that calls done.Done. It is for passing
+// the release to callback-based APIs that might call it more than once:
+// only the first call releases the space and later calls are never
+// reported as misuse. The function is safe to call from any goroutine,
+// including concurrently.
+func DoneOnce[T any](done Limited[T]) func() {
+	var once sync.Once
+	return func() {
+		once.Do(done.Done)
+	}
+}
@@ -0,0 +1,130 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func firstDone(done simultaneous.Limited[any]) {
+	done.Done()
+}
+
+func TestDoubleDone(t *testing.T) {
+	t.Parallel()
+
+	var reported []error
+	limit := simultaneous.New[any](2, simultaneous.WithMisuseDetection(func(err error) {
+		reported = append(reported, err)
+	}))
+	a := limit.Forever(context.Background())
+	b := limit.Forever(context.Background())
+	firstDone(a)
+	a.Done()
+	assert.Equal(t, 1, limit.InUse(), "second Done did not release b's space")
+	require.Len(t, reported, 1)
+	assert.ErrorIs(t, reported[0], simultaneous.ErrDoubleDone)
+	assert.Contains(t, reported[0].Error(), "firstDone")
+
+	// not misuse
+	ticket := b.Transfer()
+	b.Done()
+	c := ticket.Claim()
+	c.Done()
+	assert.Len(t, reported, 1)
+	assert.Equal(t, 0, limit.InUse())
+}
+
+func TestDoubleDoneStrict(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1).WithMisuseDetection(nil)
+	done := limit.Forever(context.Background())
+	done.Done()
+	assert.Panics(t, done.Done)
+	assert.Equal(t, 0, limit.InUse())
+
+	quiet := simultaneous.New[any](1)
+	done = quiet.Forever(context.Background())
+	done.Done()
+	assert.NotPanics(t, done.Done, "without misuse detection")
+}
+
+func TestConcurrentDone(t *testing.T) {
+	t.Parallel()
+
+	var reports atomic.Int32
+	limit := simultaneous.New[any](1).WithMisuseDetection(func(err error) {
+		assert.ErrorIs(t, err, simultaneous.ErrDoubleDone)
+		reports.Add(1)
+	})
+	plain := simultaneous.New[any](1)
+	for _, l := range []*simultaneous.Limit[any]{limit, plain} {
+		done := l.Forever(context.Background())
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				done.Done()
+			}()
+		}
+		close(start)
+		wg.Wait()
+		assert.Zero(t, l.InUse())
+		assert.Equal(t, uint64(1), l.Stats().Releases)
+	}
+	assert.Equal(t, int32(9), reports.Load())
+}
+
+func TestDoneOnce(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1).WithMisuseDetection(func(err error) {
+		t.Errorf("unexpected misuse: %s", err)
+	})
+	release := simultaneous.DoneOnce(limit.Forever(context.Background()))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release()
+		}()
+	}
+	wg.Wait()
+	release()
+	assert.Zero(t, limit.InUse())
+
+	var releases atomic.Int32
+	external := simultaneous.DoneOnce(simultaneous.Adopt[any](countingExternal{&releases}))
+	external()
+	external()
+	assert.Equal(t, int32(1), releases.Load())
+
+	adopted := simultaneous.Adopt[any](countingExternal{&releases})
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			adopted.Done()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), releases.Load())
+}
+
+type countingExternal struct {
+	releases *atomic.Int32
+}
+
+func (c countingExternal) Release()                        { c.releases.Add(1) }
+func (c countingExternal) Reacquire(context.Context) error { return nil }
@@ -0,0 +1,236 @@
+package simultaneous
+
+import (
+	"container/list"
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/memsql/errors"
+)
+
+// Amounts are quantities of resources by dimension, like slots, memory,
+// or I/O tokens
+type Amounts map[string]int64
+
+func (a Amounts) String() string {
+	dims := make([]string, 0, len(a))
+	for dim := range a {
+		dims = append(dims, dim)
+	}
+	sort.Strings(dims)
+	var b strings.Builder
+	b.WriteString("{")
+	for i, dim := range dims {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(dim)
+		b.WriteString(":")
+		b.WriteString(strconv.FormatInt(a[dim], 10))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// MultiLimit limits several resources at once. Each acquisition says how
+// much of each resource it needs and is granted all of them in a single
+// step, so unlike chaining Limits for each resource, nothing is held
+// while waiting for the rest. One Done releases everything.
+//
+//	workers := simultaneous.NewMulti[jobs](simultaneous.Amounts{"slots": 8, "memMB": 4096, "io": 16})
+//	done, err := workers.Acquire(ctx, simultaneous.Amounts{"slots": 1, "memMB": 512, "io": 4})
+//
+// Waiters are granted resources in the order they arrived, but one that
+// needs more than is available does not stop smaller requests that fit
+// from being granted.
+type MultiLimit[T any] struct {
+	lock     sync.Mutex
+	capacity Amounts
+	used     Amounts
+	waiters  list.List // of *multiWaiter
+}
+
+type multiWaiter struct {
+	want  Amounts
+	ready chan struct{} // closed once granted
+}
+
+// NewMulti creates a MultiLimit with the given capacity of each resource.
+// Acquisitions may only ask for resources that have a capacity.
+func NewMulti[T any](capacity Amounts) *MultiLimit[T] {
+	m := &MultiLimit[T]{
+		capacity: make(Amounts, len(capacity)),
+		used:     make(Amounts, len(capacity)),
+	}
+	for dim, n := range capacity {
+		m.capacity[dim] = n
+	}
+	return m
+}
+
+// SetCapacity changes the capacity of one resource, or adds a resource
+func (m *MultiLimit[T]) SetCapacity(dim string, n int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.capacity[dim] = n
+	m.grant()
+}
+
+// Capacity returns the capacity of each resource
+func (m *MultiLimit[T]) Capacity() Amounts {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return copyAmounts(m.capacity)
+}
+
+// InUse returns how much of each resource is held
+func (m *MultiLimit[T]) InUse() Amounts {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return copyAmounts(m.used)
+}
+
+// Waiting returns the number of callers waiting
+func (m *MultiLimit[T]) Waiting() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.waiters.Len()
+}
+
+// Acquire waits until all of the resources wanted are available (or for
+// the context to be cancelled) and takes them. If the context is
+// cancelled first, an error wrapping ctx.Err() is returned. Asking for
+// more of a resource than its capacity, or for a resource without a
+// capacity, fails right away with an error wrapping ErrOverBudget.
+func (m *MultiLimit[T]) Acquire(ctx context.Context, want Amounts) (Limited[T], error) {
+	want = copyAmounts(want)
+	if err := m.wait(ctx, want); err != nil {
+		return limited[T](nil), err
+	}
+	return Adopt[T](&multiHold[T]{limit: m, want: want}), nil
+}
+
+// TryAcquire takes all of the resources wanted if they are available
+// now. It returns false if they are not. Asking for more than could ever
+// be available returns an error wrapping ErrOverBudget.
+func (m *MultiLimit[T]) TryAcquire(want Amounts) (Limited[T], bool, error) {
+	want = copyAmounts(want)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if err := m.check(want); err != nil {
+		return limited[T](nil), false, err
+	}
+	if m.waiters.Len() > 0 || !m.claim(want) {
+		return limited[T](nil), false, nil
+	}
+	return Adopt[T](&multiHold[T]{limit: m, want: want}), true, nil
+}
+
+func (m *MultiLimit[T]) wait(ctx context.Context, want Amounts) error {
+	m.lock.Lock()
+	if err := m.check(want); err != nil {
+		m.lock.Unlock()
+		return err
+	}
+	if m.claim(want) {
+		m.lock.Unlock()
+		return nil
+	}
+	w := &multiWaiter{
+		want:  want,
+		ready: make(chan struct{}),
+	}
+	elem := m.waiters.PushBack(w)
+	m.lock.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	select {
+	case <-w.ready:
+		// granted while giving up
+		m.release(want)
+	default:
+		m.waiters.Remove(elem)
+		m.grant()
+	}
+	return errors.Wrapf(contextError(ctx), "context cancelled while waiting for %s from simultaneous multi-limit", want)
+}
+
+// check must be called with the lock held
+func (m *MultiLimit[T]) check(want Amounts) error {
+	for dim, n := range want {
+		capacity, ok := m.capacity[dim]
+		if !ok || n > capacity {
+			return ErrOverBudget.Errorf("asked for %d %s from a simultaneous multi-limit with capacity %s", n, dim, m.capacity)
+		}
+	}
+	return nil
+}
+
+// claim takes the resources if they are all available. Must be called
+// with the lock held.
+func (m *MultiLimit[T]) claim(want Amounts) bool {
+	for dim, n := range want {
+		if m.capacity[dim]-m.used[dim] < n {
+			return false
+		}
+	}
+	for dim, n := range want {
+		m.used[dim] += n
+	}
+	return true
+}
+
+// release must be called with the lock held
+func (m *MultiLimit[T]) release(want Amounts) {
+	for dim, n := range want {
+		m.used[dim] -= n
+	}
+	m.grant()
+}
+
+// grant hands resources to waiters in order of arrival, skipping those
+// that do not fit. Must be called with the lock held.
+func (m *MultiLimit[T]) grant() {
+	for e := m.waiters.Front(); e != nil; {
+		next := e.Next()
+		w := e.Value.(*multiWaiter)
+		if m.claim(w.want) {
+			m.waiters.Remove(e)
+			close(w.ready)
+		}
+		e = next
+	}
+}
+
+// multiHold is the External for resources held in a MultiLimit
+type multiHold[T any] struct {
+	limit *MultiLimit[T]
+	want  Amounts
+}
+
+func (h *multiHold[T]) Release() {
+	h.limit.lock.Lock()
+	defer h.limit.lock.Unlock()
+	h.limit.release(h.want)
+}
+
+func (h *multiHold[T]) Reacquire(ctx context.Context) error {
+	return h.limit.wait(ctx, h.want)
+}
+
+func copyAmounts(a Amounts) Amounts {
+	c := make(Amounts, len(a))
+	for dim, n := range a {
+		c[dim] = n
+	}
+	return c
+}
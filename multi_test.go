@@ -0,0 +1,68 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestMultiLimit(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.NewMulti[any](simultaneous.Amounts{"slots": 2, "memMB": 1024})
+	ctx := context.Background()
+	a, err := limit.Acquire(ctx, simultaneous.Amounts{"slots": 1, "memMB": 768})
+	require.NoError(t, err)
+	assert.Equal(t, simultaneous.Amounts{"slots": 1, "memMB": 768}, limit.InUse())
+
+	_, ok, err := limit.TryAcquire(simultaneous.Amounts{"slots": 1, "memMB": 512})
+	require.NoError(t, err)
+	assert.False(t, ok, "a slot is free but not the memory")
+	assert.Equal(t, simultaneous.Amounts{"slots": 1, "memMB": 768}, limit.InUse(), "nothing partially held")
+
+	_, err = limit.Acquire(ctx, simultaneous.Amounts{"slots": 3})
+	assert.ErrorIs(t, err, simultaneous.ErrOverBudget)
+	_, err = limit.Acquire(ctx, simultaneous.Amounts{"gpus": 1})
+	assert.ErrorIs(t, err, simultaneous.ErrOverBudget, "unknown resource")
+
+	got := make(chan simultaneous.Limited[any])
+	go func() {
+		done, err := limit.Acquire(ctx, simultaneous.Amounts{"slots": 1, "memMB": 512})
+		assert.NoError(t, err)
+		got <- done
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	small, ok, err := limit.TryAcquire(simultaneous.Amounts{"memMB": 128})
+	require.NoError(t, err)
+	assert.False(t, ok, "try does not pass waiters")
+
+	a.Done()
+	b := <-got
+	assert.Equal(t, simultaneous.Amounts{"slots": 1, "memMB": 512}, limit.InUse())
+	b.Done()
+	small.Done()
+	assert.Equal(t, simultaneous.Amounts{"slots": 0, "memMB": 0}, limit.InUse())
+}
+
+func TestMultiLimitCancel(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.NewMulti[any](simultaneous.Amounts{"slots": 1})
+	held, err := limit.Acquire(context.Background(), simultaneous.Amounts{"slots": 1})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limit.Acquire(ctx, simultaneous.Amounts{"slots": 1})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "{slots:1}")
+	assert.Zero(t, limit.Waiting())
+
+	ticket := held.Transfer()
+	ticket.Claim().Done()
+	assert.Equal(t, simultaneous.Amounts{"slots": 0}, limit.InUse())
+}
@@ -0,0 +1,5 @@
+//go:build !simultaneous_debug
+
+package simultaneous_test
+
+const debugBuild = false
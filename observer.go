@@ -0,0 +1,187 @@
+package simultaneous
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// observerList is a list of observers that can be added and removed while
+// the list is in use. The list is replaced, never modified, so that get
+// does not need the lock.
+type observerList[O any] struct {
+	lock      sync.Mutex // held while replacing the list
+	observers atomic.Pointer[[]*O]
+}
+
+type stuckObserver struct {
+	stuck   func(context.Context)
+	unstuck func(context.Context)
+}
+
+type waitObserver func(time.Duration)
+
+// Observer is notified about every attempt to obtain space in a Limit,
+// no matter which method is used. It is meant for telemetry. Add one with
+// AddObserver.
+type Observer interface {
+	// OnWaitStart is called when there is no space and the caller
+	// starts waiting for it
+	OnWaitStart(ctx context.Context)
+	// OnStuck is called when the caller has been waiting for longer
+	// than the stuck timeout given to SetForeverMessaging
+	OnStuck(ctx context.Context, waited time.Duration)
+	// OnAcquired is called when space has been obtained. waited is
+	// zero if there was no need to wait.
+	OnAcquired(ctx context.Context, waited time.Duration)
+	// OnTimeout is called when the caller gives up without obtaining
+	// space, because of a timeout or because the context was cancelled
+	OnTimeout(ctx context.Context, waited time.Duration)
+}
+
+// observers are shared by all copies of a Limit
+type observers struct {
+	stuck    observerList[stuckObserver]
+	wait     observerList[waitObserver]
+	observer observerList[Observer]
+}
+
+func (ol *observerList[O]) add(o *O) (remove func()) {
+	ol.lock.Lock()
+	existing := ol.get()
+	observers := append(existing[:len(existing):len(existing)], o)
+	ol.observers.Store(&observers)
+	ol.lock.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ol.lock.Lock()
+			defer ol.lock.Unlock()
+			existing := ol.get()
+			for i := range existing {
+				if existing[i] == o {
+					observers := append(existing[:i:i], existing[i+1:]...)
+					ol.observers.Store(&observers)
+					break
+				}
+			}
+		})
+	}
+}
+
+func (ol *observerList[O]) get() []*O {
+	if observers := ol.observers.Load(); observers != nil {
+		return *observers
+	}
+	return nil
+}
+
+// AddStuckObserver adds a pair of callbacks that are invoked in addition to
+// the callbacks set with SetForeverMessaging. They are called on the same
+// transitions: when Forever has waited longer than the stuck timeout given to
+// SetForeverMessaging, and when it stops waiting after that. Without a stuck
+// timeout, observers are not called. Either callback may be nil.
+//
+// Observers are shared by all copies of the Limit. A panic in an observer is
+// recovered so that the other observers are still called. Call the returned
+// function to remove the observer.
+func (l *Limit[T]) AddStuckObserver(stuck func(context.Context), unstuck func(context.Context)) (remove func()) {
+	return l.observers.stuck.add(&stuckObserver{
+		stuck:   stuck,
+		unstuck: unstuck,
+	})
+}
+
+// AddWaitObserver adds a callback that is invoked each time space is
+// obtained with the time spent waiting for it (zero if there was no need
+// to wait). It is meant for collecting metrics. Like stuck observers, wait
+// observers are shared by all copies of the Limit and panics are recovered.
+// Call the returned function to remove the observer.
+func (l *Limit[T]) AddWaitObserver(observer func(waited time.Duration)) (remove func()) {
+	o := waitObserver(observer)
+	return l.observers.wait.add(&o)
+}
+
+// AddObserver adds an Observer. Unlike the callbacks set with
+// SetForeverMessaging, it is notified about acquisitions by every method,
+// including Timeout, TryAcquireN, and ForeverNoStuck (except that
+// ForeverNoStuck never calls OnStuck). Like the other observers, it is
+// shared by all copies of the Limit and panics are recovered. Call the
+// returned function to remove the observer.
+func (l *Limit[T]) AddObserver(observer Observer) (remove func()) {
+	return l.observers.observer.add(&observer)
+}
+
+// stuck is called when a waiter has been waiting for waited. The
+// callbacks are only called if messaging is true.
+func (l *Limit[T]) stuck(ctx context.Context, messaging bool, waited time.Duration) {
+	for _, o := range l.observers.observer.get() {
+		callObserver(func() { (*o).OnStuck(ctx, waited) })
+	}
+	if !messaging {
+		return
+	}
+	l.logEvent(ctx, logWarn, "simultaneous limit stuck", "waited", waited)
+	if l.stuckCallback != nil {
+		l.stuckCallback(ctx)
+	}
+	if l.stuckInfoCallback != nil {
+		l.stuckInfoCallback(ctx, l.stuckInfo(waited, false))
+	}
+	for _, o := range l.observers.stuck.get() {
+		if o.stuck != nil {
+			callObserver(func() { o.stuck(ctx) })
+		}
+	}
+}
+
+func (l *Limit[T]) unstuck(ctx context.Context, waited time.Duration, obtained bool) {
+	l.logEvent(ctx, logInfo, "simultaneous limit unstuck")
+	if l.unstuckCallback != nil {
+		l.unstuckCallback(ctx)
+	}
+	if l.unstuckInfoCallback != nil {
+		l.unstuckInfoCallback(ctx, l.stuckInfo(waited, obtained))
+	}
+	for _, o := range l.observers.stuck.get() {
+		if o.unstuck != nil {
+			callObserver(func() { o.unstuck(ctx) })
+		}
+	}
+}
+
+func (l *Limit[T]) waitStart(ctx context.Context) {
+	for _, o := range l.observers.observer.get() {
+		callObserver(func() { (*o).OnWaitStart(ctx) })
+	}
+}
+
+func (l *Limit[T]) waited(ctx context.Context, waited time.Duration) {
+	for _, o := range l.observers.wait.get() {
+		callObserver(func() { (*o)(waited) })
+	}
+	for _, o := range l.observers.observer.get() {
+		callObserver(func() { (*o).OnAcquired(ctx, waited) })
+	}
+}
+
+func (l *Limit[T]) gaveUp(ctx context.Context, start time.Time) {
+	observers := l.observers.observer.get()
+	if len(observers) == 0 {
+		return
+	}
+	waited := l.waitedSince(start)
+	for _, o := range observers {
+		callObserver(func() { (*o).OnTimeout(ctx, waited) })
+	}
+}
+
+func callObserver(f func()) {
+	defer func() {
+		// A misbehaving observer must not prevent other observers
+		// from being called or break the Limit.
+		_ = recover()
+	}()
+	f()
+}
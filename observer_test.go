@@ -0,0 +1,158 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestStuckObservers(t *testing.T) {
+	t.Parallel()
+
+	var primaryStuck, primaryUnstuck atomic.Int32
+	limit := simultaneous.New[any](1).SetForeverMessaging(time.Millisecond,
+		func(context.Context) { primaryStuck.Add(1) },
+		func(context.Context) { primaryUnstuck.Add(1) },
+	)
+
+	var stuck, unstuck [3]atomic.Int32
+	var removes []func()
+	for i := range stuck {
+		i := i
+		removes = append(removes, limit.AddStuckObserver(
+			func(context.Context) {
+				stuck[i].Add(1)
+				if i == 0 {
+					panic("observer panic")
+				}
+			},
+			func(context.Context) { unstuck[i].Add(1) },
+		))
+	}
+
+	getStuck := func() {
+		done := limit.Forever(context.Background())
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			done.Done()
+		}()
+		limit.Forever(context.Background()).Done()
+	}
+
+	getStuck()
+	assert.Equal(t, int32(1), primaryStuck.Load())
+	assert.Equal(t, int32(1), primaryUnstuck.Load())
+	for i := range stuck {
+		assert.Equal(t, int32(1), stuck[i].Load(), "stuck observer %d", i)
+		assert.Equal(t, int32(1), unstuck[i].Load(), "unstuck observer %d", i)
+	}
+
+	removes[1]()
+	removes[1]()
+	getStuck()
+	assert.Equal(t, int32(2), primaryStuck.Load())
+	assert.Equal(t, int32(2), primaryUnstuck.Load())
+	assert.Equal(t, int32(2), stuck[0].Load())
+	assert.Equal(t, int32(1), stuck[1].Load(), "removed")
+	assert.Equal(t, int32(1), unstuck[1].Load(), "removed")
+	assert.Equal(t, int32(2), stuck[2].Load())
+	assert.Equal(t, int32(2), unstuck[2].Load())
+}
+
+func TestForeverNoStuck(t *testing.T) {
+	t.Parallel()
+
+	var stuck, unstuck, observed atomic.Int32
+	limit := simultaneous.New[any](1).SetForeverMessaging(time.Millisecond,
+		func(context.Context) { stuck.Add(1) },
+		func(context.Context) { unstuck.Add(1) },
+	)
+	defer limit.AddStuckObserver(func(context.Context) { observed.Add(1) }, nil)()
+
+	done := limit.Forever(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		done.Done()
+	}()
+	start := time.Now()
+	limit.ForeverNoStuck(context.Background()).Done()
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond, "waited past the stuck timeout")
+	assert.Zero(t, stuck.Load())
+	assert.Zero(t, unstuck.Load())
+	assert.Zero(t, observed.Load())
+}
+
+type recordingObserver struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (r *recordingObserver) add(event string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recordingObserver) take() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	events := r.events
+	r.events = nil
+	return events
+}
+
+func (r *recordingObserver) OnWaitStart(context.Context)               { r.add("wait") }
+func (r *recordingObserver) OnStuck(context.Context, time.Duration)    { r.add("stuck") }
+func (r *recordingObserver) OnAcquired(context.Context, time.Duration) { r.add("acquired") }
+func (r *recordingObserver) OnTimeout(context.Context, time.Duration)  { r.add("timeout") }
+
+func TestObserver(t *testing.T) {
+	t.Parallel()
+
+	var messaging atomic.Int32
+	limit := simultaneous.New[any](1).SetForeverMessaging(5*time.Millisecond,
+		func(context.Context) { messaging.Add(1) },
+		func(context.Context) { messaging.Add(1) },
+	)
+	var observer recordingObserver
+	remove := limit.AddObserver(&observer)
+
+	held := limit.Forever(context.Background())
+	assert.Equal(t, []string{"acquired"}, observer.take(), "Forever")
+
+	_, ok := limit.TryAcquireN(1)
+	assert.False(t, ok)
+	assert.Equal(t, []string{"timeout"}, observer.take(), "TryAcquireN")
+
+	_, err := limit.Timeout(context.Background(), time.Millisecond)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"wait", "timeout"}, observer.take(), "Timeout")
+
+	_, err = limit.Timeout(context.Background(), 50*time.Millisecond)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"wait", "stuck", "timeout"}, observer.take(), "stuck Timeout")
+	assert.Equal(t, int32(0), messaging.Load(), "SetForeverMessaging callbacks are only for Forever")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_ = limit.ForeverNoStuck(ctx)
+	assert.Equal(t, []string{"wait", "timeout"}, observer.take(), "cancelled ForeverNoStuck")
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		held.Done()
+	}()
+	limit.Forever(context.Background()).Done()
+	assert.Equal(t, []string{"wait", "stuck", "acquired"}, observer.take(), "stuck Forever")
+	assert.Equal(t, int32(2), messaging.Load())
+
+	remove()
+	limit.Forever(context.Background()).Done()
+	assert.Empty(t, observer.take(), "removed")
+}
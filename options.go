@@ -0,0 +1,105 @@
+package simultaneous
+
+import (
+	"context"
+	"time"
+)
+
+// Option configures a Limit when it is created by New. Most Options also
+// have a method of the same name for an existing Limit, and those methods
+// come in two kinds.
+//
+// The methods for how space is granted change state that is shared by
+// every copy of the Limit, including those made by Retype: they change
+// the Limit in place and return it. They are WithAccumulatingReservation,
+// WithBurst, WithFairShare, WithFIFO, WithLIFO, WithMaxWaiters,
+// WithPriorityAging, WithReserved, WithShards, WithShedding, and
+// WithWarmUp.
+//
+// The other methods, such as WithClock, WithJitter, WithLogger, and those
+// for detection and tracking, return a modified copy and leave the
+// original unchanged. The method for WithStuckMessaging is called
+// SetForeverMessaging.
+//
+// WithName and WithObserver have no method; AddObserver adds an Observer
+// to an existing Limit.
+type Option func(*state)
+
+// WithName gives the Limit a name. The name is returned by Name and is
+// meant for diagnostics.
+func WithName(name string) Option {
+	return func(s *state) {
+		s.name = name
+	}
+}
+
+// WithFIFO makes the Limit strictly first-in, first-out. See the WithFIFO
+// method.
+func WithFIFO() Option {
+	return func(s *state) {
+		s.core.fifo = true
+		s.core.updateSlow()
+	}
+}
+
+// WithPriorityAging raises the priority of waiters by one for every
+// interval that they wait. See the WithPriorityAging method.
+func WithPriorityAging(interval time.Duration) Option {
+	return func(s *state) {
+		s.core.aging = interval
+	}
+}
+
+// WithStuckMessaging sets callbacks for when Forever has waited for longer
+// than stuckTimeout. See SetForeverMessaging.
+func WithStuckMessaging(stuckTimeout time.Duration, stuckCallback func(context.Context), unstuckCallback func(context.Context)) Option {
+	return func(s *state) {
+		s.stuckTimeout = stuckTimeout
+		s.stuckCallback = stuckCallback
+		s.unstuckCallback = unstuckCallback
+	}
+}
+
+// WithObserver adds an Observer. It is the way to connect metrics to a
+// Limit from the start. See AddObserver.
+func WithObserver(observer Observer) Option {
+	return func(s *state) {
+		s.observers.observer.add(&observer)
+	}
+}
+
+// WithDeadlockDetection checks the order in which Limits are acquired.
+// See the WithDeadlockDetection method.
+func WithDeadlockDetection(callback func(context.Context, error)) Option {
+	return func(s *state) {
+		s.deadlockCallback = callback
+	}
+}
+
+// WithJitter adds a random delay after waiting. See the WithJitter method.
+func WithJitter(max time.Duration) Option {
+	return func(s *state) {
+		s.jitter = max
+	}
+}
+
+// WithPanicToError controls whether RunRecover returns panics as errors.
+// See the WithPanicToError method.
+func WithPanicToError(convert bool) Option {
+	return func(s *state) {
+		s.repanic = !convert
+	}
+}
+
+// WithEventTrace records the most recent size events. See the
+// WithEventTrace method.
+func WithEventTrace(size int) Option {
+	return func(s *state) {
+		s.trace = newEventTrace(size)
+	}
+}
+
+// Name returns the name given with WithName
+func (l *Limit[T]) Name() string {
+	return l.name
+}
@@ -0,0 +1,53 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	var stuck, unstuck atomic.Int32
+	var observer recordingObserver
+	limit := simultaneous.New[any](2,
+		simultaneous.WithName("options"),
+		simultaneous.WithFIFO(),
+		simultaneous.WithStuckMessaging(time.Millisecond,
+			func(context.Context) { stuck.Add(1) },
+			func(context.Context) { unstuck.Add(1) }),
+		simultaneous.WithObserver(&observer),
+		simultaneous.WithEventTrace(10),
+	)
+	assert.Equal(t, "options", limit.Name())
+	assert.Equal(t, 2, limit.Limit())
+
+	held, err := limit.AcquireN(context.Background(), 1)
+	require.NoError(t, err)
+	big := make(chan simultaneous.Limited[any])
+	go func() {
+		done, err := limit.AcquireN(context.Background(), 2)
+		assert.NoError(t, err)
+		big <- done
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	_, ok := limit.TryAcquireN(1)
+	assert.False(t, ok, "fifo")
+
+	time.Sleep(5 * time.Millisecond)
+	held.Done()
+	(<-big).Done()
+	assert.Equal(t, int32(1), stuck.Load())
+	assert.Equal(t, int32(1), unstuck.Load())
+	assert.Contains(t, observer.take(), "stuck")
+	assert.NotEmpty(t, limit.Events())
+
+	assert.Empty(t, simultaneous.New[any](1).Name())
+}
@@ -0,0 +1,20 @@
+package simultaneous
+
+// Pause stops the Limit from granting space. Holders of space are not
+// affected and may release it as usual. Callers that want space wait, or
+// time out, as they would if the Limit were full. Pause affects all
+// copies of the Limit.
+func (l *Limit[T]) Pause() {
+	l.core.pause(true)
+}
+
+// Resume undoes Pause. Waiting callers are granted space as it is
+// available.
+func (l *Limit[T]) Resume() {
+	l.core.pause(false)
+}
+
+// Paused returns true if the Limit has been paused
+func (l *Limit[T]) Paused() bool {
+	return l.core.isPaused()
+}
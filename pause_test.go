@@ -0,0 +1,43 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestPause(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	held := limit.Forever(context.Background())
+	limit.Pause()
+	assert.True(t, limit.Paused())
+
+	_, err := limit.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "space is available but paused")
+
+	granted := make(chan simultaneous.Limited[any])
+	go func() {
+		granted <- limit.Forever(context.Background())
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+
+	held.Done()
+	assert.Equal(t, 0, limit.InUse(), "holders release normally")
+	select {
+	case <-granted:
+		assert.Fail(t, "granted while paused")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	limit.Resume()
+	assert.False(t, limit.Paused())
+	(<-granted).Done()
+	limit.Forever(context.Background()).Done()
+}
@@ -0,0 +1,174 @@
+package simultaneous
+
+import (
+	"context"
+	"sync"
+
+	"github.com/memsql/errors"
+)
+
+// Pool combines a Limit with a set of reusable resources (like connections).
+// At most limit resources will exist at any one time: a resource is only
+// created when a slot has been obtained and there is no idle resource to
+// reuse.
+type Pool[T any, R any] struct {
+	limit   *Limit[T]
+	factory func() (R, error)
+	reset   func(R)
+	check   func(R) error
+	destroy func(R)
+	lock    sync.Mutex
+	idle    []R
+	closed  bool
+}
+
+// Pooled is a resource that has been obtained from a Pool. It implements
+// Enforced so it can be passed as proof that the limit is being obeyed.
+// Release must be called exactly once to return the resource to the pool.
+type Pooled[T any, R any] struct {
+	pool     *Pool[T, R]
+	resource R
+	done     Limited[T]
+}
+
+var _ Enforced[any] = &Pooled[any, any]{}
+
+// NewPool creates a Pool that allows at most limit resources to be in
+// use at once. The factory is called to create new resources. If reset
+// is not nil, it is called on each resource as it is returned to the pool.
+func NewPool[T any, R any](limit int, factory func() (R, error), reset func(R)) *Pool[T, R] {
+	return &Pool[T, R]{
+		limit:   New[T](limit),
+		factory: factory,
+		reset:   reset,
+		idle:    make([]R, 0, limit),
+	}
+}
+
+// WithHealthCheck sets a function that is called on an idle resource
+// before Get hands it out. If it returns an error, the resource is
+// destroyed and Get tries another. It returns the Pool so that it can be
+// chained with NewPool.
+func (p *Pool[T, R]) WithHealthCheck(check func(R) error) *Pool[T, R] {
+	p.check = check
+	return p
+}
+
+// WithDestroy sets a function that is called on resources that are
+// thrown away: those that fail the health check, those passed to
+// Discard, and idle resources when the Pool is closed. It returns the
+// Pool so that it can be chained with NewPool.
+func (p *Pool[T, R]) WithDestroy(destroy func(R)) *Pool[T, R] {
+	p.destroy = destroy
+	return p
+}
+
+// Get waits for a slot in the pool and then returns either an idle resource
+// or a newly created one. If the context is cancelled before a slot becomes
+// available, an error wrapping ctx.Err() is returned. If the factory returns
+// an error, the slot is released and the error is returned.
+func (p *Pool[T, R]) Get(ctx context.Context) (*Pooled[T, R], error) {
+	done := p.limit.Forever(ctx)
+	if err := ctx.Err(); err != nil {
+		done.Done()
+		return nil, errors.Wrapf(err, "context cancelled before any pooled resource (of %d) became available", p.limit.capacity())
+	}
+	for {
+		resource, ok := p.takeIdle()
+		if !ok {
+			break
+		}
+		if p.check != nil {
+			if err := p.check(resource); err != nil {
+				p.destroyResource(resource)
+				continue
+			}
+		}
+		return &Pooled[T, R]{
+			pool:     p,
+			resource: resource,
+			done:     done,
+		}, nil
+	}
+	resource, err := p.factory()
+	if err != nil {
+		done.Done()
+		return nil, errors.Wrap(err, "create pooled resource")
+	}
+	return &Pooled[T, R]{
+		pool:     p,
+		resource: resource,
+		done:     done,
+	}, nil
+}
+
+// takeIdle removes the most recently used idle resource from the pool
+func (p *Pool[T, R]) takeIdle() (R, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	var zero R
+	n := len(p.idle)
+	if n == 0 {
+		return zero, false
+	}
+	resource := p.idle[n-1]
+	p.idle[n-1] = zero
+	p.idle = p.idle[:n-1]
+	return resource, true
+}
+
+func (p *Pool[T, R]) destroyResource(resource R) {
+	if p.destroy != nil {
+		p.destroy(resource)
+	}
+}
+
+// Close destroys the idle resources. Resources that are in use are
+// destroyed when they are released rather than returned to the pool.
+// Get can still be used after Close but resources are not kept for
+// reuse.
+func (p *Pool[T, R]) Close() {
+	p.lock.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.lock.Unlock()
+	for _, resource := range idle {
+		p.destroyResource(resource)
+	}
+}
+
+// Resource returns the pooled resource
+func (r *Pooled[T, R]) Resource() R {
+	return r.resource
+}
+
+// Release resets the resource, returns it to the pool, and then releases
+// the slot.
+func (r *Pooled[T, R]) Release() {
+	p := r.pool
+	if p.reset != nil {
+		p.reset(r.resource)
+	}
+	p.lock.Lock()
+	closed := p.closed
+	if !closed {
+		p.idle = append(p.idle, r.resource)
+	}
+	p.lock.Unlock()
+	if closed {
+		p.destroyResource(r.resource)
+	}
+	r.done.Done()
+}
+
+// Discard destroys the resource, instead of returning it to the pool,
+// and then releases the slot. Use it instead of Release for a resource
+// that is known to be broken. A new resource will be created when one
+// is needed.
+func (r *Pooled[T, R]) Discard() {
+	r.pool.destroyResource(r.resource)
+	r.done.Done()
+}
+
+func (r *Pooled[T, R]) privateMethod() {}
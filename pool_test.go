@@ -0,0 +1,151 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/memsql/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+type poolResource struct {
+	id    int32
+	dirty bool
+}
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+
+	const poolSize = 3
+	var created atomic.Int32
+	var inUse atomic.Int32
+	pool := simultaneous.NewPool[any](poolSize,
+		func() (*poolResource, error) {
+			return &poolResource{id: created.Add(1)}, nil
+		},
+		func(r *poolResource) {
+			r.dirty = false
+		})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pooled, err := pool.Get(context.Background())
+			if !assert.NoError(t, err) {
+				return
+			}
+			r := pooled.Resource()
+			assert.False(t, r.dirty, "resource was reset")
+			r.dirty = true
+			assert.LessOrEqual(t, inUse.Add(1), int32(poolSize))
+			time.Sleep(sleep)
+			inUse.Add(-1)
+			pooled.Release()
+		}()
+	}
+	wg.Wait()
+
+	t.Logf("factory called %d times", created.Load())
+	assert.LessOrEqual(t, created.Load(), int32(poolSize), "factory calls")
+	assert.NotZero(t, created.Load())
+}
+
+func TestPoolCancelled(t *testing.T) {
+	t.Parallel()
+
+	pool := simultaneous.NewPool[any](1, func() (int, error) { return 7, nil }, nil)
+	pooled, err := pool.Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 7, pooled.Resource())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = pool.Get(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	pooled.Release()
+	pooled, err = pool.Get(context.Background())
+	require.NoError(t, err)
+	pooled.Release()
+}
+
+func TestPoolFactoryError(t *testing.T) {
+	t.Parallel()
+
+	errFactory := errors.New("no connection for you")
+	fail := true
+	pool := simultaneous.NewPool[any](1, func() (string, error) {
+		if fail {
+			return "", errFactory
+		}
+		return "conn", nil
+	}, nil)
+
+	_, err := pool.Get(context.Background())
+	assert.ErrorIs(t, err, errFactory)
+
+	// the slot was released after the factory error
+	fail = false
+	pooled, err := pool.Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "conn", pooled.Resource())
+	pooled.Release()
+}
+
+func TestPoolHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	var created atomic.Int32
+	var destroyed []int32
+	pool := simultaneous.NewPool[any](2,
+		func() (*poolResource, error) {
+			return &poolResource{id: created.Add(1)}, nil
+		}, nil).
+		WithHealthCheck(func(r *poolResource) error {
+			if r.dirty {
+				return errors.Errorf("resource %d is broken", r.id)
+			}
+			return nil
+		}).
+		WithDestroy(func(r *poolResource) {
+			destroyed = append(destroyed, r.id)
+		})
+
+	a, err := pool.Get(context.Background())
+	require.NoError(t, err)
+	b, err := pool.Get(context.Background())
+	require.NoError(t, err)
+	a.Resource().dirty = true
+	a.Release()
+	b.Release()
+
+	// b is the most recently used and is healthy
+	got, err := pool.Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), got.Resource().id)
+	// a is not healthy so it is destroyed and replaced
+	replacement, err := pool.Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), replacement.Resource().id)
+	assert.Equal(t, []int32{1}, destroyed)
+
+	got.Discard()
+	assert.Equal(t, []int32{1, 2}, destroyed)
+	got, err = pool.Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), got.Resource().id, "discarded resource is not reused")
+	got.Release()
+
+	pool.Close()
+	assert.Equal(t, []int32{1, 2, 4}, destroyed, "idle destroyed by close")
+	replacement.Release()
+	assert.Equal(t, []int32{1, 2, 4, 3}, destroyed, "released after close")
+}
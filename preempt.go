@@ -0,0 +1,53 @@
+package simultaneous
+
+import (
+	"sync/atomic"
+)
+
+// ShouldYield returns true if someone is waiting for space in the Limit
+// at a higher priority than the one this space was obtained at. See
+// AcquirePriority and WithPriorityAging.
+func (t *token[T]) ShouldYield() bool {
+	if atomic.LoadUint32(&t.done) != 0 || !t.held {
+		return false
+	}
+	return t.limit.core.preempting(t.get().prio)
+}
+
+// ShouldYield asks the External, if it has a ShouldYield method
+func (a *adopted[T]) ShouldYield() bool {
+	a.lock.Lock()
+	held := a.held
+	a.lock.Unlock()
+	if !held {
+		return false
+	}
+	if y, ok := a.external.(interface{ ShouldYield() bool }); ok {
+		return y.ShouldYield()
+	}
+	return false
+}
+
+func (l limited[T]) ShouldYield() bool { return false }
+
+// preempting returns true if a waiter has a higher effective priority
+// than prio
+func (c *core) preempting(prio int) bool {
+	if c.waiting.Load() == 0 {
+		return false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := c.now()
+	for e := c.waiters.Front(); e != nil; e = e.Next() {
+		w := e.Value.(*waiter)
+		effective := w.prio
+		if c.aging > 0 {
+			effective += int(now.Sub(w.since) / c.aging)
+		}
+		if effective > prio {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestShouldYield(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	ctx := context.Background()
+	bulk := limit.Forever(ctx)
+	important := limit.ForeverPriority(ctx, 5)
+	assert.False(t, bulk.ShouldYield(), "no one waiting")
+
+	got := make(chan simultaneous.Limited[any])
+	go func() {
+		got <- limit.Forever(ctx)
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	assert.False(t, bulk.ShouldYield(), "waiter at the same priority")
+
+	go func() {
+		got <- limit.ForeverPriority(ctx, 3)
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 2 }, time.Second, time.Millisecond)
+	assert.True(t, bulk.ShouldYield(), "higher priority waiter")
+	assert.False(t, important.ShouldYield(), "waiter is less important")
+
+	bulk.Done()
+	assert.False(t, bulk.ShouldYield(), "after Done")
+	next := <-got
+	assert.Equal(t, 1, limit.Waiting(), "the priority 3 waiter went first")
+	next.Done()
+	(<-got).Done()
+	important.Done()
+}
+
+func TestShouldYieldAging(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	limit := simultaneous.New[any](1, simultaneous.WithClock(clock))
+	limit.WithPriorityAging(time.Minute)
+	ctx := context.Background()
+	done := limit.ForeverPriority(ctx, 1)
+
+	got := make(chan simultaneous.Limited[any])
+	go func() {
+		got <- limit.Forever(ctx)
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Minute)
+	assert.False(t, done.ShouldYield(), "aged to the same priority")
+	clock.Advance(time.Minute)
+	assert.True(t, done.ShouldYield(), "aged past the holder")
+
+	done.Done()
+	(<-got).Done()
+}
+
+func TestShouldYieldTransfer(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	ctx := context.Background()
+	bulk := limit.Forever(ctx)
+
+	got := make(chan simultaneous.Limited[any])
+	go func() {
+		got <- limit.ForeverPriority(ctx, 3)
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	require.True(t, bulk.ShouldYield(), "higher priority waiter")
+
+	ticket := bulk.Transfer()
+	assert.False(t, bulk.ShouldYield(), "after Transfer")
+	claimed := ticket.Claim()
+	assert.True(t, claimed.ShouldYield(), "the claimed space keeps its priority")
+
+	claimed.Done()
+	(<-got).Done()
+}
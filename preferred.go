@@ -0,0 +1,24 @@
+package simultaneous
+
+import (
+	"context"
+)
+
+// Tiers returned by AcquirePreferred
+const (
+	PreferredTier = 0
+	FallbackTier  = 1
+)
+
+// AcquirePreferred makes a non-blocking attempt to get space in the preferred
+// Limit. If that fails, it waits for space in the fallback Limit (or for
+// the context to be cancelled). It returns which tier the space came from:
+// PreferredTier or FallbackTier. Done on the returned Limited releases the
+// space in whichever Limit it was obtained from.
+func AcquirePreferred[T any](ctx context.Context, preferred, fallback *Limit[T]) (int, Limited[T], error) {
+	if done, err := preferred.Timeout(ctx, 0); err == nil {
+		return PreferredTier, done, nil
+	}
+	done, _, err := fallback.Forever2(ctx)
+	return FallbackTier, done, err
+}
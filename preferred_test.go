@@ -0,0 +1,46 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestAcquirePreferred(t *testing.T) {
+	t.Parallel()
+
+	fast := simultaneous.New[any](1)
+	slow := simultaneous.New[any](1)
+	ctx := context.Background()
+
+	tier, first, err := simultaneous.AcquirePreferred(ctx, fast, slow)
+	require.NoError(t, err)
+	assert.Equal(t, simultaneous.PreferredTier, tier)
+
+	tier, second, err := simultaneous.AcquirePreferred(ctx, fast, slow)
+	require.NoError(t, err)
+	assert.Equal(t, simultaneous.FallbackTier, tier, "spilled to the fallback")
+
+	second.Done()
+	done, err := slow.Timeout(ctx, 0)
+	require.NoError(t, err, "released from the fallback")
+	_, err = fast.Timeout(ctx, 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "preferred still held")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	tier, _, err = simultaneous.AcquirePreferred(timeoutCtx, fast, slow)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "both tiers full")
+	assert.Equal(t, simultaneous.FallbackTier, tier)
+
+	done.Done()
+	first.Done()
+	done, err = fast.Timeout(ctx, 0)
+	require.NoError(t, err, "released from the preferred")
+	done.Done()
+}
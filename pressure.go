@@ -0,0 +1,146 @@
+package simultaneous
+
+import (
+	"context"
+	"sync"
+)
+
+// PressureWatermarks say when a Limit is under pressure. See Pressure.
+type PressureWatermarks struct {
+	// HighUtilization starts pressure when this fraction of the
+	// capacity is in use. Zero means utilization does not matter.
+	HighUtilization float64
+	// LowUtilization ends pressure once utilization falls to this
+	// fraction of the capacity
+	LowUtilization float64
+	// HighWaiters starts pressure when this many callers are waiting.
+	// Zero means waiters do not matter.
+	HighWaiters int
+	// LowWaiters ends pressure once the waiters fall to this many
+	LowWaiters int
+}
+
+// PressureEvent is sent by Pressure when a Limit comes under pressure or
+// is relieved of it
+type PressureEvent struct {
+	Pressured bool // true if the Limit is now under pressure
+	InUse     int
+	// Capacity is what can be granted right now: less than the Limit
+	// while warming up (see WithWarmUp) and more during a burst (see
+	// WithBurst)
+	Capacity int
+	Waiting  int
+}
+
+// Pressure returns a channel that receives an event when the Limit comes
+// under pressure, because a high watermark has been reached, and another
+// once it is relieved, because everything that matters is back at or
+// below its low watermark. Producers upstream of the Limit can use it to
+// slow down before callers start timing out.
+//
+//	for event := range limit.Pressure(ctx, simultaneous.PressureWatermarks{
+//		HighUtilization: 0.9,
+//		LowUtilization:  0.5,
+//		HighWaiters:     10,
+//	}) {
+//		producer.Throttle(event.Pressured)
+//	}
+//
+// The channel only holds the latest event: a slow reader misses events
+// in between but never the current state. If the Limit is already under
+// pressure, the first event says so. For a Child, the pressure is that
+// of the Limit it was made from. The channel is closed once the context
+// is cancelled.
+func (l *Limit[T]) Pressure(ctx context.Context, watermarks PressureWatermarks) <-chan PressureEvent {
+	return l.watchPressure(ctx, &pressureWatcher{
+		watermarks: watermarks,
+		events:     make(chan PressureEvent, 1),
+	})
+}
+
+func (l *Limit[T]) watchPressure(ctx context.Context, p *pressureWatcher) <-chan PressureEvent {
+	remove := l.core.pressure.add(p)
+	l.core.notePressure()
+	go func() {
+		<-ctx.Done()
+		remove()
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		p.closed = true
+		close(p.events)
+	}()
+	return p.events
+}
+
+// pressureWatcher is added by Pressure
+type pressureWatcher struct {
+	watermarks PressureWatermarks
+	latch      bool // stay under pressure once there, see WaitForUtilization
+	lock       sync.Mutex
+	pressured  bool
+	closed     bool
+	events     chan PressureEvent
+}
+
+// notePressure must be called, without the lock, whenever space is
+// taken or released or waiters come or go
+func (c *core) notePressure() {
+	watchers := c.pressure.get()
+	if len(watchers) == 0 {
+		return
+	}
+	c.lock.Lock()
+	event := PressureEvent{
+		InUse:    int(c.used.Load() - c.shardFree()),
+		Capacity: int(c.sizeNow()),
+		Waiting:  c.waiters.Len(),
+	}
+	c.lock.Unlock()
+	for _, p := range watchers {
+		p.check(event)
+	}
+}
+
+// check sends an event if the pressure has changed
+func (p *pressureWatcher) check(event PressureEvent) {
+	wm := p.watermarks
+	utilization := float64(event.InUse)
+	if event.Capacity > 0 {
+		utilization /= float64(event.Capacity)
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.closed {
+		return
+	}
+	if p.pressured {
+		if p.latch {
+			return
+		}
+		if wm.HighUtilization > 0 && utilization > wm.LowUtilization {
+			return
+		}
+		if wm.HighWaiters > 0 && event.Waiting > wm.LowWaiters {
+			return
+		}
+		p.pressured = false
+	} else {
+		if !(wm.HighUtilization > 0 && utilization >= wm.HighUtilization) &&
+			!(wm.HighWaiters > 0 && event.Waiting >= wm.HighWaiters) {
+			return
+		}
+		p.pressured = true
+	}
+	event.Pressured = p.pressured
+	// only the latest event is kept; this is the only sender so once
+	// the old one is gone there is room
+	select {
+	case p.events <- event:
+	default:
+		select {
+		case <-p.events:
+		default:
+		}
+		p.events <- event
+	}
+}
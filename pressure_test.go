@@ -0,0 +1,128 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestPressure(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := limit.Pressure(ctx, simultaneous.PressureWatermarks{
+		HighUtilization: 0.75,
+		LowUtilization:  0.25,
+	})
+	noEvent := func(msg string) {
+		select {
+		case event := <-events:
+			assert.Failf(t, "unexpected event", "%s: %+v", msg, event)
+		default:
+		}
+	}
+
+	noEvent("idle")
+	var held []simultaneous.Limited[any]
+	for i := 0; i < 3; i++ {
+		held = append(held, limit.Forever(context.Background()))
+	}
+	event := <-events
+	assert.Equal(t, simultaneous.PressureEvent{Pressured: true, InUse: 3, Capacity: 4}, event)
+
+	release(held[2:])
+	noEvent("between the watermarks")
+	release(held[1:2])
+	event = <-events
+	assert.Equal(t, simultaneous.PressureEvent{Pressured: false, InUse: 1, Capacity: 4}, event)
+	release(held[:1])
+	noEvent("already relieved")
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestPressureWaiters(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	a := limit.Forever(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := limit.Pressure(ctx, simultaneous.PressureWatermarks{HighWaiters: 2})
+
+	got := make(chan simultaneous.Limited[any], 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			got <- limit.Forever(context.Background())
+		}()
+	}
+	event := <-events
+	assert.True(t, event.Pressured)
+	assert.Equal(t, 2, event.Waiting)
+
+	a.Done()
+	(<-got).Done()
+	event = <-events
+	assert.False(t, event.Pressured, "no more waiters")
+	(<-got).Done()
+}
+
+func TestPressureAlready(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	a := limit.Forever(context.Background())
+	defer a.Done()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	event, ok := <-limit.Pressure(ctx, simultaneous.PressureWatermarks{HighUtilization: 1})
+	require.True(t, ok)
+	assert.True(t, event.Pressured, "already under pressure")
+}
+
+func TestPressureWarmUp(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	limit := simultaneous.New[any](10,
+		simultaneous.WithClock(clock),
+		simultaneous.WithWarmUp(2, 8*time.Second, simultaneous.RampLinear))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := limit.Pressure(ctx, simultaneous.PressureWatermarks{HighUtilization: 1})
+
+	held := fill(limit)
+	defer release(held)
+	require.Len(t, held, 2)
+	event := <-events
+	assert.Equal(t, simultaneous.PressureEvent{Pressured: true, InUse: 2, Capacity: 2}, event,
+		"full at the warm-up capacity")
+}
+
+func TestPressureShards(t *testing.T) {
+	t.Parallel()
+
+	// a shard borrows a quarter of the capacity at a time
+	limit := simultaneous.New[any](100, simultaneous.WithShards(1))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := limit.Pressure(ctx, simultaneous.PressureWatermarks{HighUtilization: 0.2, LowUtilization: 0.1})
+
+	done := limit.Forever(context.Background())
+	defer done.Done()
+	select {
+	case event := <-events:
+		assert.Failf(t, "space borrowed by a shard counted as in use", "%+v", event)
+	default:
+	}
+	assert.Equal(t, 1, limit.InUse())
+}
@@ -0,0 +1,42 @@
+package simultaneous
+
+import (
+	"context"
+	"time"
+)
+
+// ForeverPriority is like Forever except that the wait is at priority prio.
+// When space becomes available, it is granted to the waiter with the highest
+// priority. Waiters with the same priority are granted space in the order
+// they arrived. Forever and the other acquisition methods wait at priority
+// zero. Priorities may be negative.
+//
+// Priority only matters while waiting: if there is space, it is taken
+// right away regardless of priority. Yield waits at the same priority
+// that was used to obtain the space. Holders at a lower priority can
+// learn from ShouldYield that a waiter would like the space.
+func (l *Limit[T]) ForeverPriority(ctx context.Context, prio int) Limited[T] {
+	done, _, _ := l.forever(ctx, l.stuckTimeout, 1, prio, "")
+	return done
+}
+
+// AcquirePriority is like Acquire except that the wait is at priority
+// prio. See ForeverPriority.
+func (l *Limit[T]) AcquirePriority(ctx context.Context, prio int) (Limited[T], error) {
+	done, _, err := l.acquire(ctx, 1, prio, "")
+	return done, err
+}
+
+// WithPriorityAging raises the priority of waiters by one for every interval
+// that they have been waiting so that low priority waiters are not starved
+// forever by a steady supply of higher priority ones. An interval of zero
+// turns aging off.
+//
+// WithPriorityAging changes the Limit and all of its copies. It returns the
+// Limit so that it can be chained with New.
+func (l *Limit[T]) WithPriorityAging(interval time.Duration) *Limit[T] {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	l.core.aging = interval
+	return l
+}
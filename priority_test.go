@@ -0,0 +1,94 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+// queueWaiters starts one waiter for each priority, in order, and
+// returns a function that waits for all of them and returns the
+// priorities in the order they were granted.
+func queueWaiters(t *testing.T, limit *simultaneous.Limit[any], priorities ...int) func() []int {
+	var lock sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	base := limit.Waiting()
+	for i, prio := range priorities {
+		prio := prio
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := limit.AcquirePriority(context.Background(), prio)
+			if !assert.NoError(t, err) {
+				return
+			}
+			lock.Lock()
+			order = append(order, prio)
+			lock.Unlock()
+			done.Done()
+		}()
+		want := base + i + 1
+		require.Eventually(t, func() bool { return limit.Waiting() == want }, time.Second, time.Millisecond)
+	}
+	return func() []int {
+		wg.Wait()
+		return order
+	}
+}
+
+func TestPriority(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	held := limit.Forever(context.Background())
+	wait := queueWaiters(t, limit, 0, 5, -1, 5, 10, 0)
+	held.Done()
+	assert.Equal(t, []int{10, 5, 5, 0, 0, -1}, wait())
+}
+
+func TestPriorityAging(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1).WithPriorityAging(20 * time.Millisecond)
+	held := limit.Forever(context.Background())
+	wait := queueWaiters(t, limit, 0)
+	time.Sleep(70 * time.Millisecond)
+	wait2 := queueWaiters(t, limit, 2)
+	held.Done()
+	assert.Equal(t, []int{0}, wait())
+	assert.Equal(t, []int{2}, wait2())
+}
+
+func TestPriorityYield(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	done := limit.ForeverPriority(context.Background(), 3)
+
+	granted := make(chan simultaneous.Limited[any])
+	go func() {
+		granted <- limit.ForeverPriority(context.Background(), 1)
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+
+	yielded := make(chan error)
+	go func() {
+		yielded <- done.Yield(context.Background())
+	}()
+	other := <-granted
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	wait := queueWaiters(t, limit, 2)
+
+	other.Done()
+	require.NoError(t, <-yielded)
+	assert.Equal(t, 1, limit.Waiting(), "Yield kept priority 3")
+	done.Done()
+	assert.Equal(t, []int{2}, wait())
+}
@@ -0,0 +1,119 @@
+package simultaneous
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Waiter is a request for space that can report where it is in the
+// queue. It is returned by AcquireWaiter.
+type Waiter[T any] struct {
+	limit *Limit[T]
+	c     <-chan Limited[T]
+	lock  sync.Mutex
+	w     *waiter // nil until queued
+}
+
+// waiterHookKey carries a function that is told about the waiter when
+// forever has to wait
+type waiterHookKey struct{}
+
+// AcquireWaiter is like AcquireChan except that, while it waits, the
+// returned Waiter can say how far back in the queue it is and estimate
+// how long it will be, for example to tell an end user "your job is #7
+// in line, about 2m". Receive the space from C. The same rules as for
+// AcquireChan apply: the context must be cancelled if C will not be
+// received from.
+func (l *Limit[T]) AcquireWaiter(ctx context.Context) *Waiter[T] {
+	wr := &Waiter[T]{limit: l}
+	wr.c = l.AcquireChan(context.WithValue(ctx, waiterHookKey{}, func(w *waiter) {
+		wr.lock.Lock()
+		defer wr.lock.Unlock()
+		wr.w = w
+	}))
+	return wr
+}
+
+// C returns the channel that the space is delivered on, as from
+// AcquireChan
+func (wr *Waiter[T]) C() <-chan Limited[T] {
+	return wr.c
+}
+
+// Position returns where the Waiter is in the queue: 1 if no one is
+// ahead of it. It returns 0 if it is not waiting, because it has not
+// started to wait yet, did not need to, or has stopped. The queue is in
+// order of arrival; priorities, WithLIFO, and WithFairShare can let
+// waiters that arrived later go first.
+func (wr *Waiter[T]) Position() int {
+	wr.lock.Lock()
+	w := wr.w
+	wr.lock.Unlock()
+	if w == nil {
+		return 0
+	}
+	c := wr.limit.core
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.position(w)
+}
+
+// ETA estimates how much longer the Waiter will wait, from its position
+// and how often space has recently been granted to waiters. It returns
+// false if there is no estimate because the Waiter is not waiting or
+// nothing has been granted yet to base the estimate on.
+func (wr *Waiter[T]) ETA() (time.Duration, bool) {
+	wr.lock.Lock()
+	w := wr.w
+	wr.lock.Unlock()
+	if w == nil {
+		return 0, false
+	}
+	c := wr.limit.core
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	position := c.position(w)
+	if position == 0 || c.lastGrant.IsZero() {
+		return 0, false
+	}
+	return time.Duration(position) * c.grantEvery, true
+}
+
+// noteWaiter tells AcquireWaiter about a waiter
+func noteWaiter(ctx context.Context, w *waiter) {
+	if hook, ok := ctx.Value(waiterHookKey{}).(func(*waiter)); ok {
+		hook(w)
+	}
+}
+
+// position returns where the waiter is in the queue, or 0 if it is not
+// in it. Must be called with the lock held.
+func (c *core) position(w *waiter) int {
+	var position int
+	for e := c.waiters.Front(); e != nil; e = e.Next() {
+		position++
+		if e.Value.(*waiter) == w {
+			return position
+		}
+	}
+	return 0
+}
+
+// noteGrant keeps grantEvery, a moving average of the time between
+// grants. Time when no one was waiting does not count. Must be called
+// with the lock held.
+func (c *core) noteGrant() {
+	now := c.now()
+	from := c.lastGrant
+	if c.queuedSince.After(from) {
+		from = c.queuedSince
+	}
+	interval := now.Sub(from)
+	if c.lastGrant.IsZero() {
+		c.grantEvery = interval
+	} else {
+		c.grantEvery = (3*c.grantEvery + interval) / 4
+	}
+	c.lastGrant = now
+}
@@ -0,0 +1,60 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestWaiterPosition(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	limit := simultaneous.New[any](1, simultaneous.WithClock(clock))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	held := limit.Forever(ctx)
+	a := limit.AcquireWaiter(ctx)
+	require.Eventually(t, func() bool { return a.Position() == 1 }, time.Second, time.Millisecond)
+	b := limit.AcquireWaiter(ctx)
+	require.Eventually(t, func() bool { return b.Position() == 2 }, time.Second, time.Millisecond)
+	_, ok := b.ETA()
+	assert.False(t, ok, "nothing granted yet")
+
+	clock.Advance(10 * time.Second)
+	held.Done()
+	done := <-a.C()
+	assert.Zero(t, a.Position(), "no longer waiting")
+	assert.Equal(t, 1, b.Position())
+	eta, ok := b.ETA()
+	require.True(t, ok)
+	assert.Equal(t, 10*time.Second, eta)
+
+	c := limit.AcquireWaiter(ctx)
+	require.Eventually(t, func() bool { return c.Position() == 2 }, time.Second, time.Millisecond)
+	eta, ok = c.ETA()
+	require.True(t, ok)
+	assert.Equal(t, 20*time.Second, eta)
+
+	done.Done()
+	(<-b.C()).Done()
+	(<-c.C()).Done()
+}
+
+func TestWaiterNoWait(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	w := limit.AcquireWaiter(context.Background())
+	done := <-w.C()
+	defer done.Done()
+	assert.Zero(t, w.Position())
+	_, ok := w.ETA()
+	assert.False(t, ok)
+}
@@ -0,0 +1,100 @@
+package simultaneous
+
+import (
+	"context"
+	"sync"
+
+	"github.com/memsql/errors"
+)
+
+// ErrReentrant is returned, or reported to the callback given to
+// WithReentrancyDetection, when a goroutine tries to acquire a Limit that
+// it already holds
+var ErrReentrant errors.String = "goroutine already holds the simultaneous limit"
+
+// reentrancy tracks, for limits that have reentrancy detection enabled,
+// how many acquisitions of each limit are held by each goroutine
+var reentrancy = struct {
+	sync.Mutex
+	held map[heldBy]int
+}{
+	held: make(map[heldBy]int),
+}
+
+type heldBy struct {
+	core *core
+	gid  uint64
+}
+
+// reentrancyDetection is configured by WithReentrancyDetection
+type reentrancyDetection struct {
+	callback func(context.Context, error)
+}
+
+// WithReentrancyDetection returns a modified Limit that notices when a
+// goroutine that already holds space in the Limit tries to acquire it
+// again. With a capacity of one, that goroutine would wait forever for
+// itself. If callback is not nil, it is called with an error that
+// matches ErrReentrant and the acquisition proceeds. If callback is nil,
+// the acquisition fails with that error instead: Forever returns a
+// Limited that does not hold space, and the methods that return an error
+// return it. TryAcquireN is not checked since it never waits.
+//
+// Space that is transferred belongs to the goroutine that claims it.
+// Like deadlock detection, reentrancy detection finds the current
+// goroutine by parsing a stack trace and is intended for development
+// and testing.
+func (l Limit[T]) WithReentrancyDetection(callback func(context.Context, error)) *Limit[T] {
+	l.reentrancy = &reentrancyDetection{
+		callback: callback,
+	}
+	return &l
+}
+
+// WithReentrancyDetection notices goroutines that acquire a Limit that
+// they already hold. See the WithReentrancyDetection method.
+func WithReentrancyDetection(callback func(context.Context, error)) Option {
+	return func(s *state) {
+		s.reentrancy = &reentrancyDetection{
+			callback: callback,
+		}
+	}
+}
+
+// checkReentrant is called before acquiring the limit. It returns an
+// error if the acquisition must fail.
+func (l *Limit[T]) checkReentrant(ctx context.Context) error {
+	if l.reentrancy == nil {
+		return nil
+	}
+	reentrancy.Lock()
+	held := reentrancy.held[heldBy{core: l.core, gid: goroutineID()}]
+	reentrancy.Unlock()
+	if held == 0 {
+		return nil
+	}
+	err := ErrReentrant.Errorf("goroutine already holds %d of simultaneous limit %q (of %d) and is acquiring it again", held, l.name, l.capacity())
+	if l.reentrancy.callback != nil {
+		callObserver(func() { l.reentrancy.callback(ctx, err) })
+		return nil
+	}
+	return err
+}
+
+// trackOwner records that the current goroutine holds the limit. The
+// returned function undoes that.
+func (l *Limit[T]) trackOwner() func() {
+	key := heldBy{core: l.core, gid: goroutineID()}
+	reentrancy.Lock()
+	reentrancy.held[key]++
+	reentrancy.Unlock()
+	return func() {
+		reentrancy.Lock()
+		defer reentrancy.Unlock()
+		if reentrancy.held[key] <= 1 {
+			delete(reentrancy.held, key)
+		} else {
+			reentrancy.held[key]--
+		}
+	}
+}
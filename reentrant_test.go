@@ -0,0 +1,69 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestReentrancyError(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1, simultaneous.WithReentrancyDetection(nil))
+	ctx := context.Background()
+	held, err := limit.Acquire(ctx)
+	require.NoError(t, err)
+
+	_, err = limit.Acquire(ctx)
+	assert.ErrorIs(t, err, simultaneous.ErrReentrant)
+	_, err = limit.Timeout(ctx, time.Hour)
+	assert.ErrorIs(t, err, simultaneous.ErrReentrant)
+	assert.Equal(t, 1, limit.InUse())
+
+	// another goroutine just waits
+	got := make(chan error)
+	go func() {
+		done, err := limit.Acquire(ctx)
+		if err == nil {
+			done.Done()
+		}
+		got <- err
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	held.Done()
+	require.NoError(t, <-got)
+
+	held, err = limit.Acquire(ctx)
+	require.NoError(t, err, "no longer held")
+	held.Done()
+}
+
+func TestReentrancyCallback(t *testing.T) {
+	t.Parallel()
+
+	var reported []error
+	limit := simultaneous.New[any](2).WithReentrancyDetection(func(_ context.Context, err error) {
+		reported = append(reported, err)
+	})
+	ctx := context.Background()
+	first := limit.Forever(ctx)
+	second := limit.Forever(ctx)
+	require.Len(t, reported, 1)
+	assert.ErrorIs(t, reported[0], simultaneous.ErrReentrant)
+	assert.Equal(t, 2, limit.InUse(), "acquisition proceeds")
+
+	// transferred space belongs to the goroutine that claims it
+	ticket := first.Transfer()
+	second.Done()
+	claimed := make(chan simultaneous.Limited[any])
+	go func() { claimed <- ticket.Claim() }()
+	done := <-claimed
+	limit.Forever(ctx).Done()
+	assert.Len(t, reported, 1)
+	done.Done()
+}
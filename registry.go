@@ -0,0 +1,95 @@
+package simultaneous
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/memsql/errors"
+)
+
+// ErrNameInUse is returned by Register when the registry already has a
+// Limit with the same name
+var ErrNameInUse errors.String = "simultaneous limit name already registered"
+
+// ErrNoName is returned by Register for a Limit created without WithName
+var ErrNoName errors.String = "simultaneous limit has no name"
+
+// Named is implemented by *Limit[T] for any T. It is what a Registry
+// holds since Limits of different types can be registered together.
+type Named interface {
+	Name() string
+	Stats() Stats
+	Limit() int
+	SetLimit(int)
+	Holders() []HolderInfo
+}
+
+var _ Named = &Limit[any]{}
+
+// Registry holds named Limits so that they can be found and inspected
+// from one place
+type Registry struct {
+	lock   sync.Mutex
+	limits map[string]Named
+}
+
+// DefaultRegistry is a process-wide Registry
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		limits: make(map[string]Named),
+	}
+}
+
+// Register adds a Limit to the Registry under its name, as given with
+// WithName
+func (r *Registry) Register(l Named) error {
+	name := l.Name()
+	if name == "" {
+		return ErrNoName.Errorf("cannot register a simultaneous limit without a name")
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.limits[name]; ok {
+		return ErrNameInUse.Errorf("simultaneous limit %q is already registered", name)
+	}
+	r.limits[name] = l
+	return nil
+}
+
+// Unregister removes a Limit from the Registry
+func (r *Registry) Unregister(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.limits, name)
+}
+
+// Get returns the Limit registered under name, or nil if there is none
+func (r *Registry) Get(name string) Named {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.limits[name]
+}
+
+// All returns all the registered Limits, sorted by name
+func (r *Registry) All() []Named {
+	r.lock.Lock()
+	all := make([]Named, 0, len(r.limits))
+	for _, l := range r.limits {
+		all = append(all, l)
+	}
+	r.lock.Unlock()
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name() < all[j].Name()
+	})
+	return all
+}
+
+// Lookup returns the Limit registered under name if there is one and it
+// has type T
+func Lookup[T any](r *Registry, name string) (*Limit[T], bool) {
+	l, ok := r.Get(name).(*Limit[T])
+	return l, ok
+}
@@ -0,0 +1,41 @@
+package simultaneous_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	r := simultaneous.NewRegistry()
+	alter := simultaneous.New[string](2, simultaneous.WithName("alter-table"))
+	backup := simultaneous.New[int](3, simultaneous.WithName("backup"))
+	require.NoError(t, r.Register(backup))
+	require.NoError(t, r.Register(alter))
+
+	assert.ErrorIs(t, r.Register(simultaneous.New[string](1, simultaneous.WithName("alter-table"))), simultaneous.ErrNameInUse)
+	assert.ErrorIs(t, r.Register(simultaneous.New[string](1)), simultaneous.ErrNoName)
+
+	assert.Same(t, alter, r.Get("alter-table"))
+	assert.Nil(t, r.Get("missing"))
+
+	all := r.All()
+	require.Len(t, all, 2)
+	assert.Equal(t, "alter-table", all[0].Name())
+	assert.Equal(t, 3, all[1].Stats().Capacity)
+
+	l, ok := simultaneous.Lookup[string](r, "alter-table")
+	assert.True(t, ok)
+	assert.Same(t, alter, l)
+	_, ok = simultaneous.Lookup[int](r, "alter-table")
+	assert.False(t, ok, "wrong type")
+
+	r.Unregister("alter-table")
+	assert.Nil(t, r.Get("alter-table"))
+	assert.Len(t, r.All(), 1)
+}
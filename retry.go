@@ -0,0 +1,112 @@
+package simultaneous
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/memsql/errors"
+)
+
+// ErrRetriesExhausted is returned by AcquireRetry when the Backoff gives
+// up
+var ErrRetriesExhausted errors.String = "gave up retrying to get permission to run"
+
+// Backoff decides how long AcquireRetry waits between attempts
+type Backoff interface {
+	// Next is called after attempt (starting with 1) has failed. It
+	// returns how long to wait before the next attempt or false to give
+	// up.
+	Next(attempt int) (time.Duration, bool)
+}
+
+// BackoffFunc is a Backoff that is a function
+type BackoffFunc func(attempt int) (time.Duration, bool)
+
+func (f BackoffFunc) Next(attempt int) (time.Duration, bool) { return f(attempt) }
+
+// ExponentialBackoff is a Backoff whose delays grow by a constant factor
+// up to a maximum, with random jitter so that callers that fail together
+// do not all retry together
+type ExponentialBackoff struct {
+	Initial     time.Duration // first delay; zero means one millisecond
+	Max         time.Duration // longest delay; zero means one second
+	Multiplier  float64       // growth of each delay; zero means 2
+	Jitter      float64       // fraction of each delay that is random, from 0 (none) to 1
+	MaxAttempts int           // zero means no limit
+}
+
+var _ Backoff = ExponentialBackoff{}
+
+// DefaultBackoff is used by AcquireRetry when the Backoff is nil
+var DefaultBackoff Backoff = ExponentialBackoff{Jitter: 0.5}
+
+func (b ExponentialBackoff) Next(attempt int) (time.Duration, bool) {
+	if b.MaxAttempts > 0 && attempt >= b.MaxAttempts {
+		return 0, false
+	}
+	initial := b.Initial
+	if initial == 0 {
+		initial = time.Millisecond
+	}
+	max := b.Max
+	if max == 0 {
+		max = time.Second
+	}
+	multiplier := b.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+	delay := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if delay > float64(max) {
+		delay = float64(max)
+	}
+	if b.Jitter > 0 {
+		delay -= delay * b.Jitter * rand.Float64()
+	}
+	return time.Duration(delay), true
+}
+
+// AcquireRetry repeatedly tries to get space, like Try, waiting between
+// attempts as directed by the Backoff (DefaultBackoff if nil). Unlike
+// Acquire, the caller does not wait in line: space released between
+// attempts may go to others. This suits loops that would rather poll
+// cheaply than block. If the Backoff gives up, an error matching
+// ErrRetriesExhausted is returned. If the context is cancelled first, an
+// error wrapping ctx.Err() is returned. Either way, the Done method is a
+// no-op.
+//
+// Failed attempts are not counted as timeouts in Stats; giving up is.
+func (l *Limit[T]) AcquireRetry(ctx context.Context, backoff Backoff) (Limited[T], error) {
+	if backoff == nil {
+		backoff = DefaultBackoff
+	}
+	var start time.Time // the clock is only read if the first attempt fails
+	l.record(EventAcquireStart)
+	for attempt := 1; ; attempt++ {
+		if l.core.isClosed() {
+			return l.cancelled(ctx, start), l.closedError()
+		}
+		if l.core.tryAcquire(1, l.sub) {
+			return l.acquired(ctx, 1, 0, "", start), nil
+		}
+		if start.IsZero() {
+			start = l.now()
+		}
+		delay, ok := backoff.Next(attempt)
+		if !ok {
+			l.record(EventTimeout)
+			l.core.count(l.sub, outcomeTimeout, 0)
+			l.gaveUp(ctx, start)
+			return limited[T](nil), ErrRetriesExhausted.Errorf("gave up after %d attempts to get space in simultaneous limit (of %d)", attempt, l.capacity())
+		}
+		timer := l.newTimer(delay)
+		select {
+		case <-timer.Chan():
+		case <-ctx.Done():
+			timer.Stop()
+			return l.cancelledTimeout(ctx, start)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestAcquireRetry(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	ctx := context.Background()
+	held := limit.Forever(ctx)
+
+	var attempts []int
+	backoff := simultaneous.BackoffFunc(func(attempt int) (time.Duration, bool) {
+		attempts = append(attempts, attempt)
+		if attempt == 3 {
+			held.Done()
+		}
+		return time.Millisecond, true
+	})
+	done, err := limit.AcquireRetry(ctx, backoff)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+	assert.Equal(t, 0, limit.Waiting(), "never waited in line")
+
+	_, err = limit.AcquireRetry(ctx, simultaneous.ExponentialBackoff{MaxAttempts: 3})
+	assert.ErrorIs(t, err, simultaneous.ErrRetriesExhausted)
+	stats := limit.Stats()
+	assert.Equal(t, uint64(1), stats.Timeouts)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = limit.AcquireRetry(ctx, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	done.Done()
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	t.Parallel()
+
+	backoff := simultaneous.ExponentialBackoff{
+		Initial:     10 * time.Millisecond,
+		Max:         50 * time.Millisecond,
+		MaxAttempts: 5,
+	}
+	var delays []time.Duration
+	for attempt := 1; ; attempt++ {
+		delay, ok := backoff.Next(attempt)
+		if !ok {
+			break
+		}
+		delays = append(delays, delay)
+	}
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond}, delays)
+
+	backoff.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay, _ := backoff.Next(2)
+		assert.GreaterOrEqual(t, delay, 10*time.Millisecond)
+		assert.LessOrEqual(t, delay, 20*time.Millisecond)
+	}
+}
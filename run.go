@@ -0,0 +1,53 @@
+package simultaneous
+
+import (
+	"context"
+
+	"github.com/memsql/errors"
+)
+
+// Do waits for space in the limit (or for the context to be cancelled)
+// and then runs fn. The space is released when fn returns, even if fn
+// panics. If the context is cancelled first, fn is not run and an error
+// wrapping ctx.Err() is returned.
+func (l *Limit[T]) Do(ctx context.Context, fn func(Enforced[T]) error) error {
+	done, err := l.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer done.Done()
+	return fn(done)
+}
+
+// RunRecover waits for space in the limit (or for the context to be
+// cancelled) and then runs fn. The space is released when fn returns,
+// even if fn panics. A panic in fn is converted into an error that
+// includes the recovered value and the stack trace. Use WithPanicToError
+// to re-panic instead.
+func RunRecover[T any, R any](ctx context.Context, l *Limit[T], fn func(Enforced[T]) (R, error)) (result R, err error) {
+	done, _, err := l.Forever2(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		r := recover()
+		done.Done()
+		if r == nil {
+			return
+		}
+		if l.repanic {
+			panic(r)
+		}
+		err = errors.Wrap(errors.FromPanic(r), "panic while running with simultaneous limit")
+	}()
+	return fn(done)
+}
+
+// WithPanicToError returns a modified Limit that controls what RunRecover
+// does when its function panics. By default, the panic is converted to an
+// error. If convert is false, the panic continues after the space in the
+// limit has been released.
+func (l Limit[T]) WithPanicToError(convert bool) *Limit[T] {
+	l.repanic = !convert
+	return &l
+}
@@ -0,0 +1,99 @@
+package simultaneous_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestRunRecover(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	result, err := simultaneous.RunRecover(context.Background(), limit, func(simultaneous.Enforced[any]) (int, error) {
+		return 3, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result)
+
+	_, err = simultaneous.RunRecover(context.Background(), limit, func(simultaneous.Enforced[any]) (int, error) {
+		panic("oops")
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "oops")
+		assert.Contains(t, err.Error(), "panic")
+		assert.Contains(t, fmt.Sprintf("%+v", err), "TestRunRecover", "stack captured")
+	}
+
+	done, err := limit.Timeout(context.Background(), 0)
+	require.NoError(t, err, "space released after panic")
+	done.Done()
+}
+
+func TestRunRecoverRepanic(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1).WithPanicToError(false)
+	assert.PanicsWithValue(t, "oops", func() {
+		_, _ = simultaneous.RunRecover(context.Background(), limit, func(simultaneous.Enforced[any]) (int, error) {
+			panic("oops")
+		})
+	})
+
+	done, err := limit.Timeout(context.Background(), 0)
+	require.NoError(t, err, "space released after panic")
+	done.Done()
+}
+
+func TestRunRecoverCancelled(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	defer limit.Forever(context.Background()).Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	_, err := simultaneous.RunRecover(ctx, limit, func(simultaneous.Enforced[any]) (int, error) {
+		called = true
+		return 0, nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, called)
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	errFn := fmt.Errorf("fn failed")
+	assert.NoError(t, limit.Do(context.Background(), func(simultaneous.Enforced[any]) error {
+		assert.Equal(t, 1, limit.InUse())
+		return nil
+	}))
+	assert.Equal(t, 0, limit.InUse())
+	assert.ErrorIs(t, limit.Do(context.Background(), func(simultaneous.Enforced[any]) error {
+		return errFn
+	}), errFn)
+
+	assert.PanicsWithValue(t, "oops", func() {
+		_ = limit.Do(context.Background(), func(simultaneous.Enforced[any]) error {
+			panic("oops")
+		})
+	})
+	assert.Equal(t, 0, limit.InUse(), "released after panic")
+
+	defer limit.Forever(context.Background()).Done()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limit.Do(ctx, func(simultaneous.Enforced[any]) error {
+		t.Error("should not run")
+		return nil
+	}), context.DeadlineExceeded)
+}
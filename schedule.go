@@ -0,0 +1,99 @@
+package simultaneous
+
+import (
+	"context"
+	"time"
+)
+
+// Schedule is a capacity that depends on the time of day, for example
+// more capacity at night when a downstream is otherwise idle
+//
+//	// 20 from midnight to 6am UTC, 5 otherwise
+//	schedule := simultaneous.Schedule{
+//		Default: 5,
+//		Windows: []simultaneous.ScheduleWindow{
+//			{Start: 0, End: 6 * time.Hour, Capacity: 20},
+//		},
+//	}
+type Schedule struct {
+	Default  int              // capacity outside of the windows
+	Windows  []ScheduleWindow // the first window that contains the time wins
+	Location *time.Location   // time zone of the windows; nil means UTC
+}
+
+// ScheduleWindow is a part of each day, measured from midnight. If End is
+// before Start, the window wraps around midnight.
+type ScheduleWindow struct {
+	Start    time.Duration
+	End      time.Duration
+	Capacity int
+}
+
+// CapacityAt returns the capacity that the Schedule gives at t
+func (s Schedule) CapacityAt(t time.Time) int {
+	offset := s.offset(t)
+	for _, w := range s.Windows {
+		if w.contains(offset) {
+			return w.Capacity
+		}
+	}
+	return s.Default
+}
+
+// offset returns the time since midnight of t
+func (s Schedule) offset(t time.Time) time.Duration {
+	loc := s.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return t.Sub(midnight)
+}
+
+func (w ScheduleWindow) contains(offset time.Duration) bool {
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// next returns how long after t the capacity might next change
+func (s Schedule) next(t time.Time) time.Duration {
+	offset := s.offset(t)
+	const day = 24 * time.Hour
+	soonest := day - offset // midnight
+	for _, w := range s.Windows {
+		for _, edge := range []time.Duration{w.Start, w.End} {
+			d := edge - offset
+			if d <= 0 {
+				d += day
+			}
+			if d < soonest {
+				soonest = d
+			}
+		}
+	}
+	return soonest
+}
+
+// FollowSchedule sets the capacity of the Limit from the Schedule now and
+// whenever the Schedule changes it, until the context is cancelled. Time
+// comes from the clock given by WithClock. Like SetLimit, lowering the
+// capacity does not take space from holders: the new capacity is
+// enforced as they release it.
+func (l *Limit[T]) FollowSchedule(ctx context.Context, schedule Schedule) {
+	l.SetLimit(schedule.CapacityAt(l.now()))
+	go func() {
+		for {
+			timer := l.newTimer(schedule.next(l.now()))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.Chan():
+			}
+			l.SetLimit(schedule.CapacityAt(l.now()))
+		}
+	}()
+}
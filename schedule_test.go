@@ -0,0 +1,65 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestScheduleCapacityAt(t *testing.T) {
+	t.Parallel()
+
+	schedule := simultaneous.Schedule{
+		Default: 5,
+		Windows: []simultaneous.ScheduleWindow{
+			{Start: 0, End: 6 * time.Hour, Capacity: 20},
+			{Start: 22 * time.Hour, End: time.Hour, Capacity: 10},
+		},
+	}
+	at := func(hour, minute int) time.Time {
+		return time.Date(2020, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+	assert.Equal(t, 20, schedule.CapacityAt(at(0, 30)), "first window wins")
+	assert.Equal(t, 20, schedule.CapacityAt(at(5, 59)))
+	assert.Equal(t, 5, schedule.CapacityAt(at(6, 0)))
+	assert.Equal(t, 10, schedule.CapacityAt(at(23, 0)), "wraps around midnight")
+
+	schedule.Location = time.FixedZone("UTC+2", 2*60*60)
+	assert.Equal(t, 20, schedule.CapacityAt(at(3, 0)), "05:00 in UTC+2")
+	assert.Equal(t, 5, schedule.CapacityAt(at(4, 0)))
+}
+
+func TestFollowSchedule(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock() // starts at midnight
+	limit := simultaneous.New[any](1, simultaneous.WithClock(clock))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	limit.FollowSchedule(ctx, simultaneous.Schedule{
+		Default: 2,
+		Windows: []simultaneous.ScheduleWindow{
+			{Start: 0, End: 6 * time.Hour, Capacity: 4},
+		},
+	})
+	assert.Equal(t, 4, limit.Limit())
+
+	held := fill(limit)
+	require.Len(t, held, 4)
+	require.Eventually(t, func() bool { return clock.pending() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(6 * time.Hour)
+	require.Eventually(t, func() bool { return limit.Limit() == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, 4, limit.InUse(), "holders keep their space")
+	release(held[:2])
+	assert.Empty(t, fill(limit), "enforced as space is released")
+	release(held[2:])
+
+	require.Eventually(t, func() bool { return clock.pending() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(18 * time.Hour)
+	require.Eventually(t, func() bool { return limit.Limit() == 4 }, time.Second, time.Millisecond)
+}
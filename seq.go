@@ -0,0 +1,47 @@
+//go:build go1.23
+
+package simultaneous
+
+import (
+	"context"
+	"iter"
+)
+
+// MapSeq is like MapChan for inputs from an iterator. It returns an
+// iterator of the outputs and the errors from producing them. Stopping
+// the iteration early cancels the context given to fn and stops reading
+// inputs. If ctx is cancelled before all of the inputs are processed,
+// the last pair has an error wrapping ctx.Err(). MapSeq requires go1.23.
+//
+//	for size, err := range simultaneous.MapSeq(ctx, limit, paths, fileSize) {
+//		if err != nil {
+//			return err
+//		}
+//		total += size
+//	}
+func MapSeq[T any, In any, Out any](ctx context.Context, limit *Limit[T], inputs iter.Seq[In], fn func(context.Context, Enforced[T], In) (Out, error), opts ...FanOutOption) iter.Seq2[Out, error] {
+	return func(yield func(Out, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		in := make(chan In)
+		go func() {
+			defer close(in)
+			for input := range inputs {
+				select {
+				case in <- input:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		for r := range MapChan(ctx, limit, in, fn, opts...) {
+			if !yield(r.Value, r.Err) {
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			var zero Out
+			yield(zero, limit.cancelledError(ctx))
+		}
+	}
+}
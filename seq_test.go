@@ -0,0 +1,66 @@
+//go:build go1.23
+
+package simultaneous_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestMapSeq(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	var outputs []int
+	for out, err := range simultaneous.MapSeq(context.Background(), limit, slices.Values([]int{5, 1, 3}), sleepAndDouble) {
+		require.NoError(t, err)
+		outputs = append(outputs, out)
+	}
+	assert.Equal(t, []int{10, 2, 6}, outputs)
+}
+
+func TestMapSeqEarlyStop(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	naturals := func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	var outputs []int
+	for out, err := range simultaneous.MapSeq(context.Background(), limit, naturals, sleepAndDouble) {
+		require.NoError(t, err)
+		outputs = append(outputs, out)
+		if len(outputs) == 3 {
+			break
+		}
+	}
+	assert.Equal(t, []int{0, 2, 4}, outputs)
+	assert.Eventually(t, func() bool { return limit.InUse() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestMapSeqCancel(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	ctx, cancel := context.WithCancel(context.Background())
+	var errs []error
+	for _, err := range simultaneous.MapSeq(ctx, limit, slices.Values([]int{1, 2, 3}), func(ctx context.Context, _ simultaneous.Enforced[any], in int) (int, error) {
+		cancel()
+		return in, nil
+	}) {
+		errs = append(errs, err)
+	}
+	require.NotEmpty(t, errs)
+	assert.ErrorIs(t, errs[len(errs)-1], context.Canceled)
+}
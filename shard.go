@@ -0,0 +1,166 @@
+package simultaneous
+
+import (
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+)
+
+// WithShards spreads the accounting of free space across n shards so
+// that callers on many cores are not all updating the same counter.
+// Each shard keeps a small supply of space borrowed from the Limit and
+// callers take and release space from a shard chosen at random. This is
+// only worthwhile for large limits (thousands of units) that are used
+// heavily by many goroutines at once: space borrowed by one shard is not
+// available to callers that happen to pick another one until someone has
+// to wait, at which point the shards give back what they hold. Zero or
+// less means one shard per GOMAXPROCS.
+//
+// Sharding only affects callers that could skip the lock anyway: those
+// without a class or a Child, on a Limit that is not FIFO, accumulating
+// reservations, paused, or closed, and has no reservations for classes.
+//
+// WithShards changes the Limit and all of its copies. It returns the
+// Limit so that it can be chained with New.
+func (l *Limit[T]) WithShards(n int) *Limit[T] {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	// the new set is in place before the old one is drained, so that
+	// space given to the old one afterwards is noticed, see releaseShard
+	old := l.core.shards.Swap(newShardSet(n))
+	l.core.drainSet(old)
+	return l
+}
+
+// WithShards spreads the accounting of free space across n shards. See
+// the WithShards method.
+func WithShards(n int) Option {
+	return func(s *state) {
+		s.core.shards.Store(newShardSet(n))
+	}
+}
+
+type shardSet struct {
+	shards []shard
+}
+
+type shard struct {
+	free atomic.Int64
+	_    [56]byte // keep shards on separate cache lines
+}
+
+func newShardSet(n int) *shardSet {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	return &shardSet{
+		shards: make([]shard, n),
+	}
+}
+
+func (set *shardSet) pick() *shard {
+	// the top-level functions in math/rand do not share a lock
+	return &set.shards[rand.Intn(len(set.shards))]
+}
+
+// batch is how much space a shard borrows from the core at a time
+func (set *shardSet) batch(size int64) int64 {
+	batch := size / int64(4*len(set.shards))
+	if batch < 1 {
+		return 1
+	}
+	return batch
+}
+
+// tryShard takes n units of space from a shard, borrowing more space from
+// the core for the shard if it doesn't have enough
+func (c *core) tryShard(set *shardSet, n int64) bool {
+	s := set.pick()
+	for {
+		free := s.free.Load()
+		if free < n {
+			break
+		}
+		if s.free.CompareAndSwap(free, free-n) {
+			return true
+		}
+	}
+	var batch int64
+	if c.waiting.Load() == 0 {
+		batch = set.batch(c.size.Load())
+	}
+	for {
+		used := c.used.Load()
+		free := c.size.Load() - used
+		if free < n {
+			return false
+		}
+		take := n + batch
+		if take > free {
+			take = free
+		}
+		if c.used.CompareAndSwap(used, used+take) {
+			if take > n {
+				s.free.Add(take - n)
+				if c.shards.Load() != set {
+					// replaced by WithShards and perhaps already drained
+					c.lock.Lock()
+					c.drainSet(set)
+					c.grant()
+					c.lock.Unlock()
+				}
+			}
+			return true
+		}
+	}
+}
+
+// releaseShard gives n units of space back to a shard. It returns false
+// if the lock must be taken to grant space to waiters, to notice that the
+// core is idle, or to drain the set because WithShards has replaced it.
+func (c *core) releaseShard(set *shardSet, n int64) bool {
+	s := set.pick()
+	free := s.free.Add(n)
+	// waiting is checked after giving back the space, see acquire
+	if c.waiting.Load() != 0 || c.idleWanted.Load() || c.shards.Load() != set {
+		return false
+	}
+	if batch := set.batch(c.size.Load()); free > 2*batch {
+		if s.free.CompareAndSwap(free, batch) {
+			c.used.Add(batch - free)
+		}
+	}
+	return true
+}
+
+// drainShards returns the space held by the shards to the core. Must be
+// called with the lock held.
+func (c *core) drainShards() {
+	c.drainSet(c.shards.Load())
+}
+
+// drainSet returns the space held by the shards of set, which may no
+// longer be in use, to the core. Must be called with the lock held.
+func (c *core) drainSet(set *shardSet) {
+	if set == nil {
+		return
+	}
+	for i := range set.shards {
+		if free := set.shards[i].free.Swap(0); free != 0 {
+			c.used.Add(-free)
+		}
+	}
+}
+
+// shardFree returns the space held by the shards but not in use
+func (c *core) shardFree() int64 {
+	set := c.shards.Load()
+	if set == nil {
+		return 0
+	}
+	var free int64
+	for i := range set.shards {
+		free += set.shards[i].free.Load()
+	}
+	return free
+}
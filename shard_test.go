@@ -0,0 +1,116 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestShards(t *testing.T) {
+	t.Parallel()
+
+	const size = 50
+	limit := simultaneous.New[any](size, simultaneous.WithShards(4))
+	ctx := context.Background()
+	var current, most atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				done := limit.Forever(ctx)
+				c := current.Add(1)
+				for {
+					m := most.Load()
+					if c <= m || most.CompareAndSwap(m, c) {
+						break
+					}
+				}
+				current.Add(-1)
+				done.Done()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, most.Load(), int64(size))
+	assert.Zero(t, limit.InUse())
+	assert.Zero(t, limit.Waiting())
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	require.NoError(t, limit.WaitForIdle(ctx))
+}
+
+func TestShardsWholeLimit(t *testing.T) {
+	t.Parallel()
+
+	// space borrowed by shards must be usable by a caller that wants all of it
+	limit := simultaneous.New[any](100).WithShards(8)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		limit.Forever(ctx).Done()
+	}
+	done, ok := limit.TryAcquireN(100)
+	require.True(t, ok)
+	assert.Equal(t, 100, limit.InUse())
+	_, ok = limit.Try()
+	assert.False(t, ok)
+	done.Done()
+
+	held, err := limit.AcquireN(ctx, 100)
+	require.NoError(t, err)
+	got := make(chan struct{})
+	go func() {
+		limit.Forever(ctx).Done()
+		close(got)
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	held.Done()
+	select {
+	case <-got:
+	case <-time.After(time.Second):
+		require.Fail(t, "waiter not granted")
+	}
+}
+
+func TestShardsReplaced(t *testing.T) {
+	t.Parallel()
+
+	const size = 100
+	limit := simultaneous.New[any](size, simultaneous.WithShards(4))
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if done, ok := limit.Try(); ok {
+					done.Done()
+				}
+			}
+		}()
+	}
+	for i := 0; i < 2000; i++ {
+		limit.WithShards(i%4 + 1)
+	}
+	close(stop)
+	wg.Wait()
+	assert.Zero(t, limit.InUse(), "no space lost with a replaced set")
+	done, ok := limit.TryAcquireN(size)
+	require.True(t, ok, "the whole limit is available")
+	done.Done()
+}
@@ -0,0 +1,149 @@
+package simultaneous
+
+import (
+	"sync"
+	"time"
+
+	"github.com/memsql/errors"
+)
+
+// ErrShedding is returned when space is not available and the Limit is
+// shedding load because of WithShedding
+var ErrShedding errors.String = "simultaneous limit is shedding load"
+
+// SheddingPolicy says when a Limit should stop queueing. See WithShedding.
+type SheddingPolicy struct {
+	// Window is how far back outcomes are considered. Zero means ten
+	// seconds.
+	Window time.Duration
+	// MinSamples is how many acquisitions and timeouts there must be in
+	// the window before shedding starts. Zero means 20.
+	MinSamples int
+	// MaxTimeoutRate starts shedding when more than this fraction of the
+	// acquisitions and timeouts in the window are timeouts. As in Stats,
+	// failed tries count as timeouts. Zero means the timeout rate does
+	// not matter.
+	MaxTimeoutRate float64
+	// MaxAverageWait starts shedding when acquisitions in the window
+	// waited longer than this on average. Zero means waits do not
+	// matter.
+	MaxAverageWait time.Duration
+	// CoolDown is how long shedding lasts. Zero means the same as the
+	// window.
+	CoolDown time.Duration
+}
+
+// WithShedding makes the Limit act like a circuit breaker. It watches how
+// long acquisitions wait and how often they time out and, once the policy
+// says the Limit is saturated, sheds load for the cool-down period:
+// callers that can't get space right away fail immediately rather than
+// waiting. Methods that return an error return one wrapping ErrShedding
+// and Forever returns a Limited that does not hold space. Space that is
+// available is still granted. After the cool-down, the outcomes are
+// watched afresh.
+//
+// WithShedding changes the Limit and all of its copies. It returns the
+// Limit so that it can be chained with New.
+func (l *Limit[T]) WithShedding(policy SheddingPolicy) *Limit[T] {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	l.core.breaker.Store(newBreaker(policy))
+	return l
+}
+
+// WithShedding makes the Limit shed load when it is saturated. See the
+// WithShedding method.
+func WithShedding(policy SheddingPolicy) Option {
+	return func(s *state) {
+		s.core.breaker.Store(newBreaker(policy))
+	}
+}
+
+// Shedding returns true if the Limit is shedding load
+func (l *Limit[T]) Shedding() bool {
+	b := l.core.breaker.Load()
+	return b != nil && b.shedding(l.core.now())
+}
+
+func (l *Limit[T]) sheddingError() error {
+	return ErrShedding.Errorf("simultaneous limit (of %d) is saturated and shedding load", l.capacity())
+}
+
+const breakerBuckets = 10
+
+// breaker keeps counts of recent outcomes in buckets that each cover a
+// tenth of the window
+type breaker struct {
+	policy  SheddingPolicy
+	lock    sync.Mutex
+	buckets [breakerBuckets]breakerBucket
+	until   time.Time // shedding until then
+}
+
+type breakerBucket struct {
+	id       int64 // which tenth of a window since the epoch
+	acquired int
+	timeouts int
+	wait     time.Duration
+}
+
+func newBreaker(policy SheddingPolicy) *breaker {
+	if policy.Window <= 0 {
+		policy.Window = 10 * time.Second
+	}
+	if policy.MinSamples <= 0 {
+		policy.MinSamples = 20
+	}
+	if policy.CoolDown <= 0 {
+		policy.CoolDown = policy.Window
+	}
+	return &breaker{policy: policy}
+}
+
+func (b *breaker) shedding(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return now.Before(b.until)
+}
+
+// record counts an outcome and starts shedding if the policy says so
+func (b *breaker) record(outcome outcome, waited time.Duration, now time.Time) {
+	if outcome != outcomeAcquired && outcome != outcomeTimeout {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if now.Before(b.until) {
+		return
+	}
+	id := now.UnixNano() / int64(b.policy.Window/breakerBuckets)
+	bucket := &b.buckets[id%breakerBuckets]
+	if bucket.id != id {
+		*bucket = breakerBucket{id: id}
+	}
+	if outcome == outcomeTimeout {
+		bucket.timeouts++
+	} else {
+		bucket.acquired++
+		bucket.wait += waited
+	}
+
+	var acquired, timeouts int
+	var wait time.Duration
+	for _, bucket := range b.buckets {
+		if bucket.id > id-breakerBuckets {
+			acquired += bucket.acquired
+			timeouts += bucket.timeouts
+			wait += bucket.wait
+		}
+	}
+	total := acquired + timeouts
+	if total < b.policy.MinSamples {
+		return
+	}
+	if (b.policy.MaxTimeoutRate > 0 && float64(timeouts) > b.policy.MaxTimeoutRate*float64(total)) ||
+		(b.policy.MaxAverageWait > 0 && acquired > 0 && wait/time.Duration(acquired) > b.policy.MaxAverageWait) {
+		b.until = now.Add(b.policy.CoolDown)
+		b.buckets = [breakerBuckets]breakerBucket{}
+	}
+}
@@ -0,0 +1,93 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestShedding(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	limit := simultaneous.New[any](1,
+		simultaneous.WithClock(clock),
+		simultaneous.WithShedding(simultaneous.SheddingPolicy{
+			Window:         time.Minute,
+			MinSamples:     4,
+			MaxTimeoutRate: 0.5,
+			CoolDown:       10 * time.Second,
+		}))
+	ctx := context.Background()
+
+	a := limit.Forever(ctx)
+	for i := 0; i < 2; i++ {
+		_, ok := limit.TryAcquireN(1)
+		assert.False(t, ok)
+	}
+	assert.False(t, limit.Shedding(), "not enough samples")
+	_, ok := limit.TryAcquireN(1)
+	assert.False(t, ok)
+	require.True(t, limit.Shedding(), "every outcome was a timeout")
+
+	_, err := limit.Acquire(ctx)
+	assert.ErrorIs(t, err, simultaneous.ErrShedding, "Acquire")
+	_, err = limit.Timeout(ctx, time.Hour)
+	assert.ErrorIs(t, err, simultaneous.ErrShedding, "Timeout")
+	assert.Zero(t, limit.Waiting())
+
+	a.Done()
+	b, err := limit.Acquire(ctx)
+	require.NoError(t, err, "available space is granted while shedding")
+	b.Done()
+
+	clock.Advance(10 * time.Second)
+	assert.False(t, limit.Shedding(), "cool-down is over")
+	a = limit.Forever(ctx)
+	got := make(chan error)
+	go func() {
+		done, err := limit.Timeout(ctx, time.Hour)
+		if err == nil {
+			done.Done()
+		}
+		got <- err
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	a.Done()
+	assert.NoError(t, <-got, "waiting again after the cool-down")
+}
+
+func TestSheddingWait(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	limit := simultaneous.New[any](1,
+		simultaneous.WithClock(clock),
+		simultaneous.WithShedding(simultaneous.SheddingPolicy{
+			MinSamples:     2,
+			MaxAverageWait: time.Second,
+		}))
+	ctx := context.Background()
+
+	a := limit.Forever(ctx)
+	got := make(chan simultaneous.Limited[any])
+	go func() {
+		got <- limit.Forever(ctx)
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(3 * time.Second)
+	a.Done()
+	b := <-got
+	assert.True(t, limit.Shedding(), "average wait of 1.5s")
+	_, err := limit.Acquire(ctx)
+	assert.ErrorIs(t, err, simultaneous.ErrShedding)
+	b.Done()
+
+	clock.Advance(10 * time.Second)
+	assert.False(t, limit.Shedding(), "default cool-down is the window")
+}
@@ -0,0 +1,125 @@
+/*
+Package simultaneousgrpc limits the number of gRPC calls that are handled
+at once.
+*/
+package simultaneousgrpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+// Option configures the interceptors
+type Option func(*config)
+
+type config struct {
+	queueTimeout time.Duration
+	queue        bool
+}
+
+// WithQueueTimeout limits how long a call waits for space before it is
+// rejected with RESOURCE_EXHAUSTED. A timeout of zero rejects calls
+// immediately when the limit is full. Without WithQueueTimeout, calls wait
+// until there is space or the call is cancelled.
+func WithQueueTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.queueTimeout = timeout
+		c.queue = false
+	}
+}
+
+// acquirer gets space for a call to a method
+type acquirer[T any] func(ctx context.Context, method string) (simultaneous.Limited[T], error)
+
+// UnaryServerInterceptor returns an interceptor that runs at most as many
+// unary calls at once as the Limit allows
+func UnaryServerInterceptor[T any](limit *simultaneous.Limit[T], opts ...Option) grpc.UnaryServerInterceptor {
+	return unary(limitAcquirer(limit, opts))
+}
+
+// StreamServerInterceptor returns an interceptor that runs at most as many
+// streaming calls at once as the Limit allows. The space is held until the
+// handler returns.
+func StreamServerInterceptor[T any](limit *simultaneous.Limit[T], opts ...Option) grpc.StreamServerInterceptor {
+	return stream(limitAcquirer(limit, opts))
+}
+
+// KeyedUnaryServerInterceptor is like UnaryServerInterceptor except that
+// each method has its own limit. The key is the full method name, for
+// example "/package.Service/Method".
+func KeyedUnaryServerInterceptor[T any](limit *simultaneous.KeyedLimit[string, T], opts ...Option) grpc.UnaryServerInterceptor {
+	return unary(keyedAcquirer(limit, opts))
+}
+
+// KeyedStreamServerInterceptor is like StreamServerInterceptor except that
+// each method has its own limit. The key is the full method name.
+func KeyedStreamServerInterceptor[T any](limit *simultaneous.KeyedLimit[string, T], opts ...Option) grpc.StreamServerInterceptor {
+	return stream(keyedAcquirer(limit, opts))
+}
+
+func newConfig(opts []Option) config {
+	c := config{
+		queue: true,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+func limitAcquirer[T any](limit *simultaneous.Limit[T], opts []Option) acquirer[T] {
+	c := newConfig(opts)
+	return func(ctx context.Context, _ string) (simultaneous.Limited[T], error) {
+		if c.queue {
+			return limit.Acquire(ctx)
+		}
+		return limit.Timeout(ctx, c.queueTimeout)
+	}
+}
+
+func keyedAcquirer[T any](limit *simultaneous.KeyedLimit[string, T], opts []Option) acquirer[T] {
+	c := newConfig(opts)
+	return func(ctx context.Context, method string) (simultaneous.Limited[T], error) {
+		if c.queue {
+			return limit.Acquire(ctx, method)
+		}
+		return limit.Timeout(ctx, method, c.queueTimeout)
+	}
+}
+
+func unary[T any](acquire acquirer[T]) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		done, err := acquire(ctx, info.FullMethod)
+		if err != nil {
+			return nil, rejected(ctx, info.FullMethod, err)
+		}
+		defer done.Done()
+		return handler(ctx, req)
+	}
+}
+
+func stream[T any](acquire acquirer[T]) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		done, err := acquire(ctx, info.FullMethod)
+		if err != nil {
+			return rejected(ctx, info.FullMethod, err)
+		}
+		defer done.Done()
+		return handler(srv, ss)
+	}
+}
+
+// rejected converts the error from acquiring space into a status error
+func rejected(ctx context.Context, method string, err error) error {
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return status.Errorf(codes.ResourceExhausted, "%s: %s", method, err)
+}
@@ -0,0 +1,122 @@
+package simultaneousgrpc_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/singlestore-labs/simultaneous"
+	"github.com/singlestore-labs/simultaneous/simultaneousgrpc"
+)
+
+var unaryInfo = &grpc.UnaryServerInfo{FullMethod: "/test.Service/Unary"}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s serverStream) Context() context.Context { return s.ctx }
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	const limit = 2
+	interceptor := simultaneousgrpc.UnaryServerInterceptor(simultaneous.New[any](limit))
+	var running atomic.Int32
+	handler := func(_ context.Context, req any) (any, error) {
+		assert.LessOrEqual(t, running.Add(1), int32(limit))
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		return req, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := interceptor(context.Background(), i, unaryInfo, handler)
+			assert.NoError(t, err)
+			assert.Equal(t, i, resp)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestUnaryServerInterceptorRejects(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	held := limit.Forever(context.Background())
+	defer held.Done()
+	handler := func(context.Context, any) (any, error) {
+		assert.Fail(t, "handler called")
+		return nil, nil
+	}
+
+	interceptor := simultaneousgrpc.UnaryServerInterceptor(limit, simultaneousgrpc.WithQueueTimeout(5*time.Millisecond))
+	_, err := interceptor(context.Background(), nil, unaryInfo, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	interceptor = simultaneousgrpc.UnaryServerInterceptor(limit)
+	_, err = interceptor(ctx, nil, unaryInfo, handler)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	interceptor := simultaneousgrpc.StreamServerInterceptor(limit, simultaneousgrpc.WithQueueTimeout(0))
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}
+	ss := serverStream{ctx: context.Background()}
+
+	err := interceptor(nil, ss, info, func(any, grpc.ServerStream) error {
+		assert.Equal(t, 1, limit.InUse(), "held while the handler runs")
+		err := interceptor(nil, ss, info, func(any, grpc.ServerStream) error {
+			assert.Fail(t, "nested handler called")
+			return nil
+		})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, limit.InUse())
+}
+
+func TestKeyedServerInterceptors(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.NewKeyed[string, any](1)
+	unary := simultaneousgrpc.KeyedUnaryServerInterceptor(limit, simultaneousgrpc.WithQueueTimeout(0))
+	stream := simultaneousgrpc.KeyedStreamServerInterceptor(limit, simultaneousgrpc.WithQueueTimeout(0))
+	ss := serverStream{ctx: context.Background()}
+
+	_, err := unary(context.Background(), nil, unaryInfo, func(ctx context.Context, _ any) (any, error) {
+		// a different method has its own limit
+		err := stream(nil, ss, &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}, func(any, grpc.ServerStream) error {
+			return nil
+		})
+		assert.NoError(t, err)
+
+		_, err = unary(ctx, nil, unaryInfo, func(context.Context, any) (any, error) {
+			assert.Fail(t, "nested handler called")
+			return nil, nil
+		})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		return nil, nil
+	})
+	require.NoError(t, err)
+}
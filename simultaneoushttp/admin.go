@@ -0,0 +1,113 @@
+package simultaneoushttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+// LimitStatus is how AdminHandler reports a Limit
+type LimitStatus struct {
+	Name         string `json:"name"`
+	Capacity     int    `json:"capacity"`
+	InUse        int    `json:"in_use"`
+	Waiters      int    `json:"waiters"`
+	Acquisitions uint64 `json:"acquisitions"`
+	Timeouts     uint64 `json:"timeouts"`
+	// Holders is only included when asked for
+	Holders []HolderStatus `json:"holders,omitempty"`
+}
+
+// HolderStatus is how AdminHandler reports a holder of space
+type HolderStatus struct {
+	Acquired time.Time `json:"acquired"`
+	Units    int64     `json:"units"`
+	Label    string    `json:"label,omitempty"`
+	Stack    string    `json:"stack"`
+}
+
+// AdminHandler returns an http.Handler for inspecting and adjusting the
+// Limits in a Registry. Mount it on an internal-only address, like the
+// handlers from net/http/pprof.
+//
+// GET responds with a JSON array of LimitStatus, one for each registered
+// Limit. With a "name" query parameter, it responds with just that Limit.
+// With a "holders" query parameter of "true", the holders of space in
+// Limits created WithHolderTracking are included.
+//
+// POST with "name" and "limit" form values changes the capacity of the
+// named Limit, as with SetLimit, and responds with its new LimitStatus.
+func AdminHandler(registry *simultaneous.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			name := r.URL.Query().Get("name")
+			withHolders, _ := strconv.ParseBool(r.URL.Query().Get("holders"))
+			if name == "" {
+				all := registry.All()
+				statuses := make([]LimitStatus, len(all))
+				for i, l := range all {
+					statuses[i] = status(l, withHolders)
+				}
+				writeJSON(w, statuses)
+				return
+			}
+			l := registry.Get(name)
+			if l == nil {
+				http.Error(w, "no limit named "+strconv.Quote(name), http.StatusNotFound)
+				return
+			}
+			writeJSON(w, status(l, withHolders))
+		case http.MethodPost:
+			name := r.FormValue("name")
+			l := registry.Get(name)
+			if l == nil {
+				http.Error(w, "no limit named "+strconv.Quote(name), http.StatusNotFound)
+				return
+			}
+			n, err := strconv.Atoi(r.FormValue("limit"))
+			if err != nil || n < 0 {
+				http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			l.SetLimit(n)
+			writeJSON(w, status(l, false))
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func status(l simultaneous.Named, withHolders bool) LimitStatus {
+	stats := l.Stats()
+	s := LimitStatus{
+		Name:         l.Name(),
+		Capacity:     stats.Capacity,
+		InUse:        stats.InUse,
+		Waiters:      stats.Waiters,
+		Acquisitions: stats.Acquisitions,
+		Timeouts:     stats.Timeouts,
+	}
+	if withHolders {
+		for _, h := range l.Holders() {
+			s.Holders = append(s.Holders, HolderStatus{
+				Acquired: h.Acquired,
+				Units:    h.Units,
+				Label:    h.Label,
+				Stack:    string(h.Stack),
+			})
+		}
+	}
+	return s
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
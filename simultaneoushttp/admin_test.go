@@ -0,0 +1,71 @@
+package simultaneoushttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+	"github.com/singlestore-labs/simultaneous/simultaneoushttp"
+)
+
+func TestAdminHandler(t *testing.T) {
+	t.Parallel()
+
+	registry := simultaneous.NewRegistry()
+	alter := simultaneous.New[any](2, simultaneous.WithName("alter"), simultaneous.WithHolderTracking())
+	require.NoError(t, registry.Register(alter))
+	require.NoError(t, registry.Register(simultaneous.New[any](5, simultaneous.WithName("backup"))))
+	done := alter.Forever(context.Background())
+	defer done.Done()
+	handler := simultaneoushttp.AdminHandler(registry)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var all []simultaneoushttp.LimitStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &all))
+	assert.Equal(t, []simultaneoushttp.LimitStatus{
+		{Name: "alter", Capacity: 2, InUse: 1, Acquisitions: 1},
+		{Name: "backup", Capacity: 5},
+	}, all)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?name=alter&holders=true", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var one simultaneoushttp.LimitStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &one))
+	require.Len(t, one.Holders, 1)
+	assert.Contains(t, one.Holders[0].Stack, "TestAdminHandler")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?name=missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	w = post(url.Values{"name": {"alter"}, "limit": {"7"}})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &one))
+	assert.Equal(t, 7, one.Capacity)
+	assert.Equal(t, 7, alter.Limit())
+
+	assert.Equal(t, http.StatusBadRequest, post(url.Values{"name": {"alter"}, "limit": {"many"}}).Code)
+	assert.Equal(t, http.StatusNotFound, post(url.Values{"name": {"nope"}, "limit": {"1"}}).Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,94 @@
+/*
+Package simultaneoushttp limits the number of HTTP requests that are
+handled at once by a server (Middleware) or sent at once by a client
+(LimitedTransport).
+*/
+package simultaneoushttp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+// DefaultRetryAfter is the Retry-After sent with rejected requests
+const DefaultRetryAfter = time.Second
+
+// Option configures Middleware
+type Option func(*config)
+
+type config struct {
+	queueTimeout time.Duration
+	queue        bool
+	retryAfter   time.Duration
+	rejected     http.Handler
+}
+
+// WithQueueTimeout limits how long a request waits for space before it
+// is rejected. A timeout of zero rejects requests immediately when the
+// limit is full. Without WithQueueTimeout, requests wait until there is
+// space or the request context is cancelled.
+func WithQueueTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.queueTimeout = timeout
+		c.queue = false
+	}
+}
+
+// WithRetryAfter sets the Retry-After header sent with rejected requests.
+// It is rounded up to whole seconds. Zero omits the header.
+func WithRetryAfter(retryAfter time.Duration) Option {
+	return func(c *config) {
+		c.retryAfter = retryAfter
+	}
+}
+
+// WithRejected replaces the response sent when a request is rejected.
+// The Retry-After header is set before the handler is called.
+func WithRejected(rejected http.Handler) Option {
+	return func(c *config) {
+		c.rejected = rejected
+	}
+}
+
+// Middleware returns a function that wraps an http.Handler so that at most
+// as many requests as the Limit allows are handled at once. Requests that
+// can't get space are rejected with 503 Service Unavailable.
+//
+//	limit := simultaneous.New[http.Handler](100)
+//	handler = simultaneoushttp.Middleware(limit, simultaneoushttp.WithQueueTimeout(time.Second))(handler)
+func Middleware[T any](limit *simultaneous.Limit[T], opts ...Option) func(http.Handler) http.Handler {
+	c := config{
+		queue:      true,
+		retryAfter: DefaultRetryAfter,
+		rejected: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		}),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var done simultaneous.Limited[T]
+			var err error
+			if c.queue {
+				done, err = limit.Acquire(r.Context())
+			} else {
+				done, err = limit.Timeout(r.Context(), c.queueTimeout)
+			}
+			if err != nil {
+				if c.retryAfter > 0 {
+					seconds := int64((c.retryAfter + time.Second - 1) / time.Second)
+					w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+				}
+				c.rejected.ServeHTTP(w, r)
+				return
+			}
+			defer done.Done()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package simultaneoushttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/singlestore-labs/simultaneous"
+	"github.com/singlestore-labs/simultaneous/simultaneoushttp"
+)
+
+func TestMiddlewareQueues(t *testing.T) {
+	t.Parallel()
+
+	const limit = 2
+	var running atomic.Int32
+	handler := simultaneoushttp.Middleware(simultaneous.New[any](limit))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		assert.LessOrEqual(t, running.Add(1), int32(limit))
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMiddlewareSheds(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	held := limit.Forever(context.Background())
+	defer held.Done()
+
+	called := false
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true })
+
+	handler := simultaneoushttp.Middleware(limit,
+		simultaneoushttp.WithQueueTimeout(0),
+		simultaneoushttp.WithRetryAfter(1500*time.Millisecond),
+	)(next)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	handler = simultaneoushttp.Middleware(limit,
+		simultaneoushttp.WithQueueTimeout(10*time.Millisecond),
+		simultaneoushttp.WithRetryAfter(0),
+		simultaneoushttp.WithRejected(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		})),
+	)(next)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+	assert.False(t, called)
+}
+
+func TestMiddlewareCancelled(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	held := limit.Forever(context.Background())
+	defer held.Done()
+
+	handler := simultaneoushttp.Middleware(limit)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		assert.Fail(t, "handler called")
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
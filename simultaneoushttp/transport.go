@@ -0,0 +1,94 @@
+package simultaneoushttp
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+// LimitedTransport is an http.RoundTripper that bounds the number of
+// outbound requests in flight. A request is in flight from when it is sent
+// until its response body is closed, so callers must close response bodies
+// as the http package requires.
+//
+// Requests wait for space until their context is cancelled. Limit and
+// PerHost can be used together, in which case a request needs space in
+// both. Space in PerHost is obtained first.
+type LimitedTransport[T any] struct {
+	// Base is the RoundTripper that sends requests. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+	// Limit, if not nil, bounds the total number of requests in flight
+	Limit *simultaneous.Limit[T]
+	// PerHost, if not nil, bounds the number of requests in flight to
+	// each host. The key is the host from the request URL, including
+	// the port if there is one.
+	PerHost *simultaneous.KeyedLimit[string, T]
+}
+
+var _ http.RoundTripper = &LimitedTransport[any]{}
+
+// RoundTrip implements http.RoundTripper
+func (t *LimitedTransport[T]) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	var held []simultaneous.Limited[T]
+	release := func() {
+		for _, done := range held {
+			done.Done()
+		}
+	}
+	if t.PerHost != nil {
+		done, err := t.PerHost.Acquire(ctx, req.URL.Host)
+		if err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+		held = append(held, done)
+	}
+	if t.Limit != nil {
+		done, err := t.Limit.Acquire(ctx)
+		if err != nil {
+			release()
+			closeRequestBody(req)
+			return nil, err
+		}
+		held = append(held, done)
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{
+		ReadCloser: resp.Body,
+		release:    release,
+	}
+	return resp, nil
+}
+
+// closeRequestBody closes the request body as RoundTrip must do even
+// when it returns an error
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+// releasingBody releases space when the response body is closed
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
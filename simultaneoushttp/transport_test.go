@@ -0,0 +1,106 @@
+package simultaneoushttp_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+	"github.com/singlestore-labs/simultaneous/simultaneoushttp"
+)
+
+func TestLimitedTransport(t *testing.T) {
+	t.Parallel()
+
+	const limit = 2
+	var running atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		assert.LessOrEqual(t, running.Add(1), int32(limit))
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	transport := &simultaneoushttp.LimitedTransport[any]{
+		Base:  server.Client().Transport,
+		Limit: simultaneous.New[any](limit),
+	}
+	client := &http.Client{Transport: transport}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if !assert.NoError(t, err) {
+				return
+			}
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, "ok", string(body))
+			assert.NoError(t, resp.Body.Close())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 0, transport.Limit.InUse())
+}
+
+func TestLimitedTransportHeldUntilClose(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	perHost := simultaneous.NewKeyed[string, any](1)
+	client := &http.Client{Transport: &simultaneoushttp.LimitedTransport[any]{
+		Base:    server.Client().Transport,
+		PerHost: perHost,
+	}}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "body of first response not yet closed")
+
+	require.NoError(t, resp.Body.Close())
+	require.NoError(t, resp.Body.Close())
+	resp, err = client.Get(server.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+}
+
+func TestLimitedTransportError(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	client := &http.Client{Transport: &simultaneoushttp.LimitedTransport[any]{
+		Base: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return nil, io.ErrUnexpectedEOF
+		}),
+		Limit: limit,
+	}}
+	_, err := client.Get("http://example.invalid/")
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, 0, limit.InUse())
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
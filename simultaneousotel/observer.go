@@ -0,0 +1,137 @@
+/*
+Package simultaneousotel records long waits for space in a
+simultaneous.Limit in OpenTelemetry traces.
+*/
+package simultaneousotel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+const instrumentationName = "github.com/singlestore-labs/simultaneous/simultaneousotel"
+
+// DefaultThreshold is the shortest wait that is recorded unless
+// WithThreshold is used
+const DefaultThreshold = 10 * time.Millisecond
+
+// Attribute keys used on spans and events
+const (
+	LimitKey   = attribute.Key("simultaneous.limit")
+	WaitedKey  = attribute.Key("simultaneous.waited_ms")
+	OutcomeKey = attribute.Key("simultaneous.outcome")
+)
+
+const (
+	spanName  = "simultaneous.wait"
+	acquired  = "acquired"
+	timedOut  = "timeout"
+	cancelled = "cancelled"
+)
+
+// Option configures the Observer made by NewObserver
+type Option func(*observer)
+
+// WithThreshold sets the shortest wait that is recorded. Acquisitions
+// that wait less, including those that do not wait at all, are not
+// recorded.
+func WithThreshold(threshold time.Duration) Option {
+	return func(o *observer) {
+		o.threshold = threshold
+	}
+}
+
+// WithTracerProvider sets where spans are created. The default is the
+// global TracerProvider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *observer) {
+		o.tracer = provider.Tracer(instrumentationName)
+	}
+}
+
+// WithSpanEvents records each long wait as an event on the span in the
+// context of the caller rather than as a span of its own. Nothing is
+// recorded when the context has no span.
+func WithSpanEvents() Option {
+	return func(o *observer) {
+		o.events = true
+	}
+}
+
+type observer struct {
+	name      string
+	threshold time.Duration
+	tracer    trace.Tracer
+	events    bool
+}
+
+// NewObserver returns a simultaneous.Observer that records each wait for
+// space that lasts at least the threshold as a span that is a child of
+// the span in the caller's context. The span covers the wait and has
+// attributes for the name of the limit, how long the wait was, and its
+// outcome: "acquired", "timeout", or "cancelled".
+//
+//	limit.AddObserver(simultaneousotel.NewObserver("alter-table"))
+func NewObserver(name string, opts ...Option) simultaneous.Observer {
+	o := &observer{
+		name:      name,
+		threshold: DefaultThreshold,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.tracer == nil {
+		o.tracer = otel.GetTracerProvider().Tracer(instrumentationName)
+	}
+	return o
+}
+
+func (o *observer) OnWaitStart(context.Context) {}
+
+func (o *observer) OnStuck(context.Context, time.Duration) {}
+
+func (o *observer) OnAcquired(ctx context.Context, waited time.Duration) {
+	o.record(ctx, waited, acquired)
+}
+
+func (o *observer) OnTimeout(ctx context.Context, waited time.Duration) {
+	outcome := timedOut
+	if ctx.Err() != nil {
+		outcome = cancelled
+	}
+	o.record(ctx, waited, outcome)
+}
+
+func (o *observer) record(ctx context.Context, waited time.Duration, outcome string) {
+	if waited < o.threshold || waited == 0 {
+		return
+	}
+	end := time.Now()
+	attributes := []attribute.KeyValue{
+		LimitKey.String(o.name),
+		WaitedKey.Float64(float64(waited) / float64(time.Millisecond)),
+		OutcomeKey.String(outcome),
+	}
+	if o.events {
+		span := trace.SpanFromContext(ctx)
+		if !span.IsRecording() {
+			return
+		}
+		span.AddEvent(spanName, trace.WithTimestamp(end), trace.WithAttributes(attributes...))
+		return
+	}
+	_, span := o.tracer.Start(ctx, spanName,
+		trace.WithTimestamp(end.Add(-waited)),
+		trace.WithAttributes(attributes...))
+	if outcome != acquired {
+		span.SetStatus(codes.Error, "gave up waiting for space")
+	}
+	span.End(trace.WithTimestamp(end))
+}
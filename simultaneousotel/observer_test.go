@@ -0,0 +1,74 @@
+package simultaneousotel_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/singlestore-labs/simultaneous"
+	"github.com/singlestore-labs/simultaneous/simultaneousotel"
+)
+
+func TestObserver(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	limit := simultaneous.New[any](1, simultaneous.WithObserver(
+		simultaneousotel.NewObserver("test",
+			simultaneousotel.WithTracerProvider(provider),
+			simultaneousotel.WithThreshold(5*time.Millisecond))))
+	ctx := context.Background()
+
+	held := limit.Forever(ctx)
+	_, err := limit.Timeout(ctx, 10*time.Millisecond)
+	require.ErrorIs(t, err, simultaneous.ErrTimeout)
+	_, err = limit.Timeout(ctx, time.Millisecond)
+	require.ErrorIs(t, err, simultaneous.ErrTimeout)
+	held.Done()
+	limit.Forever(ctx).Done()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1, "only the wait over the threshold")
+	span := spans[0]
+	assert.Equal(t, "simultaneous.wait", span.Name())
+	attributes := map[string]any{}
+	for _, kv := range span.Attributes() {
+		attributes[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	assert.Equal(t, "test", attributes[string(simultaneousotel.LimitKey)])
+	assert.Equal(t, "timeout", attributes[string(simultaneousotel.OutcomeKey)])
+	assert.GreaterOrEqual(t, attributes[string(simultaneousotel.WaitedKey)], float64(10))
+	assert.GreaterOrEqual(t, span.EndTime().Sub(span.StartTime()), 10*time.Millisecond)
+}
+
+func TestObserverSpanEvents(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	limit := simultaneous.New[any](1)
+	limit.AddObserver(simultaneousotel.NewObserver("test",
+		simultaneousotel.WithSpanEvents(),
+		simultaneousotel.WithThreshold(time.Millisecond)))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	held := limit.Forever(ctx)
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		held.Done()
+	}()
+	limit.Forever(ctx).Done()
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	events := spans[0].Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "simultaneous.wait", events[0].Name)
+}
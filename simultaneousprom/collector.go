@@ -0,0 +1,93 @@
+/*
+Package simultaneousprom exports the state of a simultaneous.Limit as
+Prometheus metrics.
+*/
+package simultaneousprom
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+// Source is implemented by *simultaneous.Limit[T] for any T
+type Source interface {
+	Stats() simultaneous.Stats
+	AddWaitObserver(func(time.Duration)) (remove func())
+}
+
+// Collector is a prometheus.Collector for a Limit. Every metric has a
+// "limit" label with the name given to NewCollector.
+type Collector struct {
+	source       Source
+	remove       func()
+	capacity     *prometheus.Desc
+	inUse        *prometheus.Desc
+	waiters      *prometheus.Desc
+	acquisitions *prometheus.Desc
+	timeouts     *prometheus.Desc
+	tryFailures  *prometheus.Desc
+	waitSeconds  prometheus.Histogram
+}
+
+var _ prometheus.Collector = &Collector{}
+
+// DefaultWaitBuckets are the buckets used for the wait time histogram
+var DefaultWaitBuckets = []float64{.0001, .001, .01, .1, 1, 10, 100}
+
+// NewCollector creates a Collector for a Limit. The Collector must be
+// registered to be useful:
+//
+//	prometheus.MustRegister(simultaneousprom.NewCollector("alter-table", limit))
+func NewCollector(name string, source Source) *Collector {
+	labels := prometheus.Labels{"limit": name}
+	c := &Collector{
+		source:       source,
+		capacity:     prometheus.NewDesc("simultaneous_capacity", "Maximum units of space that can be held at once.", nil, labels),
+		inUse:        prometheus.NewDesc("simultaneous_in_use", "Units of space currently held.", nil, labels),
+		waiters:      prometheus.NewDesc("simultaneous_waiters", "Callers currently waiting for space.", nil, labels),
+		acquisitions: prometheus.NewDesc("simultaneous_acquisitions_total", "Number of times space was obtained.", nil, labels),
+		timeouts:     prometheus.NewDesc("simultaneous_timeouts_total", "Number of times waiting for space timed out.", nil, labels),
+		tryFailures:  prometheus.NewDesc("simultaneous_try_failures_total", "Number of times trying for space without waiting found none. Included in simultaneous_timeouts_total.", nil, labels),
+		waitSeconds: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:        "simultaneous_wait_seconds",
+			Help:        "Time spent waiting for space.",
+			ConstLabels: labels,
+			Buckets:     DefaultWaitBuckets,
+		}),
+	}
+	c.remove = source.AddWaitObserver(func(waited time.Duration) {
+		c.waitSeconds.Observe(waited.Seconds())
+	})
+	return c
+}
+
+// Close stops recording wait times. It does not unregister the Collector.
+func (c *Collector) Close() {
+	c.remove()
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.capacity
+	ch <- c.inUse
+	ch <- c.waiters
+	ch <- c.acquisitions
+	ch <- c.timeouts
+	ch <- c.tryFailures
+	c.waitSeconds.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.source.Stats()
+	ch <- prometheus.MustNewConstMetric(c.capacity, prometheus.GaugeValue, float64(stats.Capacity))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.waiters, prometheus.GaugeValue, float64(stats.Waiters))
+	ch <- prometheus.MustNewConstMetric(c.acquisitions, prometheus.CounterValue, float64(stats.Acquisitions))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.tryFailures, prometheus.CounterValue, float64(stats.TryFailures))
+	c.waitSeconds.Collect(ch)
+}
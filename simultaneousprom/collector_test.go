@@ -0,0 +1,66 @@
+package simultaneousprom_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+	"github.com/singlestore-labs/simultaneous/simultaneousprom"
+)
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	collector := simultaneousprom.NewCollector("test", limit)
+	defer collector.Close()
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	done := limit.Forever(context.Background())
+	_, err := limit.AcquireN(context.Background(), 1)
+	require.NoError(t, err)
+	_, err = limit.Timeout(context.Background(), time.Millisecond)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+	_, ok := limit.Try()
+	assert.False(t, ok)
+	done.Done()
+
+	err = testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP simultaneous_acquisitions_total Number of times space was obtained.
+# TYPE simultaneous_acquisitions_total counter
+simultaneous_acquisitions_total{limit="test"} 2
+# HELP simultaneous_capacity Maximum units of space that can be held at once.
+# TYPE simultaneous_capacity gauge
+simultaneous_capacity{limit="test"} 2
+# HELP simultaneous_in_use Units of space currently held.
+# TYPE simultaneous_in_use gauge
+simultaneous_in_use{limit="test"} 1
+# HELP simultaneous_timeouts_total Number of times waiting for space timed out.
+# TYPE simultaneous_timeouts_total counter
+simultaneous_timeouts_total{limit="test"} 2
+# HELP simultaneous_try_failures_total Number of times trying for space without waiting found none. Included in simultaneous_timeouts_total.
+# TYPE simultaneous_try_failures_total counter
+simultaneous_try_failures_total{limit="test"} 1
+# HELP simultaneous_waiters Callers currently waiting for space.
+# TYPE simultaneous_waiters gauge
+simultaneous_waiters{limit="test"} 0
+`), "simultaneous_acquisitions_total", "simultaneous_capacity", "simultaneous_in_use", "simultaneous_timeouts_total", "simultaneous_try_failures_total", "simultaneous_waiters")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(collector, "simultaneous_wait_seconds"))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == "simultaneous_wait_seconds" {
+			assert.Equal(t, uint64(2), family.GetMetric()[0].GetHistogram().GetSampleCount())
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package simultaneousredis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/memsql/errors"
+
+	"github.com/singlestore-labs/simultaneous/internal/lease"
+)
+
+// setStore keeps leases in the sorted set at the key of a Limit. It
+// implements lease.Store.
+type setStore[T any] struct {
+	limit *Limit[T]
+}
+
+var _ lease.Store = setStore[any]{}
+
+func (l *Limit[T]) newLease() *lease.Lease {
+	return lease.New(setStore[T]{limit: l}, lease.Config{
+		TTL:          l.ttl,
+		PollInterval: l.pollInterval,
+		LeaseLost:    l.leaseLost,
+		Space:        fmt.Sprintf("space (of %d) in %s", l.limit, l.key),
+	})
+}
+
+func (s setStore[T]) Take(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	l := s.limit
+	n, err := acquireScript.Run(ctx, l.client, []string{l.key}, l.limit, holder, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, errors.Wrapf(err, "lease space in %s", l.key)
+	}
+	return n == 1, nil
+}
+
+// Renew extends the lease. It returns true if the lease has been lost.
+func (s setStore[T]) Renew(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	l := s.limit
+	n, err := renewScript.Run(ctx, l.client, []string{l.key}, holder, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, errors.Wrapf(err, "renew lease in %s", l.key)
+	}
+	if n == 0 {
+		return true, ErrLeaseLost.Errorf("lease in %s expired", l.key)
+	}
+	return false, nil
+}
+
+func (s setStore[T]) Release(ctx context.Context, holder string) error {
+	l := s.limit
+	return errors.Wrapf(releaseScript.Run(ctx, l.client, []string{l.key}, holder).Err(), "release lease in %s", l.key)
+}
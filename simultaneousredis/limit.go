@@ -0,0 +1,187 @@
+/*
+Package simultaneousredis enforces a simultaneous limit across processes
+using Redis.
+
+The holders of a limit are kept in a sorted set, scored by when their
+leases expire. Lua scripts make taking, renewing, and releasing a lease
+atomic. While space is held, the lease is renewed in the background. If a
+process crashes, its lease expires and the space becomes available again.
+All times come from the Redis server so clocks on the clients do not need
+to agree. Redis 5 or later is required.
+
+Space obtained from a Limit is a simultaneous.Limited so code written
+against an in-process simultaneous.Limit can use a Limit from this package
+without changes.
+*/
+package simultaneousredis
+
+import (
+	"context"
+	"time"
+
+	"github.com/memsql/errors"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/singlestore-labs/simultaneous"
+	"github.com/singlestore-labs/simultaneous/internal/lease"
+)
+
+const (
+	// DefaultLeaseTTL is how long a lease lasts without being renewed
+	DefaultLeaseTTL = 30 * time.Second
+	// DefaultPollInterval is how often a waiter checks for free space
+	DefaultPollInterval = 100 * time.Millisecond
+	// MinLeaseTTL is the shortest lease TTL, see WithLeaseTTL
+	MinLeaseTTL = lease.MinTTL
+)
+
+// ErrLeaseLost is passed to the callback set with WithLeaseLost when a
+// held lease could not be renewed before it expired
+var ErrLeaseLost errors.String = "redis lease lost"
+
+// now sets the local "now" to the server time in milliseconds
+const now = `
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+`
+
+// KEYS[1] is the set of holders. ARGV is the limit, the holder, and the
+// ttl in milliseconds. Returns 1 if the lease was taken.
+var acquireScript = redis.NewScript(now + `
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[3]), ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+// KEYS[1] is the set of holders. ARGV is the holder and the ttl in
+// milliseconds. Returns 0 if the lease has been lost.
+var renewScript = redis.NewScript(now + `
+local expires = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not expires or tonumber(expires) < now then
+	return 0
+end
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[2]), ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+// KEYS[1] is the set of holders. ARGV is the holder.
+var releaseScript = redis.NewScript(`
+return redis.call('ZREM', KEYS[1], ARGV[1])
+`)
+
+// KEYS[1] is the set of holders. Returns the number of unexpired leases.
+var inUseScript = redis.NewScript(now + `
+return redis.call('ZCOUNT', KEYS[1], now, '+inf')
+`)
+
+// Limit is a limit on simultaneous actions that is shared by every
+// process that uses the same Redis key.
+type Limit[T any] struct {
+	client       redis.Scripter
+	key          string
+	limit        int
+	ttl          time.Duration
+	pollInterval time.Duration
+	leaseLost    func(context.Context, error)
+}
+
+// New creates a Limit, stored in Redis at key, that allows limit holders
+// at once across all processes. The client can be a *redis.Client,
+// *redis.ClusterClient, or anything else that can run scripts. The type
+// parameter serves the same purpose as it does for simultaneous.New.
+func New[T any](client redis.Scripter, key string, limit int) *Limit[T] {
+	return &Limit[T]{
+		client:       client,
+		key:          key,
+		limit:        limit,
+		ttl:          DefaultLeaseTTL,
+		pollInterval: DefaultPollInterval,
+	}
+}
+
+// WithLeaseTTL returns a modified Limit whose leases expire after ttl
+// if they are not renewed. Leases are renewed every third of ttl. A ttl
+// below MinLeaseTTL, including zero or less, is raised to MinLeaseTTL.
+func (l Limit[T]) WithLeaseTTL(ttl time.Duration) *Limit[T] {
+	l.ttl = ttl
+	return &l
+}
+
+// WithPollInterval returns a modified Limit that checks for free space
+// every interval (plus up to 10% random jitter) while waiting
+func (l Limit[T]) WithPollInterval(interval time.Duration) *Limit[T] {
+	l.pollInterval = interval
+	return &l
+}
+
+// WithLeaseLost returns a modified Limit that calls leaseLost if a held
+// lease cannot be renewed. After that, another process may be given the
+// space so the holder should stop what it is doing. The error wraps
+// ErrLeaseLost if the lease expired and otherwise is the Redis error from
+// the last renewal attempt.
+func (l Limit[T]) WithLeaseLost(leaseLost func(context.Context, error)) *Limit[T] {
+	l.leaseLost = leaseLost
+	return &l
+}
+
+// Acquire waits until a lease can be taken or the context is cancelled.
+// If the context is cancelled first, an error wrapping ctx.Err() is
+// returned. Redis errors are returned too. On error, the returned Limited
+// does not hold space and its Done method is a no-op; otherwise it
+// releases the lease.
+func (l *Limit[T]) Acquire(ctx context.Context) (simultaneous.Limited[T], error) {
+	s := l.newLease()
+	if err := s.Acquire(ctx); err != nil {
+		return simultaneous.NotHeld[T](), err
+	}
+	return simultaneous.Adopt[T](s), nil
+}
+
+// TryAcquire takes a lease if there is space without waiting. It
+// returns false if there is not.
+func (l *Limit[T]) TryAcquire(ctx context.Context) (simultaneous.Limited[T], bool, error) {
+	s := l.newLease()
+	ok, err := s.Try(ctx)
+	if err != nil || !ok {
+		return simultaneous.NotHeld[T](), false, err
+	}
+	return simultaneous.Adopt[T](s), true, nil
+}
+
+// AcquireTimeout is like Acquire except that it waits for at most
+// timeout. If the timeout expires first, the error matches
+// simultaneous.ErrTimeout. A timeout of zero or less is the same as
+// TryAcquire.
+func (l *Limit[T]) AcquireTimeout(ctx context.Context, timeout time.Duration) (simultaneous.Limited[T], error) {
+	if timeout <= 0 {
+		done, ok, err := l.TryAcquire(ctx)
+		if err == nil && !ok {
+			err = l.timeoutError(timeout)
+		}
+		return done, err
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done, err := l.Acquire(waitCtx)
+	if err != nil && ctx.Err() == nil && waitCtx.Err() != nil {
+		return simultaneous.NotHeld[T](), l.timeoutError(timeout)
+	}
+	return done, err
+}
+
+func (l *Limit[T]) timeoutError(timeout time.Duration) error {
+	return simultaneous.ErrTimeout.Errorf("timeout (%s) expired before any redis lease (of %d) for %s became available", timeout, l.limit, l.key)
+}
+
+var _ simultaneous.Limiter[any] = &Limit[any]{}
+
+// InUse returns the number of unexpired leases
+func (l *Limit[T]) InUse(ctx context.Context) (int, error) {
+	n, err := inUseScript.Run(ctx, l.client, []string{l.key}).Int()
+	return n, errors.Wrapf(err, "count leases for %s", l.key)
+}
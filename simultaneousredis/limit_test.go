@@ -0,0 +1,157 @@
+package simultaneousredis_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+	"github.com/singlestore-labs/simultaneous/simultaneousredis"
+)
+
+func newClient(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return server, client
+}
+
+func TestRedis(t *testing.T) {
+	t.Parallel()
+	_, client := newClient(t)
+
+	const limit = 3
+	// two Limits with the same key stand in for two processes
+	a := simultaneousredis.New[any](client, "test", limit).WithPollInterval(time.Millisecond)
+	b := simultaneousredis.New[any](client, "test", limit).WithPollInterval(2 * time.Millisecond)
+
+	var running atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		l := a
+		if i%2 == 1 {
+			l = b
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := l.Acquire(context.Background())
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer done.Done()
+			assert.LessOrEqual(t, running.Add(1), int32(limit))
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	inUse, err := a.InUse(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, inUse)
+}
+
+func TestRedisTryAcquire(t *testing.T) {
+	t.Parallel()
+	_, client := newClient(t)
+
+	l := simultaneousredis.New[any](client, "test", 1).WithPollInterval(time.Millisecond)
+	done, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+	var _ simultaneous.Enforced[any] = done
+
+	_, ok, err := l.TryAcquire(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = l.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, done.Yield(context.Background()))
+	inUse, err := l.InUse(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, inUse, "after Yield")
+
+	done.Done()
+	done, ok, err = l.TryAcquire(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok)
+	done.Done()
+}
+
+func TestRedisExpiry(t *testing.T) {
+	t.Parallel()
+	server, client := newClient(t)
+
+	lost := make(chan error, 1)
+	l := simultaneousredis.New[any](client, "test", 1).
+		WithLeaseTTL(30 * time.Millisecond).
+		WithLeaseLost(func(_ context.Context, err error) { lost <- err })
+	done, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+
+	// renewals keep the lease alive past its TTL
+	time.Sleep(100 * time.Millisecond)
+	_, ok, err := l.TryAcquire(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// a crashed holder stops renewing, simulated here by moving the
+	// server clock past the expiry
+	server.SetTime(time.Now().Add(time.Hour))
+	other, ok, err := l.TryAcquire(context.Background())
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.ErrorIs(t, <-lost, simultaneousredis.ErrLeaseLost)
+	other.Done()
+	done.Done()
+}
+
+func TestRedisAcquireTimeout(t *testing.T) {
+	t.Parallel()
+	_, client := newClient(t)
+
+	var l simultaneous.Limiter[any] = simultaneousredis.New[any](client, "timeout", 1).WithPollInterval(time.Millisecond)
+	done, err := l.AcquireTimeout(context.Background(), time.Second)
+	require.NoError(t, err)
+
+	// failures return a Limited whose Done is a no-op
+	failed, err := l.AcquireTimeout(context.Background(), 20*time.Millisecond)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+	assert.NotPanics(t, failed.Done)
+	failed, err = l.AcquireTimeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+	assert.NotPanics(t, failed.Done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	failed, err = l.AcquireTimeout(ctx, time.Second)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, simultaneous.ErrTimeout)
+	assert.NotPanics(t, failed.Done)
+
+	done.Done()
+}
+
+func TestRedisZeroTTL(t *testing.T) {
+	t.Parallel()
+	_, client := newClient(t)
+
+	// a TTL too short to renew is raised to MinLeaseTTL rather than
+	// stopping the heartbeat with a panic
+	l := simultaneousredis.New[any](client, "test", 1).WithLeaseTTL(-time.Second)
+	done, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+	time.Sleep(10 * simultaneousredis.MinLeaseTTL)
+	done.Done()
+}
@@ -0,0 +1,277 @@
+package simultaneoussql
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/memsql/errors"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+// limitedConn is a driver.Conn that gets space before running statements.
+// It implements all of the optional interfaces that database/sql looks
+// for and falls back to what database/sql would do when the underlying
+// connection does not implement them.
+type limitedConn struct {
+	driver.Conn
+	connector *connector
+}
+
+var (
+	_ driver.QueryerContext     = &limitedConn{}
+	_ driver.ExecerContext      = &limitedConn{}
+	_ driver.ConnPrepareContext = &limitedConn{}
+	_ driver.ConnBeginTx        = &limitedConn{}
+	_ driver.Pinger             = &limitedConn{}
+	_ driver.SessionResetter    = &limitedConn{}
+	_ driver.Validator          = &limitedConn{}
+	_ driver.NamedValueChecker  = &limitedConn{}
+)
+
+func (c *limitedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		// database/sql will prepare a statement instead
+		return nil, driver.ErrSkip
+	}
+	all, err := c.connector.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		all.Done()
+		return nil, err
+	}
+	return &limitedRows{
+		Rows: rows,
+		all:  all,
+	}, nil
+}
+
+func (c *limitedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	all, err := c.connector.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer all.Done()
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *limitedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *limitedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &limitedStmt{
+		Stmt:      stmt,
+		connector: c.connector,
+		query:     query,
+	}, nil
+}
+
+func (c *limitedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 {
+		return nil, errors.Errorf("sql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.Errorf("sql: driver does not support read-only transactions")
+	}
+	return c.Conn.Begin() //nolint:staticcheck // fallback for drivers without BeginTx
+}
+
+func (c *limitedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *limitedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *limitedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *limitedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// limitedStmt is a driver.Stmt that gets space before running
+type limitedStmt struct {
+	driver.Stmt
+	connector *connector
+	query     string
+}
+
+var (
+	_ driver.StmtQueryContext  = &limitedStmt{}
+	_ driver.StmtExecContext   = &limitedStmt{}
+	_ driver.NamedValueChecker = &limitedStmt{}
+)
+
+func (s *limitedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	all, err := s.connector.acquire(ctx, s.query)
+	if err != nil {
+		return nil, err
+	}
+	var rows driver.Rows
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		values, err = namedValues(args)
+		if err == nil {
+			rows, err = s.Stmt.Query(values) //nolint:staticcheck // fallback for drivers without QueryContext
+		}
+	}
+	if err != nil {
+		all.Done()
+		return nil, err
+	}
+	return &limitedRows{
+		Rows: rows,
+		all:  all,
+	}, nil
+}
+
+func (s *limitedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	all, err := s.connector.acquire(ctx, s.query)
+	if err != nil {
+		return nil, err
+	}
+	defer all.Done()
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return execer.ExecContext(ctx, args)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(values) //nolint:staticcheck // fallback for drivers without ExecContext
+}
+
+func (s *limitedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func namedValues(named []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(named))
+	for i, nv := range named {
+		if nv.Name != "" {
+			return nil, errors.Errorf("sql: driver does not support the use of Named Parameters")
+		}
+		values[i] = nv.Value
+	}
+	return values, nil
+}
+
+// limitedRows releases the space when the rows are closed. Like
+// limitedConn, it implements the optional interfaces with the defaults
+// that database/sql would use.
+type limitedRows struct {
+	driver.Rows
+	once sync.Once
+	all  *simultaneous.AllLimited
+}
+
+var (
+	_ driver.RowsNextResultSet              = &limitedRows{}
+	_ driver.RowsColumnTypeScanType         = &limitedRows{}
+	_ driver.RowsColumnTypeDatabaseTypeName = &limitedRows{}
+	_ driver.RowsColumnTypeLength           = &limitedRows{}
+	_ driver.RowsColumnTypeNullable         = &limitedRows{}
+	_ driver.RowsColumnTypePrecisionScale   = &limitedRows{}
+)
+
+func (r *limitedRows) Close() error {
+	err := r.Rows.Close()
+	r.once.Do(r.all.Done)
+	return err
+}
+
+func (r *limitedRows) HasNextResultSet() bool {
+	if next, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return next.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *limitedRows) NextResultSet() error {
+	if next, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return next.NextResultSet()
+	}
+	return io.EOF
+}
+
+var anyType = reflect.TypeOf(new(any)).Elem()
+
+func (r *limitedRows) ColumnTypeScanType(index int) reflect.Type {
+	if t, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return t.ColumnTypeScanType(index)
+	}
+	return anyType
+}
+
+func (r *limitedRows) ColumnTypeDatabaseTypeName(index int) string {
+	if t, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return t.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *limitedRows) ColumnTypeLength(index int) (int64, bool) {
+	if t, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return t.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *limitedRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if t, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return t.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *limitedRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	if t, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return t.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
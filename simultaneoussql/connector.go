@@ -0,0 +1,141 @@
+/*
+Package simultaneoussql limits the number of database/sql queries and
+statements that run at once, independently of the number of open
+connections.
+*/
+package simultaneoussql
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+// Kind is the kind of a statement, as decided by the classifier
+type Kind string
+
+// Kinds returned by the default classifier
+const (
+	Read  Kind = "read"
+	Write Kind = "write"
+)
+
+// Option configures NewConnector
+type Option func(*config)
+
+type config struct {
+	classify func(query string) Kind
+	kinds    map[Kind]simultaneous.Acquirer
+}
+
+// WithKindLimit adds a limit for statements of a kind. Such statements
+// must get space in both the limit passed to NewConnector and this one.
+// The limit may be any *simultaneous.Limit.
+func WithKindLimit(kind Kind, limit simultaneous.Acquirer) Option {
+	return func(c *config) {
+		c.kinds[kind] = limit
+	}
+}
+
+// WithClassifier replaces the function that decides the Kind of a
+// statement. The default is Classify.
+func WithClassifier(classify func(query string) Kind) Option {
+	return func(c *config) {
+		c.classify = classify
+	}
+}
+
+// Classify returns Read for statements that start with SELECT, SHOW,
+// DESCRIBE, DESC, EXPLAIN, or WITH, and Write for everything else.
+// Leading whitespace, comments, and parentheses are skipped.
+func Classify(query string) Kind {
+	q := query
+	for {
+		q = strings.TrimLeft(q, " \t\r\n(")
+		switch {
+		case strings.HasPrefix(q, "--") || strings.HasPrefix(q, "#"):
+			i := strings.IndexByte(q, '\n')
+			if i < 0 {
+				return Write
+			}
+			q = q[i+1:]
+			continue
+		case strings.HasPrefix(q, "/*"):
+			i := strings.Index(q, "*/")
+			if i < 0 {
+				return Write
+			}
+			q = q[i+2:]
+			continue
+		}
+		break
+	}
+	end := strings.IndexFunc(q, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	if end >= 0 {
+		q = q[:end]
+	}
+	switch strings.ToUpper(q) {
+	case "SELECT", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "WITH":
+		return Read
+	}
+	return Write
+}
+
+// NewConnector wraps a driver.Connector so that queries and statements
+// run through its connections need space in the Limit. The space is held
+// while a statement executes and, for queries, until the rows are
+// closed. Use the result with sql.OpenDB:
+//
+//	limit := simultaneous.New[singlestore](50)
+//	db := sql.OpenDB(simultaneoussql.NewConnector(connector, limit,
+//		simultaneoussql.WithKindLimit(simultaneoussql.Write, simultaneous.New[writes](10))))
+//
+// Preparing statements, beginning and ending transactions, and pinging
+// do not need space.
+func NewConnector[T any](base driver.Connector, limit *simultaneous.Limit[T], opts ...Option) driver.Connector {
+	c := config{
+		classify: Classify,
+		kinds:    make(map[Kind]simultaneous.Acquirer),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &connector{
+		base:   base,
+		limit:  limit,
+		config: c,
+	}
+}
+
+type connector struct {
+	base   driver.Connector
+	limit  simultaneous.Acquirer
+	config config
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &limitedConn{
+		Conn:      conn,
+		connector: c,
+	}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// acquire gets space for running the query
+func (c *connector) acquire(ctx context.Context, query string) (*simultaneous.AllLimited, error) {
+	if kind, ok := c.config.kinds[c.config.classify(query)]; ok {
+		return simultaneous.AcquireAll(ctx, c.limit, kind)
+	}
+	return simultaneous.AcquireAll(ctx, c.limit)
+}
@@ -0,0 +1,179 @@
+package simultaneoussql_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+	"github.com/singlestore-labs/simultaneous/simultaneoussql"
+)
+
+type (
+	queries struct{}
+	writes  struct{}
+)
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	for query, kind := range map[string]simultaneoussql.Kind{
+		"SELECT 1":                             simultaneoussql.Read,
+		"  select * from t":                    simultaneoussql.Read,
+		"(SELECT 1) UNION (SELECT 2)":          simultaneoussql.Read,
+		"/* hint */ SELECT 1":                  simultaneoussql.Read,
+		"-- comment\nshow tables":              simultaneoussql.Read,
+		"WITH x AS (SELECT 1) SELECT * FROM x": simultaneoussql.Read,
+		"INSERT INTO t VALUES (1)":             simultaneoussql.Write,
+		"update t set a=1":                     simultaneoussql.Write,
+		"ALTER TABLE t ADD COLUMN b int":       simultaneoussql.Write,
+		"/* unterminated":                      simultaneoussql.Write,
+	} {
+		assert.Equal(t, kind, simultaneoussql.Classify(query), query)
+	}
+}
+
+func TestConnector(t *testing.T) {
+	t.Parallel()
+
+	for _, prepared := range []bool{false, true} {
+		prepared := prepared
+		t.Run(map[bool]string{false: "direct", true: "prepared"}[prepared], func(t *testing.T) {
+			t.Parallel()
+
+			fake := &fakeConnector{prepareOnly: prepared}
+			limit := simultaneous.New[queries](3)
+			writeLimit := simultaneous.New[writes](1)
+			db := sql.OpenDB(simultaneoussql.NewConnector(fake, limit,
+				simultaneoussql.WithKindLimit(simultaneoussql.Write, writeLimit)))
+			defer db.Close()
+
+			rows, err := db.QueryContext(context.Background(), "SELECT 1")
+			require.NoError(t, err)
+			assert.Equal(t, 1, limit.InUse(), "held while rows are open")
+			assert.Equal(t, 0, writeLimit.InUse())
+			for rows.Next() {
+				var n int
+				require.NoError(t, rows.Scan(&n))
+				assert.Equal(t, 1, n)
+			}
+			require.NoError(t, rows.Close())
+			assert.Equal(t, 0, limit.InUse())
+
+			fake.delay = 20 * time.Millisecond
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := db.ExecContext(context.Background(), "INSERT INTO t VALUES (1)")
+					assert.NoError(t, err)
+				}()
+			}
+			wg.Wait()
+			assert.Equal(t, int32(1), fake.maxRunning.Load(), "writes are limited to one")
+			assert.Equal(t, 0, limit.InUse())
+			assert.Equal(t, 0, writeLimit.InUse())
+		})
+	}
+}
+
+func TestConnectorCancelled(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[queries](1)
+	db := sql.OpenDB(simultaneoussql.NewConnector(&fakeConnector{}, limit))
+	defer db.Close()
+	db.SetMaxOpenConns(2)
+	held := limit.Forever(context.Background())
+	defer held.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := db.ExecContext(ctx, "DELETE FROM t")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// fakeConnector is a driver that returns a single row with the value 1
+// for every query. If prepareOnly is set, its connections only support
+// prepared statements.
+type fakeConnector struct {
+	prepareOnly bool
+	delay       time.Duration
+	running     atomic.Int32
+	maxRunning  atomic.Int32
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	if c.prepareOnly {
+		return &fakePrepareConn{c: c}, nil
+	}
+	return &fakeConn{fakePrepareConn{c: c}}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return nil }
+
+func (c *fakeConnector) run() {
+	n := c.running.Add(1)
+	for {
+		max := c.maxRunning.Load()
+		if n <= max || c.maxRunning.CompareAndSwap(max, n) {
+			break
+		}
+	}
+	time.Sleep(c.delay)
+	c.running.Add(-1)
+}
+
+type fakePrepareConn struct{ c *fakeConnector }
+
+func (c *fakePrepareConn) Prepare(string) (driver.Stmt, error) { return &fakeStmt{c: c.c}, nil }
+func (c *fakePrepareConn) Close() error                        { return nil }
+func (c *fakePrepareConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+type fakeConn struct{ fakePrepareConn }
+
+func (c *fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	c.c.run()
+	return &fakeRows{}, nil
+}
+
+func (c *fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	c.c.run()
+	return driver.RowsAffected(1), nil
+}
+
+type fakeStmt struct{ c *fakeConnector }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.c.run()
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.c.run()
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct{ done bool }
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
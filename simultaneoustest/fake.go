@@ -0,0 +1,282 @@
+/*
+Package simultaneoustest helps test code that uses simultaneous limits
+without depending on timing. Fake is a Limiter that only grants space
+when the test says so, and Token makes a Limited for calling functions
+that require an Enforced.
+*/
+package simultaneoustest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/memsql/errors"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+// Patience is how long the Expect methods of a Fake wait for the
+// expected state before failing the test
+var Patience = 10 * time.Second
+
+// Fake is a simultaneous.Limiter that grants space only when the test
+// calls Grant. Callers wait in the order they arrive. Timeouts passed to
+// AcquireTimeout never expire on their own: call ExpireTimeouts.
+//
+//	fake := simultaneoustest.NewFake[myLimit](t)
+//	go worker(ctx, fake)
+//	fake.ExpectWaiters(1)
+//	fake.Grant()
+//	fake.ExpectHeld(0)
+type Fake[T any] struct {
+	t            testing.TB
+	lock         sync.Mutex
+	waiters      []*fakeWaiter
+	available    int
+	held         int
+	acquisitions int
+	changed      chan struct{} // closed when anything changes
+}
+
+type fakeWaiter struct {
+	ready    chan struct{} // closed when granted or timed out
+	granted  bool
+	timeout  bool // from AcquireTimeout
+	timedOut bool
+}
+
+var _ simultaneous.Limiter[any] = &Fake[any]{}
+
+// NewFake creates a Fake with no space available. The test is failed by
+// the Expect methods when their expectations are not met.
+func NewFake[T any](t testing.TB) *Fake[T] {
+	return &Fake[T]{
+		t:       t,
+		changed: make(chan struct{}),
+	}
+}
+
+// notify must be called with the lock held
+func (f *Fake[T]) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// Grant gives one unit of space to the caller that has waited the
+// longest. If no one is waiting, the next caller gets it without
+// waiting.
+func (f *Fake[T]) Grant() {
+	f.GrantN(1)
+}
+
+// GrantN is like calling Grant n times
+func (f *Fake[T]) GrantN(n int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.available += n
+	for f.available > 0 && len(f.waiters) > 0 {
+		w := f.waiters[0]
+		f.waiters = f.waiters[1:]
+		f.available--
+		f.take()
+		w.granted = true
+		close(w.ready)
+	}
+	f.notify()
+}
+
+// ExpireTimeouts makes every caller waiting in AcquireTimeout fail with
+// an error that matches simultaneous.ErrTimeout
+func (f *Fake[T]) ExpireTimeouts() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	remaining := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.timeout {
+			w.timedOut = true
+			close(w.ready)
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	f.waiters = remaining
+	f.notify()
+}
+
+// Waiters returns the number of callers currently waiting
+func (f *Fake[T]) Waiters() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return len(f.waiters)
+}
+
+// Held returns the number of units of space that have been granted and
+// not released
+func (f *Fake[T]) Held() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.held
+}
+
+// Acquisitions returns the number of times space has been granted
+func (f *Fake[T]) Acquisitions() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.acquisitions
+}
+
+// ExpectWaiters waits until exactly n callers are waiting. It fails the
+// test if that doesn't happen within Patience.
+func (f *Fake[T]) ExpectWaiters(n int) {
+	f.t.Helper()
+	f.expect(func() bool { return len(f.waiters) == n }, "%d waiters", n)
+}
+
+// ExpectHeld waits until exactly n units of space are held. It fails the
+// test if that doesn't happen within Patience.
+func (f *Fake[T]) ExpectHeld(n int) {
+	f.t.Helper()
+	f.expect(func() bool { return f.held == n }, "%d held", n)
+}
+
+// expect waits for ok, which is called with the lock held, to return true
+func (f *Fake[T]) expect(ok func() bool, format string, args ...any) {
+	f.t.Helper()
+	timer := time.NewTimer(Patience)
+	defer timer.Stop()
+	for {
+		f.lock.Lock()
+		if ok() {
+			f.lock.Unlock()
+			return
+		}
+		changed := f.changed
+		waiters, held := len(f.waiters), f.held
+		f.lock.Unlock()
+		select {
+		case <-changed:
+		case <-timer.C:
+			f.t.Fatalf("expected "+format+" but there are %d waiters and %d held after %s",
+				append(args, waiters, held, Patience)...)
+			return
+		}
+	}
+}
+
+// take must be called with the lock held
+func (f *Fake[T]) take() {
+	f.held++
+	f.acquisitions++
+}
+
+func (f *Fake[T]) release() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.held--
+	f.notify()
+}
+
+// Acquire waits for Grant or for the context to be cancelled
+func (f *Fake[T]) Acquire(ctx context.Context) (simultaneous.Limited[T], error) {
+	if err := f.wait(ctx, nil); err != nil {
+		return Token[T](), err
+	}
+	return f.token(), nil
+}
+
+// TryAcquire takes space only if a Grant is waiting to be used
+func (f *Fake[T]) TryAcquire(ctx context.Context) (simultaneous.Limited[T], bool, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.available == 0 {
+		return Token[T](), false, nil
+	}
+	f.available--
+	f.take()
+	f.notify()
+	return f.token(), true, nil
+}
+
+// AcquireTimeout waits for Grant, for the context to be cancelled, or
+// for ExpireTimeouts. The timeout itself is ignored.
+func (f *Fake[T]) AcquireTimeout(ctx context.Context, timeout time.Duration) (simultaneous.Limited[T], error) {
+	if err := f.wait(ctx, &timeout); err != nil {
+		return Token[T](), err
+	}
+	return f.token(), nil
+}
+
+// wait waits for a grant. If timeout is not nil, ExpireTimeouts makes it
+// fail.
+func (f *Fake[T]) wait(ctx context.Context, timeout *time.Duration) error {
+	f.lock.Lock()
+	if f.available > 0 {
+		f.available--
+		f.take()
+		f.notify()
+		f.lock.Unlock()
+		return nil
+	}
+	w := &fakeWaiter{
+		ready:   make(chan struct{}),
+		timeout: timeout != nil,
+	}
+	f.waiters = append(f.waiters, w)
+	f.notify()
+	f.lock.Unlock()
+
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		if w.granted {
+			// the grant goes to the next caller instead
+			f.held--
+			f.acquisitions--
+			f.available++
+		} else if !w.timedOut {
+			for i, other := range f.waiters {
+				if other == w {
+					f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+					break
+				}
+			}
+		}
+		f.notify()
+		return errors.Wrap(ctx.Err(), "simultaneoustest: context cancelled before a grant")
+	}
+	if w.timedOut {
+		return simultaneous.ErrTimeout.Errorf("simultaneoustest: timeout (%s) expired", *timeout)
+	}
+	return nil
+}
+
+func (f *Fake[T]) token() simultaneous.Limited[T] {
+	return simultaneous.Adopt[T](&fakeExternal[T]{fake: f})
+}
+
+type fakeExternal[T any] struct {
+	fake *Fake[T]
+}
+
+func (e *fakeExternal[T]) Release() { e.fake.release() }
+
+// Reacquire is used by Yield which waits for another Grant
+func (e *fakeExternal[T]) Reacquire(ctx context.Context) error {
+	return e.fake.wait(ctx, nil)
+}
+
+// Token returns a Limited that does not hold space in any limit. It is
+// for calling functions that take an Enforced or a Limited in unit
+// tests.
+func Token[T any]() simultaneous.Limited[T] {
+	return simultaneous.Adopt[T](noExternal{})
+}
+
+type noExternal struct{}
+
+func (noExternal) Release()                        {}
+func (noExternal) Reacquire(context.Context) error { return nil }
@@ -0,0 +1,111 @@
+package simultaneoustest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+	"github.com/singlestore-labs/simultaneous/simultaneoustest"
+)
+
+type testLimit struct{}
+
+func TestFake(t *testing.T) {
+	t.Parallel()
+
+	fake := simultaneoustest.NewFake[testLimit](t)
+	ctx := context.Background()
+	results := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		i := i
+		go func() {
+			done, err := fake.Acquire(ctx)
+			if !assert.NoError(t, err) {
+				return
+			}
+			results <- i
+			done.Done()
+		}()
+		fake.ExpectWaiters(i + 1)
+	}
+
+	fake.Grant()
+	assert.Equal(t, 0, <-results, "oldest first")
+	fake.ExpectWaiters(2)
+	fake.GrantN(2)
+	assert.ElementsMatch(t, []int{1, 2}, []int{<-results, <-results})
+	fake.ExpectHeld(0)
+	assert.Equal(t, 3, fake.Acquisitions())
+
+	_, ok, err := fake.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	fake.Grant()
+	done, ok, err := fake.TryAcquire(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, 1, fake.Held())
+	done.Done()
+	done.Done()
+	assert.Equal(t, 0, fake.Held())
+}
+
+func TestFakeTimeout(t *testing.T) {
+	t.Parallel()
+
+	fake := simultaneoustest.NewFake[testLimit](t)
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() {
+		_, err := fake.AcquireTimeout(ctx, time.Minute)
+		errs <- err
+	}()
+	fake.ExpectWaiters(1)
+	go func() {
+		_, err := fake.Acquire(ctx)
+		errs <- err
+	}()
+	fake.ExpectWaiters(2)
+
+	fake.ExpireTimeouts()
+	assert.ErrorIs(t, <-errs, simultaneous.ErrTimeout)
+	fake.ExpectWaiters(1)
+
+	cancel()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	fake.ExpectWaiters(0)
+	fake.ExpectHeld(0)
+}
+
+func TestFakeYield(t *testing.T) {
+	t.Parallel()
+
+	fake := simultaneoustest.NewFake[testLimit](t)
+	fake.Grant()
+	done, err := fake.Acquire(context.Background())
+	require.NoError(t, err)
+	yielded := make(chan error)
+	go func() { yielded <- done.Yield(context.Background()) }()
+	fake.ExpectWaiters(1)
+	fake.ExpectHeld(0)
+	fake.Grant()
+	require.NoError(t, <-yielded)
+	assert.Equal(t, 1, fake.Held())
+	done.Done()
+	assert.Equal(t, 0, fake.Held())
+}
+
+func requiresEnforced(simultaneous.Enforced[testLimit]) bool { return true }
+
+func TestToken(t *testing.T) {
+	t.Parallel()
+
+	token := simultaneoustest.Token[testLimit]()
+	assert.True(t, requiresEnforced(token))
+	require.NoError(t, token.Yield(context.Background()))
+	token.Done()
+}
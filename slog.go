@@ -0,0 +1,60 @@
+//go:build go1.21
+
+package simultaneous
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger returns a modified Limit that logs structured records for
+// notable events. Every record has the name of the Limit and its
+// capacity, space in use, and number of waiters.
+//
+//   - "simultaneous limit stuck" (warn) when Forever has waited longer
+//     than the stuck timeout, with "waited"
+//   - "simultaneous limit unstuck" (info) when a stuck Forever stops
+//     waiting
+//   - "simultaneous limit timeout" (info) when Timeout gives up, with
+//     "timeout"
+//   - "simultaneous limit leak" (error) when WithLeakDetection finds
+//     space that was never released, with "stack"
+//   - "simultaneous limit resize" (debug) when SetLimit changes the
+//     capacity, with "from"
+//
+// Stuck records require a stuck timeout, which is set along with the
+// callbacks by SetForeverMessaging or WithStuckMessaging. The callbacks
+// may be nil. WithLogger requires go1.21.
+func (l Limit[T]) WithLogger(logger *slog.Logger) *Limit[T] {
+	l.logger = newSlogLogger(logger)
+	return &l
+}
+
+// WithLogger logs notable events. See the WithLogger method.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *state) {
+		s.logger = newSlogLogger(logger)
+	}
+}
+
+func newSlogLogger(logger *slog.Logger) eventLogger {
+	if logger == nil {
+		return nil
+	}
+	return slogLogger{logger}
+}
+
+type slogLogger struct {
+	*slog.Logger
+}
+
+var slogLevels = map[logLevel]slog.Level{
+	logDebug: slog.LevelDebug,
+	logInfo:  slog.LevelInfo,
+	logWarn:  slog.LevelWarn,
+	logError: slog.LevelError,
+}
+
+func (s slogLogger) log(ctx context.Context, level logLevel, msg string, args ...any) {
+	s.Log(ctx, slogLevels[level], msg, args...)
+}
@@ -0,0 +1,84 @@
+//go:build go1.21
+
+package simultaneous_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+type logBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) records(t *testing.T) []map[string]any {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf logBuffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	limit := simultaneous.New[any](1,
+		simultaneous.WithName("logged"),
+		simultaneous.WithLogger(logger),
+		simultaneous.WithStuckMessaging(time.Millisecond, nil, nil))
+	ctx := context.Background()
+
+	held := limit.Forever(ctx)
+	_, err := limit.Timeout(ctx, time.Millisecond)
+	require.Error(t, err)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		held.Done()
+	}()
+	limit.Forever(ctx).Done()
+	limit.SetLimit(3)
+
+	records := buf.records(t)
+	var messages []string
+	for _, record := range records {
+		messages = append(messages, record["msg"].(string))
+		assert.Equal(t, "logged", record["limit"])
+	}
+	assert.Equal(t, []string{
+		"simultaneous limit timeout",
+		"simultaneous limit stuck",
+		"simultaneous limit unstuck",
+		"simultaneous limit resize",
+	}, messages)
+	assert.Equal(t, "WARN", records[1]["level"])
+	assert.Equal(t, float64(1), records[1]["in_use"])
+	assert.Equal(t, float64(1), records[3]["from"])
+	assert.Equal(t, float64(3), records[3]["capacity"])
+}
@@ -0,0 +1,155 @@
+package simultaneous
+
+import (
+	"context"
+	"sync"
+)
+
+// Pipeline connects Stages that each process the output of the one before
+// with their own Limit. It is like Group: the first error cancels the
+// Pipeline's context, which stops every stage, and is returned by Wait.
+//
+//	p, ctx := simultaneous.NewPipeline(ctx)
+//	paths := simultaneous.Source(p, listFiles)
+//	contents := simultaneous.NewStage(diskLimit, readFile).Run(p, paths)
+//	uploaded := simultaneous.NewStage(netLimit, upload).Run(p, contents)
+//	for name := range uploaded {
+//		log.Print("uploaded ", name)
+//	}
+//	err := p.Wait()
+type Pipeline struct {
+	parent  context.Context
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+	lock    sync.Mutex
+	errs    FanOutErrors // from stages with CollectErrors
+}
+
+// NewPipeline returns a Pipeline and a context derived from ctx. The
+// context is cancelled when a stage fails, when Stop is called, or when
+// Wait returns, whichever comes first.
+func NewPipeline(ctx context.Context) (*Pipeline, context.Context) {
+	pctx, cancel := context.WithCancel(ctx)
+	return &Pipeline{
+		parent: ctx,
+		ctx:    pctx,
+		cancel: cancel,
+	}, pctx
+}
+
+// Stop cancels the Pipeline's context so that every stage stops without
+// that being an error. Use it when the output of the last stage is no
+// longer wanted.
+func (p *Pipeline) Stop() { p.cancel() }
+
+// Wait waits for every stage and source to finish. It returns the first
+// error, if any. If there was none and ctx (as given to NewPipeline) was
+// cancelled, the context's error is returned because some inputs may not
+// have been processed. Otherwise, if stages with CollectErrors had
+// failures, their errors are returned as FanOutErrors.
+//
+// The output of the last stage must be read until it is closed, or Stop
+// called, before Wait can return.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.cancel()
+	if p.err != nil {
+		return p.err
+	}
+	if p.parent.Err() != nil {
+		return contextError(p.parent)
+	}
+	if len(p.errs) > 0 {
+		return p.errs
+	}
+	return nil
+}
+
+func (p *Pipeline) fail(err error) {
+	p.errOnce.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
+
+func (p *Pipeline) collect(err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.errs = append(p.errs, err)
+}
+
+// Source runs fn in a new goroutine to produce the input of the first
+// stage of a Pipeline. fn should send on out until it has nothing more
+// to send or ctx is cancelled. out is closed when fn returns. An error
+// from fn fails the Pipeline.
+func Source[Out any](p *Pipeline, fn func(ctx context.Context, out chan<- Out) error) <-chan Out {
+	out := make(chan Out)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(out)
+		if err := fn(p.ctx, out); err != nil {
+			p.fail(err)
+		}
+	}()
+	return out
+}
+
+// Stage is one step of a Pipeline. It runs fn for each of its inputs
+// like MapChan: each call in its own goroutine while holding space in the
+// Limit. A Stage can be Run more than once, in the same or different
+// Pipelines.
+type Stage[T any, In any, Out any] struct {
+	limit  *Limit[T]
+	fn     func(context.Context, Enforced[T], In) (Out, error)
+	opts   []FanOutOption
+	config fanOutConfig
+}
+
+// NewStage creates a Stage. Outputs are in the order of the inputs
+// unless Unordered is given. By default an error from fn fails the
+// Pipeline. With CollectErrors, inputs for which fn fails are dropped
+// and their errors are returned by Pipeline.Wait.
+func NewStage[T any, In any, Out any](limit *Limit[T], fn func(context.Context, Enforced[T], In) (Out, error), opts ...FanOutOption) *Stage[T, In, Out] {
+	s := &Stage[T, In, Out]{
+		limit: limit,
+		fn:    fn,
+		opts:  opts,
+	}
+	for _, opt := range opts {
+		opt(&s.config)
+	}
+	return s
+}
+
+// Run starts the Stage as part of a Pipeline. It reads inputs until the
+// channel is closed or the Pipeline is cancelled, and returns the channel
+// of outputs, which is closed once the Stage is finished.
+func (s *Stage[T, In, Out]) Run(p *Pipeline, inputs <-chan In) <-chan Out {
+	out := make(chan Out)
+	results := MapChan(p.ctx, s.limit, inputs, s.fn, s.opts...)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(out)
+		for r := range results {
+			if r.Err != nil {
+				if s.config.collect {
+					p.collect(r.Err)
+				} else {
+					p.fail(r.Err)
+				}
+				continue
+			}
+			select {
+			case out <- r.Value:
+			case <-p.ctx.Done():
+				// MapChan closes results promptly
+			}
+		}
+	}()
+	return out
+}
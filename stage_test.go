@@ -0,0 +1,103 @@
+package simultaneous_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/memsql/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func count(n int) func(context.Context, chan<- int) error {
+	return func(ctx context.Context, out chan<- int) error {
+		for i := 0; i < n; i++ {
+			select {
+			case out <- i:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		return nil
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	t.Parallel()
+
+	first := simultaneous.New[any](2)
+	second := simultaneous.New[string](3)
+	p, _ := simultaneous.NewPipeline(context.Background())
+	doubled := simultaneous.NewStage(first, sleepAndDouble).Run(p, simultaneous.Source(p, count(10)))
+	formatted := simultaneous.NewStage(second, func(_ context.Context, _ simultaneous.Enforced[string], in int) (string, error) {
+		return strconv.Itoa(in), nil
+	}).Run(p, doubled)
+
+	var outputs []string
+	for s := range formatted {
+		outputs = append(outputs, s)
+	}
+	require.NoError(t, p.Wait())
+	assert.Equal(t, []string{"0", "2", "4", "6", "8", "10", "12", "14", "16", "18"}, outputs)
+	assert.Zero(t, first.InUse())
+	assert.Zero(t, second.InUse())
+}
+
+func TestPipelineErrors(t *testing.T) {
+	t.Parallel()
+
+	const failed errors.String = "failed"
+	failOdd := func(_ context.Context, _ simultaneous.Enforced[any], in int) (int, error) {
+		if in%2 == 1 {
+			return 0, failed.Errorf("input %d", in)
+		}
+		return in, nil
+	}
+	limit := simultaneous.New[any](2)
+
+	p, ctx := simultaneous.NewPipeline(context.Background())
+	for range simultaneous.NewStage(limit, failOdd).Run(p, simultaneous.Source(p, count(1000))) {
+	}
+	err := p.Wait()
+	assert.ErrorIs(t, err, failed)
+	assert.Error(t, ctx.Err(), "pipeline context cancelled")
+
+	p, _ = simultaneous.NewPipeline(context.Background())
+	var outputs []int
+	for out := range simultaneous.NewStage(limit, failOdd, simultaneous.CollectErrors()).Run(p, simultaneous.Source(p, count(6))) {
+		outputs = append(outputs, out)
+	}
+	err = p.Wait()
+	var errs simultaneous.FanOutErrors
+	require.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 3)
+	assert.Equal(t, []int{0, 2, 4}, outputs)
+}
+
+func TestPipelineStop(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	p, _ := simultaneous.NewPipeline(context.Background())
+	var outputs []int
+	for out := range simultaneous.NewStage(limit, sleepAndDouble).Run(p, simultaneous.Source(p, count(1000000))) {
+		outputs = append(outputs, out)
+		if len(outputs) == 3 {
+			p.Stop()
+		}
+	}
+	require.NoError(t, p.Wait())
+	assert.Equal(t, []int{0, 2, 4}, outputs[:3])
+	assert.Zero(t, limit.InUse())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p, _ = simultaneous.NewPipeline(ctx)
+	for range simultaneous.NewStage(limit, sleepAndDouble).Run(p, simultaneous.Source(p, count(1000000))) {
+		cancel()
+	}
+	assert.ErrorIs(t, p.Wait(), context.Canceled)
+}
@@ -0,0 +1,140 @@
+package simultaneous
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the state of a Limit. It can be marshaled as
+// JSON, for example for a health endpoint. Durations are marshaled as
+// nanoseconds.
+type Stats struct {
+	Capacity      int           `json:"capacity"`      // current capacity
+	InUse         int           `json:"in_use"`        // units of space currently held
+	Waiters       int           `json:"waiters"`       // callers currently waiting for space
+	Acquisitions  uint64        `json:"acquisitions"`  // space obtained, since the Limit was created
+	Releases      uint64        `json:"releases"`      // space released by holders, since the Limit was created
+	Timeouts      uint64        `json:"timeouts"`      // gave up because the timeout expired (or TryAcquireN failed), since the Limit was created
+	TryFailures   uint64        `json:"try_failures"`  // of the Timeouts, tries without waiting that found no space, since the Limit was created
+	Cancellations uint64        `json:"cancellations"` // gave up because the context was cancelled, since the Limit was created
+	MaxWaiters    int           `json:"max_waiters"`   // most callers waiting at once, since the Limit was created
+	TotalWait     time.Duration `json:"total_wait"`    // time spent waiting by callers that obtained space, since the Limit was created
+	MaxWait       time.Duration `json:"max_wait"`      // longest wait of a caller that obtained space, since the Limit was created
+}
+
+// Stats returns a snapshot of the state of the Limit. It is shared by all
+// copies of the Limit. For a Child, it is the state of the Child.
+func (l *Limit[T]) Stats() Stats {
+	c := l.core
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if s := l.sub; s != nil {
+		return s.counters.stats(Stats{
+			Capacity: int(s.size),
+			InUse:    int(s.used),
+			Waiters:  s.waiting,
+		})
+	}
+	return c.counters.stats(Stats{
+		Capacity: int(c.size.Load()),
+		InUse:    int(c.used.Load() - c.shardFree()),
+		Waiters:  c.waiters.Len(),
+	})
+}
+
+type outcome int
+
+const (
+	outcomeAcquired outcome = iota
+	outcomeReleased
+	outcomeTimeout
+	outcomeCancelled
+)
+
+// counters are the totals for Stats. They are updated without the lock.
+type counters struct {
+	acquisitions  atomic.Uint64
+	releases      atomic.Uint64
+	timeouts      atomic.Uint64
+	tryFailures   atomic.Uint64
+	cancellations atomic.Uint64
+	maxWaiters    atomic.Int64
+	totalWait     atomic.Int64
+	maxWait       atomic.Int64
+}
+
+func (c *counters) add(outcome outcome, waited time.Duration) {
+	switch outcome {
+	case outcomeAcquired:
+		c.acquisitions.Add(1)
+		if waited > 0 {
+			c.totalWait.Add(int64(waited))
+			raise(&c.maxWait, int64(waited))
+		}
+	case outcomeReleased:
+		c.releases.Add(1)
+	case outcomeTimeout:
+		c.timeouts.Add(1)
+	case outcomeCancelled:
+		c.cancellations.Add(1)
+	}
+}
+
+func (c *counters) sawWaiters(n int) {
+	raise(&c.maxWaiters, int64(n))
+}
+
+// raise sets max to n if n is larger
+func raise(max *atomic.Int64, n int64) {
+	for {
+		current := max.Load()
+		if n <= current || max.CompareAndSwap(current, n) {
+			return
+		}
+	}
+}
+
+// stats fills in the totals
+func (c *counters) stats(s Stats) Stats {
+	s.Acquisitions = c.acquisitions.Load()
+	s.Releases = c.releases.Load()
+	s.Timeouts = c.timeouts.Load()
+	s.TryFailures = c.tryFailures.Load()
+	s.Cancellations = c.cancellations.Load()
+	s.MaxWaiters = int(c.maxWaiters.Load())
+	s.TotalWait = time.Duration(c.totalWait.Load())
+	s.MaxWait = time.Duration(c.maxWait.Load())
+	return s
+}
+
+// InUse returns the units of space currently held
+func (l *Limit[T]) InUse() int {
+	return l.Stats().InUse
+}
+
+// Waiting returns the number of callers currently waiting for space
+func (l *Limit[T]) Waiting() int {
+	return l.Stats().Waiters
+}
+
+// Capacity returns the current capacity of the Limit. It is the same
+// as Limit.
+func (l *Limit[T]) Capacity() int {
+	return int(l.capacity())
+}
+
+// Available returns the units of space that are not in use. It is zero,
+// not negative, after the capacity has been lowered below what is in use.
+func (l *Limit[T]) Available() int {
+	s := l.Stats()
+	if s.InUse >= s.Capacity {
+		return 0
+	}
+	return s.Capacity - s.InUse
+}
+
+// Saturated returns true if all of the space is in use
+func (l *Limit[T]) Saturated() bool {
+	s := l.Stats()
+	return s.InUse >= s.Capacity
+}
@@ -0,0 +1,154 @@
+package simultaneous_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](3)
+	var waits []time.Duration
+	remove := limit.AddWaitObserver(func(waited time.Duration) {
+		waits = append(waits, waited)
+	})
+
+	held, err := limit.AcquireN(context.Background(), 3)
+	require.NoError(t, err)
+	_, ok := limit.TryAcquireN(1)
+	assert.False(t, ok)
+	waiting := make(chan simultaneous.Limited[any])
+	go func() {
+		waiting <- limit.Forever(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, simultaneous.Stats{
+		Capacity:     3,
+		InUse:        3,
+		Waiters:      1,
+		Acquisitions: 1,
+		Timeouts:     1,
+		TryFailures:  1,
+		MaxWaiters:   1,
+	}, withoutWaits(limit.Stats()))
+
+	held.Done()
+	(<-waiting).Done()
+	remove()
+	limit.Forever(context.Background()).Done()
+	stats := limit.Stats()
+	assert.GreaterOrEqual(t, stats.MaxWait, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, stats.TotalWait, stats.MaxWait)
+	assert.Equal(t, simultaneous.Stats{
+		Capacity:     3,
+		InUse:        0,
+		Waiters:      0,
+		Acquisitions: 3,
+		Releases:     3,
+		Timeouts:     1,
+		TryFailures:  1,
+		MaxWaiters:   1,
+	}, withoutWaits(stats))
+	if assert.Len(t, waits, 2, "observer removed") {
+		assert.GreaterOrEqual(t, waits[1], 10*time.Millisecond, "waited")
+	}
+}
+
+func withoutWaits(stats simultaneous.Stats) simultaneous.Stats {
+	stats.TotalWait = 0
+	stats.MaxWait = 0
+	return stats
+}
+
+func TestStatsCancellations(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	held := limit.Forever(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := limit.Acquire(ctx)
+	require.Error(t, err)
+	_, err = limit.Timeout(ctx, time.Second)
+	require.Error(t, err)
+	held.Done()
+
+	stats := limit.Stats()
+	assert.Equal(t, uint64(2), stats.Cancellations)
+	assert.Equal(t, uint64(1), stats.Releases)
+
+	encoded, err := json.Marshal(stats)
+	require.NoError(t, err)
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, float64(2), decoded["cancellations"])
+	assert.Equal(t, float64(1), decoded["capacity"])
+	assert.Contains(t, decoded, "max_wait")
+}
+
+func TestStatsTryFailures(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	child := limit.Child(1)
+	held := fill(limit)
+	defer release(held)
+
+	_, ok, err := limit.TryAcquire(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, ok = limit.Try()
+	assert.False(t, ok)
+	_, ok = limit.TryAcquireN(1)
+	assert.False(t, ok)
+	_, err = limit.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+	_, ok = child.Try()
+	assert.False(t, ok)
+	// waiting that times out is not a try
+	_, err = limit.Timeout(context.Background(), time.Millisecond)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+
+	// fill stops at a failed try too
+	stats := limit.Stats()
+	assert.Equal(t, uint64(6), stats.TryFailures)
+	assert.Equal(t, uint64(7), stats.Timeouts)
+	assert.Equal(t, uint64(1), child.Stats().TryFailures)
+}
+
+func TestIntrospection(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	assert.Equal(t, 2, limit.Capacity())
+	assert.Equal(t, 0, limit.InUse())
+	assert.Equal(t, 0, limit.Waiting())
+
+	held, err := limit.AcquireN(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, limit.InUse())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	waiting := make(chan error)
+	go func() {
+		_, err := limit.Acquire(ctx)
+		waiting <- err
+	}()
+	assert.Eventually(t, func() bool { return limit.Waiting() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-waiting, context.Canceled)
+	assert.Equal(t, 0, limit.Waiting())
+
+	limit.SetLimit(5)
+	assert.Equal(t, 5, limit.Capacity())
+	held.Done()
+	assert.Equal(t, 0, limit.InUse())
+}
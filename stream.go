@@ -0,0 +1,134 @@
+package simultaneous
+
+import (
+	"context"
+	"sync"
+)
+
+// Result is an output of MapChan or the error from producing it
+type Result[Out any] struct {
+	Value Out
+	Err   error
+}
+
+// Unordered makes MapChan and MapSeq deliver results as soon as they are
+// ready rather than in the order of the inputs
+func Unordered() FanOutOption {
+	return func(c *fanOutConfig) {
+		c.unordered = true
+	}
+}
+
+// MapChan is like Map for inputs that arrive on a channel, which may be
+// unbounded. It reads inputs until the channel is closed and runs fn for
+// each, in its own goroutine while holding space in the Limit, and sends
+// the results on the returned channel. That channel is closed once all
+// results have been sent.
+//
+// Results are in the order of the inputs unless Unordered is given. In
+// order, at most the capacity of the Limit (when MapChan was called)
+// results wait for an earlier input to finish. By default, the first
+// error is the last result: fn's context is cancelled and no more
+// inputs are read. With CollectErrors, a failure is just a result with
+// an error.
+//
+// The results must be read until the channel is closed, or ctx
+// cancelled. If ctx is cancelled, the channel is closed without further
+// results, so check ctx.Err() to know if all of the inputs were
+// processed.
+func MapChan[T any, In any, Out any](ctx context.Context, limit *Limit[T], inputs <-chan In, fn func(context.Context, Enforced[T], In) (Out, error), opts ...FanOutOption) <-chan Result[Out] {
+	var config fanOutConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	out := make(chan Result[Out])
+	workCtx, stop := context.WithCancel(ctx)
+
+	// results come from slots, in order, or from unordered
+	backlog := limit.Capacity()
+	if backlog < 1 {
+		backlog = 1
+	}
+	slots := make(chan chan Result[Out], backlog)
+	unordered := make(chan Result[Out])
+
+	go func() {
+		defer close(slots)
+		var wg sync.WaitGroup
+		defer func() {
+			wg.Wait()
+			close(unordered)
+		}()
+		for {
+			var in In
+			select {
+			case next, ok := <-inputs:
+				if !ok {
+					return
+				}
+				in = next
+			case <-workCtx.Done():
+				return
+			}
+			done, err := limit.Acquire(workCtx)
+			if err != nil {
+				return
+			}
+			var slot chan Result[Out]
+			if !config.unordered {
+				slot = make(chan Result[Out], 1)
+				select {
+				case slots <- slot:
+				case <-workCtx.Done():
+					done.Done()
+					return
+				}
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := fn(workCtx, done, in)
+				done.Done()
+				if slot != nil {
+					slot <- Result[Out]{Value: value, Err: err}
+					return
+				}
+				unordered <- Result[Out]{Value: value, Err: err}
+			}()
+		}
+	}()
+
+	go func() {
+		defer close(out)
+		defer stop()
+		stopped := false
+		emit := func(r Result[Out]) {
+			if stopped {
+				return
+			}
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				stopped = true
+				return
+			}
+			if r.Err != nil && !config.collect {
+				stopped = true
+				stop()
+			}
+		}
+		if config.unordered {
+			for r := range unordered {
+				emit(r)
+			}
+			return
+		}
+		for slot := range slots {
+			emit(<-slot)
+		}
+		for range unordered {
+			// wait for the workers to finish
+		}
+	}()
+	return out
+}
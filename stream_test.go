@@ -0,0 +1,103 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/memsql/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func feed(inputs ...int) <-chan int {
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		for _, in := range inputs {
+			ch <- in
+		}
+	}()
+	return ch
+}
+
+func sleepAndDouble(_ context.Context, _ simultaneous.Enforced[any], in int) (int, error) {
+	time.Sleep(time.Duration(in) * time.Millisecond)
+	return in * 2, nil
+}
+
+func TestMapChanOrdered(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](3)
+	var outputs []int
+	for r := range simultaneous.MapChan(context.Background(), limit, feed(20, 1, 10, 2, 5), sleepAndDouble) {
+		require.NoError(t, r.Err)
+		outputs = append(outputs, r.Value)
+	}
+	assert.Equal(t, []int{40, 2, 20, 4, 10}, outputs)
+	assert.Zero(t, limit.InUse())
+}
+
+func TestMapChanUnordered(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](5)
+	var outputs []int
+	for r := range simultaneous.MapChan(context.Background(), limit, feed(30, 1, 2), sleepAndDouble, simultaneous.Unordered()) {
+		require.NoError(t, r.Err)
+		outputs = append(outputs, r.Value)
+	}
+	assert.Equal(t, 60, outputs[2], "slowest last")
+	sort.Ints(outputs)
+	assert.Equal(t, []int{2, 4, 60}, outputs)
+}
+
+func TestMapChanErrors(t *testing.T) {
+	t.Parallel()
+
+	const failed errors.String = "failed"
+	fn := func(_ context.Context, _ simultaneous.Enforced[any], in int) (int, error) {
+		if in == 2 {
+			return 0, failed.Errorf("input %d", in)
+		}
+		return in, nil
+	}
+	limit := simultaneous.New[any](1)
+
+	var results []simultaneous.Result[int]
+	for r := range simultaneous.MapChan(context.Background(), limit, feed(1, 2, 3, 4), fn) {
+		results = append(results, r)
+	}
+	require.Len(t, results, 2, "stops at the first error")
+	assert.Equal(t, 1, results[0].Value)
+	assert.ErrorIs(t, results[1].Err, failed)
+
+	results = nil
+	for r := range simultaneous.MapChan(context.Background(), limit, feed(1, 2, 3, 4), fn, simultaneous.CollectErrors()) {
+		results = append(results, r)
+	}
+	require.Len(t, results, 4)
+	assert.ErrorIs(t, results[1].Err, failed)
+	assert.Equal(t, 4, results[3].Value)
+	assert.Zero(t, limit.InUse())
+}
+
+func TestMapChanCancel(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	ctx, cancel := context.WithCancel(context.Background())
+	inputs := make(chan int)
+	results := simultaneous.MapChan(ctx, limit, inputs, sleepAndDouble)
+	inputs <- 1
+	r := <-results
+	assert.Equal(t, 2, r.Value)
+	cancel()
+	for range results {
+	}
+	assert.Zero(t, limit.InUse())
+}
@@ -0,0 +1,55 @@
+package simultaneous
+
+import (
+	"context"
+	"time"
+)
+
+// StuckInfo describes a wait that has gone on longer than the stuck
+// timeout. It is what is given to the callbacks set with WithStuckInfo.
+type StuckInfo struct {
+	Name     string        // the name given by WithName
+	Capacity int           // capacity of the Limit
+	InUse    int           // units of space in use
+	Waiters  int           // callers waiting for space, including this one when stuck
+	Waited   time.Duration // how long this caller has waited
+	Obtained bool          // for unstuck, whether the space was obtained
+}
+
+// WithStuckInfo returns a modified Limit that is like one from
+// SetForeverMessaging except that the callbacks are told about the state
+// of the Limit so they do not have to close over it to describe the wait.
+// The stuck callback is called after waiting for stuckTimeout. The
+// unstuck callback is called when the wait ends after that, whether or
+// not space was obtained. Either callback may be nil. These callbacks are
+// in addition to any set with SetForeverMessaging, which share the stuck
+// timeout.
+func (l Limit[T]) WithStuckInfo(stuckTimeout time.Duration, stuck func(context.Context, StuckInfo), unstuck func(context.Context, StuckInfo)) *Limit[T] {
+	l.stuckTimeout = stuckTimeout
+	l.stuckInfoCallback = stuck
+	l.unstuckInfoCallback = unstuck
+	return &l
+}
+
+// WithStuckInfo sets callbacks, with a description of the wait, for when
+// Forever has waited for longer than stuckTimeout. See the WithStuckInfo
+// method.
+func WithStuckInfo(stuckTimeout time.Duration, stuck func(context.Context, StuckInfo), unstuck func(context.Context, StuckInfo)) Option {
+	return func(s *state) {
+		s.stuckTimeout = stuckTimeout
+		s.stuckInfoCallback = stuck
+		s.unstuckInfoCallback = unstuck
+	}
+}
+
+func (l *Limit[T]) stuckInfo(waited time.Duration, obtained bool) StuckInfo {
+	stats := l.Stats()
+	return StuckInfo{
+		Name:     l.name,
+		Capacity: stats.Capacity,
+		InUse:    stats.InUse,
+		Waiters:  stats.Waiters,
+		Waited:   waited,
+		Obtained: obtained,
+	}
+}
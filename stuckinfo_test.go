@@ -0,0 +1,60 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestStuckInfo(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	stuck := make(chan simultaneous.StuckInfo, 1)
+	unstuck := make(chan simultaneous.StuckInfo, 1)
+	limit := simultaneous.New[any](1,
+		simultaneous.WithName("info"),
+		simultaneous.WithClock(clock),
+		simultaneous.WithStuckInfo(time.Hour,
+			func(_ context.Context, info simultaneous.StuckInfo) { stuck <- info },
+			func(_ context.Context, info simultaneous.StuckInfo) { unstuck <- info }))
+	ctx := context.Background()
+	held := limit.Forever(ctx)
+
+	got := make(chan simultaneous.Limited[any])
+	go func() { got <- limit.Forever(ctx) }()
+	require.Eventually(t, func() bool { return clock.pending() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Hour)
+	assert.Equal(t, simultaneous.StuckInfo{
+		Name:     "info",
+		Capacity: 1,
+		InUse:    1,
+		Waiters:  1,
+		Waited:   time.Hour,
+	}, <-stuck)
+
+	clock.Advance(time.Minute)
+	held.Done()
+	done := <-got
+	info := <-unstuck
+	assert.True(t, info.Obtained)
+	assert.Equal(t, time.Hour+time.Minute, info.Waited)
+	done.Done()
+
+	held = limit.Forever(ctx)
+	defer held.Done()
+	ctx, cancel := context.WithCancel(ctx)
+	go func() { got <- limit.Forever(ctx) }()
+	require.Eventually(t, func() bool { return clock.pending() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Hour)
+	<-stuck
+	cancel()
+	info = <-unstuck
+	assert.False(t, info.Obtained)
+	<-got
+}
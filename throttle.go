@@ -0,0 +1,304 @@
+package simultaneous
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Throttle combines a Limit on simultaneous runners with a limit on the
+// rate at which runners may start. The rate is enforced by a token
+// bucket: up to burst runners may start at once, and after that they
+// start at perSecond.
+//
+// Space in the Limit is obtained first and then held while waiting for
+// the rate (and any quotas added by WithQuota) to allow a start. Done
+// releases the space in the Limit; the rate is not affected by Done.
+type Throttle[T any] struct {
+	limit  *Limit[T]
+	lock   sync.Mutex
+	bucket *tokenBucket // nil if there is no rate
+	pacers []pacer
+}
+
+// pacer is something that controls when runners may start
+type pacer interface {
+	// reserve takes permission to start and returns how long to wait
+	// until it may be used. If max is not nil and the wait would be
+	// longer than *max, nothing is taken and ok is false. The returned
+	// cancel gives back permission that is not used.
+	reserve(max *time.Duration) (delay time.Duration, cancel func(), ok bool)
+}
+
+// NewThrottle creates a Throttle that allows at most limit simultaneous
+// runners and at most perSecond starts per second, with bursts of up to
+// burst starts. The options configure the Limit. The rate uses the clock
+// given with the WithClock Option.
+func NewThrottle[T any](limit int, perSecond float64, burst int, opts ...Option) *Throttle[T] {
+	l := New[T](limit, opts...)
+	bucket := newTokenBucket(perSecond, burst, l.now)
+	return &Throttle[T]{
+		limit:  l,
+		bucket: bucket,
+		pacers: []pacer{bucket},
+	}
+}
+
+// NewQuota creates a Throttle that allows at most limit simultaneous
+// runners and at most n starts in any period. It has no rate until
+// SetRate is called.
+//
+//	// at most 10 at once and at most 500 a minute
+//	quota := simultaneous.NewQuota[apiCalls](10, 500, time.Minute)
+func NewQuota[T any](limit int, n int, period time.Duration, opts ...Option) *Throttle[T] {
+	return (&Throttle[T]{
+		limit: New[T](limit, opts...),
+	}).WithQuota(n, period)
+}
+
+// WithQuota adds a quota of at most n starts in any period. The quota is
+// a sliding window: a start is allowed once fewer than n starts have
+// happened in the period before it. Quotas are in addition to the rate
+// and to each other so, for example, a per-minute quota can be combined
+// with a per-day quota. It returns the Throttle so that it can be
+// chained with NewThrottle.
+func (th *Throttle[T]) WithQuota(n int, period time.Duration) *Throttle[T] {
+	th.lock.Lock()
+	defer th.lock.Unlock()
+	th.pacers = append(th.pacers, &quotaWindow{
+		n:      n,
+		period: period,
+		now:    th.limit.now,
+	})
+	return th
+}
+
+// Base returns the Limit on simultaneous runners. Space obtained directly
+// from it is not subject to the rate.
+func (th *Throttle[T]) Base() *Limit[T] { return th.limit }
+
+// SetRate changes the rate at which runners may start
+func (th *Throttle[T]) SetRate(perSecond float64, burst int) {
+	th.lock.Lock()
+	defer th.lock.Unlock()
+	if th.bucket == nil {
+		th.bucket = newTokenBucket(perSecond, burst, th.limit.now)
+		th.pacers = append(th.pacers, th.bucket)
+		return
+	}
+	th.bucket.setRate(perSecond, burst)
+}
+
+// wait waits until all of the pacers allow a start. It returns false if
+// the context is cancelled first or if max is not nil and the wait would
+// be longer than *max.
+func (th *Throttle[T]) wait(ctx context.Context, max *time.Duration) bool {
+	th.lock.Lock()
+	pacers := th.pacers
+	th.lock.Unlock()
+
+	var delay time.Duration
+	cancels := make([]func(), 0, len(pacers))
+	cancel := func() {
+		for _, c := range cancels {
+			c()
+		}
+	}
+	for _, p := range pacers {
+		d, c, ok := p.reserve(max)
+		if !ok {
+			cancel()
+			if max == nil {
+				// nothing more will ever be allowed
+				<-ctx.Done()
+			}
+			return false
+		}
+		cancels = append(cancels, c)
+		if d > delay {
+			delay = d
+		}
+	}
+	if delay <= 0 {
+		return true
+	}
+	timer := th.limit.newTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.Chan():
+		return true
+	case <-ctx.Done():
+		cancel()
+		return false
+	}
+}
+
+// Forever is like Limit.Forever. If the context is cancelled while
+// waiting for the rate, the space in the Limit is released and Forever
+// returns.
+func (th *Throttle[T]) Forever(ctx context.Context) Limited[T] {
+	done := th.limit.Forever(ctx)
+	if _, ok := done.(*token[T]); !ok {
+		return done
+	}
+	if !th.wait(ctx, nil) {
+		done.Done()
+		return limited[T](nil)
+	}
+	return done
+}
+
+// Acquire is like Limit.Acquire. If the context is cancelled while
+// waiting for the rate, the space in the Limit is released and an error
+// wrapping ctx.Err() is returned.
+func (th *Throttle[T]) Acquire(ctx context.Context) (Limited[T], error) {
+	done, err := th.limit.Acquire(ctx)
+	if err != nil {
+		return done, err
+	}
+	if !th.wait(ctx, nil) {
+		done.Done()
+		return limited[T](nil), th.limit.cancelledError(ctx)
+	}
+	return done, nil
+}
+
+// Timeout is like Limit.Timeout. The timeout covers both waiting for
+// space in the Limit and waiting for the rate. If the rate will not
+// allow a start before the timeout, Timeout fails right away rather
+// than waiting.
+func (th *Throttle[T]) Timeout(ctx context.Context, timeout time.Duration) (Limited[T], error) {
+	start := th.limit.now()
+	done, err := th.limit.Timeout(ctx, timeout)
+	if err != nil {
+		return done, err
+	}
+	remaining := timeout - th.limit.since(start)
+	if remaining < 0 {
+		remaining = 0
+	}
+	if !th.wait(ctx, &remaining) {
+		done.Done()
+		if ctx.Err() != nil {
+			return limited[T](nil), th.limit.cancelledError(ctx)
+		}
+		return limited[T](nil), th.limit.timeoutError(timeout)
+	}
+	return done, nil
+}
+
+// tokenBucket allows perSecond events per second with bursts of burst
+// events. Tokens are taken ahead of time by reservations so that the
+// token count can be negative.
+type tokenBucket struct {
+	lock      sync.Mutex
+	perSecond float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+	now       func() time.Time
+}
+
+func newTokenBucket(perSecond float64, burst int, now func() time.Time) *tokenBucket {
+	return &tokenBucket{
+		perSecond: perSecond,
+		burst:     float64(burst),
+		tokens:    float64(burst),
+		last:      now(),
+		now:       now,
+	}
+}
+
+// refill must be called with the lock held
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.perSecond
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+func (b *tokenBucket) setRate(perSecond float64, burst int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.refill(b.now())
+	b.perSecond = perSecond
+	b.burst = float64(burst)
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+func (b *tokenBucket) reserve(max *time.Duration) (time.Duration, func(), bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.refill(b.now())
+	var delay time.Duration
+	if b.tokens < 1 {
+		if b.perSecond <= 0 {
+			return 0, nil, false
+		}
+		delay = time.Duration((1 - b.tokens) / b.perSecond * float64(time.Second))
+	}
+	if max != nil && delay > *max {
+		return 0, nil, false
+	}
+	b.tokens--
+	return delay, b.unreserve, true
+}
+
+// unreserve gives back a token that was reserved but not used
+func (b *tokenBucket) unreserve() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.refill(b.now())
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// quotaWindow allows at most n events in any period. It remembers the
+// times of the most recent events, including those reserved for the
+// future, in order.
+type quotaWindow struct {
+	lock   sync.Mutex
+	n      int
+	period time.Duration
+	times  []time.Time
+	now    func() time.Time
+}
+
+func (q *quotaWindow) reserve(max *time.Duration) (time.Duration, func(), bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.n <= 0 {
+		return 0, nil, false
+	}
+	now := q.now()
+	for len(q.times) > 0 && now.Sub(q.times[0]) >= q.period {
+		q.times = q.times[1:]
+	}
+	at := now
+	if len(q.times) >= q.n {
+		at = q.times[len(q.times)-q.n].Add(q.period)
+	}
+	delay := at.Sub(now)
+	if max != nil && delay > *max {
+		return 0, nil, false
+	}
+	q.times = append(q.times, at)
+	return delay, func() { q.unreserve(at) }, true
+}
+
+// unreserve forgets an event that was reserved but did not happen
+func (q *quotaWindow) unreserve(at time.Time) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for i := len(q.times) - 1; i >= 0; i-- {
+		if q.times[i].Equal(at) {
+			q.times = append(q.times[:i], q.times[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestThrottleRate(t *testing.T) {
+	t.Parallel()
+
+	throttle := simultaneous.NewThrottle[any](10, 20, 2)
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		done, err := throttle.Acquire(context.Background())
+		require.NoError(t, err)
+		done.Done()
+	}
+	// two from the burst and then four at 50ms each
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestThrottleConcurrency(t *testing.T) {
+	t.Parallel()
+
+	throttle := simultaneous.NewThrottle[any](1, 1000, 10)
+	held := throttle.Forever(context.Background())
+	_, err := throttle.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+	held.Done()
+	assert.Equal(t, 0, throttle.Base().InUse())
+}
+
+func TestThrottleTimeout(t *testing.T) {
+	t.Parallel()
+
+	throttle := simultaneous.NewThrottle[any](10, 1, 1)
+	done, err := throttle.Timeout(context.Background(), time.Second)
+	require.NoError(t, err)
+	done.Done()
+
+	start := time.Now()
+	_, err = throttle.Timeout(context.Background(), 100*time.Millisecond)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+	assert.Less(t, time.Since(start), 50*time.Millisecond, "fails without waiting")
+	assert.Equal(t, 0, throttle.Base().InUse(), "space released")
+
+	throttle.SetRate(1000, 1)
+	time.Sleep(5 * time.Millisecond)
+	done, err = throttle.Timeout(context.Background(), 100*time.Millisecond)
+	require.NoError(t, err)
+	done.Done()
+}
+
+func TestThrottleCancel(t *testing.T) {
+	t.Parallel()
+
+	throttle := simultaneous.NewThrottle[any](10, 0, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := throttle.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, throttle.Base().InUse())
+
+	done := throttle.Forever(ctx)
+	done.Done()
+	assert.Equal(t, 0, throttle.Base().InUse())
+}
+
+func TestQuota(t *testing.T) {
+	t.Parallel()
+
+	quota := simultaneous.NewQuota[any](2, 3, 100*time.Millisecond)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		done, err := quota.Acquire(context.Background())
+		require.NoError(t, err)
+		done.Done()
+	}
+	assert.Less(t, time.Since(start), 50*time.Millisecond, "within the quota")
+
+	_, err := quota.Timeout(context.Background(), 10*time.Millisecond)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "over the quota")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = quota.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, quota.Base().InUse())
+
+	done, err := quota.Acquire(context.Background())
+	require.NoError(t, err)
+	done.Done()
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "waited for the window")
+}
+
+func TestQuotaAndRate(t *testing.T) {
+	t.Parallel()
+
+	throttle := simultaneous.NewThrottle[any](10, 1000, 10).WithQuota(1, time.Hour)
+	done, err := throttle.Timeout(context.Background(), 0)
+	require.NoError(t, err)
+	done.Done()
+	_, err = throttle.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+
+	throttle = simultaneous.NewQuota[any](10, 100, time.Hour)
+	throttle.SetRate(0, 1)
+	done, err = throttle.Timeout(context.Background(), 0)
+	require.NoError(t, err)
+	done.Done()
+	_, err = throttle.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, "rate added to quota")
+}
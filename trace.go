@@ -0,0 +1,95 @@
+package simultaneous
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType identifies what happened in an Event
+type EventType int
+
+const (
+	EventAcquireStart EventType = iota // Forever or Timeout called
+	EventAcquireGrant                  // space obtained
+	EventRelease                       // Done called on an obtained space
+	EventTimeout                       // Timeout gave up because the timeout expired
+	EventCancel                        // gave up because the context was cancelled
+)
+
+func (e EventType) String() string {
+	switch e {
+	case EventAcquireStart:
+		return "acquire-start"
+	case EventAcquireGrant:
+		return "acquire-grant"
+	case EventRelease:
+		return "release"
+	case EventTimeout:
+		return "timeout"
+	case EventCancel:
+		return "cancel"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is an entry in the event trace of a Limit. See WithEventTrace.
+type Event struct {
+	Type EventType
+	Time time.Time
+}
+
+type eventTrace struct {
+	lock   sync.Mutex
+	events []Event
+	next   int
+	full   bool
+}
+
+// WithEventTrace returns a modified Limit that records acquisitions,
+// releases, timeouts, and cancellations. The most recent size events are
+// kept and are available from Events. Event tracing is meant for testing
+// and debugging.
+func (l Limit[T]) WithEventTrace(size int) *Limit[T] {
+	l.trace = newEventTrace(size)
+	return &l
+}
+
+func newEventTrace(size int) *eventTrace {
+	return &eventTrace{
+		events: make([]Event, size),
+	}
+}
+
+// Events returns the recorded events, oldest first. It returns nil unless
+// the Limit was created with WithEventTrace.
+func (l *Limit[T]) Events() []Event {
+	if l.trace == nil {
+		return nil
+	}
+	t := l.trace
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.full {
+		return append([]Event(nil), t.events[:t.next]...)
+	}
+	return append(append(make([]Event, 0, len(t.events)), t.events[t.next:]...), t.events[:t.next]...)
+}
+
+func (l *Limit[T]) record(eventType EventType) {
+	if l.trace == nil || len(l.trace.events) == 0 {
+		return
+	}
+	t := l.trace
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.events[t.next] = Event{
+		Type: eventType,
+		Time: l.now(),
+	}
+	t.next++
+	if t.next == len(t.events) {
+		t.next = 0
+		t.full = true
+	}
+}
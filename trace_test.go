@@ -0,0 +1,74 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func eventTypes(events []simultaneous.Event) []simultaneous.EventType {
+	types := make([]simultaneous.EventType, len(events))
+	for i, e := range events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+func TestEventTrace(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1).WithEventTrace(100)
+	done := limit.Forever(context.Background())
+	_, err := limit.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = limit.Timeout(ctx, time.Hour)
+	assert.ErrorIs(t, err, context.Canceled)
+	done.Done()
+	limit.Forever(context.Background()).Done()
+
+	events := limit.Events()
+	assert.Equal(t, []simultaneous.EventType{
+		simultaneous.EventAcquireStart,
+		simultaneous.EventAcquireGrant,
+		simultaneous.EventAcquireStart,
+		simultaneous.EventTimeout,
+		simultaneous.EventAcquireStart,
+		simultaneous.EventCancel,
+		simultaneous.EventRelease,
+		simultaneous.EventAcquireStart,
+		simultaneous.EventAcquireGrant,
+		simultaneous.EventRelease,
+	}, eventTypes(events))
+	for i := 1; i < len(events); i++ {
+		assert.False(t, events[i].Time.Before(events[i-1].Time), "event times are ordered")
+	}
+	assert.Equal(t, "acquire-grant", simultaneous.EventAcquireGrant.String())
+}
+
+func TestEventTraceBounded(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1).WithEventTrace(3)
+	limit.Forever(context.Background()).Done()
+	limit.Forever(context.Background()).Done()
+	limit.Forever(context.Background())
+	assert.Equal(t, []simultaneous.EventType{
+		simultaneous.EventRelease,
+		simultaneous.EventAcquireStart,
+		simultaneous.EventAcquireGrant,
+	}, eventTypes(limit.Events()), "only the most recent events are kept")
+}
+
+func TestEventTraceOff(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	limit.Forever(context.Background()).Done()
+	assert.Nil(t, limit.Events())
+}
@@ -0,0 +1,110 @@
+package simultaneous
+
+import (
+	"sync"
+	"time"
+)
+
+// TransferTicket carries space in a Limit from one holder to another
+// without releasing it in between. Exactly one of Claim or Release should
+// be called. Once the ticket has been claimed or released, Claim returns
+// a Limited that does not hold space and Release does nothing.
+//
+// Use a ticket when the space is obtained in one goroutine and released
+// in another, for example when a request handler starts work that is
+// completed by a callback:
+//
+//	done, err := limit.Acquire(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	ticket := done.Transfer()
+//	client.Send(request, func(response Response) {
+//		defer ticket.Claim().Done()
+//		...
+//	})
+//
+// The claimed Limited is tracked as held by the goroutine that claimed it
+// (for WithReentrancyDetection, WithDeadlockDetection, and
+// WithCycleDetection) and WithHolderTracking reports where it was claimed
+// while keeping the time it was first obtained.
+type TransferTicket[T any] struct {
+	lock      sync.Mutex
+	limit     *Limit[T]
+	n         int64
+	prio      int
+	class     string
+	onRelease func()
+	external  External
+	waited    time.Duration
+	label     string
+	acquired  time.Time
+}
+
+// Claim returns a Limited that holds the space carried by the ticket.
+// Claim can be called from any goroutine.
+func (tt *TransferTicket[T]) Claim() Limited[T] {
+	tt.lock.Lock()
+	defer tt.lock.Unlock()
+	if tt.external != nil {
+		e := tt.external
+		tt.external = nil
+		return Adopt[T](e)
+	}
+	if tt.limit == nil {
+		return limited[T](nil)
+	}
+	l := tt.limit
+	tt.limit = nil
+	t := &token[T]{
+		limit: l,
+		n:     tt.n,
+		held:  true,
+		details: &tokenDetails{
+			prio:        tt.prio,
+			class:       tt.class,
+			onRelease:   tt.onRelease,
+			waited:      tt.waited,
+			label:       tt.label,
+			acquired:    tt.acquired,
+			transferred: l.now(),
+		},
+	}
+	t.startTracking()
+	return t
+}
+
+// Release releases the space carried by an unclaimed ticket
+func (tt *TransferTicket[T]) Release() {
+	tt.Claim().Done()
+}
+
+// Transfer gives up the space held by the token without releasing it.
+// The space is carried by the returned ticket.
+func (t *token[T]) Transfer() *TransferTicket[T] {
+	if !t.held {
+		return &TransferTicket[T]{}
+	}
+	t.held = false
+	t.stopTracking()
+	d := t.get()
+	if d.watchdog != nil && !d.watchdog.release() {
+		// the space was revoked so there is nothing to carry
+		if d.onRelease != nil {
+			d.onRelease()
+		}
+		return &TransferTicket[T]{}
+	}
+	return &TransferTicket[T]{
+		limit:     t.limit,
+		n:         t.n,
+		prio:      d.prio,
+		class:     d.class,
+		onRelease: d.onRelease,
+		waited:    d.waited,
+		label:     d.label,
+		acquired:  d.acquired,
+	}
+}
+
+func (l limited[T]) Transfer() *TransferTicket[T] { return &TransferTicket[T]{} }
@@ -0,0 +1,107 @@
+package simultaneous_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+// assertFull verifies that a limit with capacity one is in use
+func assertFull(t *testing.T, limit *simultaneous.Limit[any], msg string) {
+	t.Helper()
+	_, err := limit.Timeout(context.Background(), 0)
+	assert.ErrorIs(t, err, simultaneous.ErrTimeout, msg)
+}
+
+// assertEmpty verifies that a limit with capacity one is not in use
+func assertEmpty(t *testing.T, limit *simultaneous.Limit[any], msg string) {
+	t.Helper()
+	done, err := limit.Timeout(context.Background(), 0)
+	if assert.NoError(t, err, msg) {
+		done.Done()
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	done := limit.Forever(context.Background())
+	ticket := done.Transfer()
+	assertFull(t, limit, "held by ticket")
+	done.Done()
+	assertFull(t, limit, "original Done does nothing")
+
+	claimed := make(chan simultaneous.Limited[any])
+	go func() {
+		claimed <- ticket.Claim()
+	}()
+	done2 := <-claimed
+	assertFull(t, limit, "held by claimed token")
+	ticket.Release()
+	ticket.Claim().Done()
+	assertFull(t, limit, "ticket can only be claimed once")
+
+	done2.Done()
+	assertEmpty(t, limit, "released by claimed token")
+	done2.Done()
+	done, err := limit.Timeout(context.Background(), 0)
+	require.NoError(t, err)
+	assertFull(t, limit, "claimed token does not release twice")
+	done.Done()
+}
+
+func TestTransferUnclaimed(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	ticket := limit.Forever(context.Background()).Transfer()
+	assertFull(t, limit, "held by ticket")
+	ticket.Release()
+	assertEmpty(t, limit, "released by ticket")
+	ticket.Release()
+	ticket.Claim().Done()
+
+	done := limit.Forever(context.Background())
+	assertFull(t, limit, "ticket does not release twice")
+	done.Done()
+}
+
+func TestTransferHolders(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	limit := simultaneous.New[any](1, simultaneous.WithHolderTracking(), simultaneous.WithClock(clock))
+	acquiredAt := clock.Now()
+	ticket := limit.Forever(context.Background()).Transfer()
+	clock.Advance(time.Second)
+
+	claimed := make(chan simultaneous.Limited[any])
+	go func() {
+		claimed <- claimInCallback(ticket)
+	}()
+	done := <-claimed
+	clock.Advance(time.Second)
+
+	holders := limit.Holders()
+	require.Len(t, holders, 1)
+	assert.Equal(t, acquiredAt, holders[0].Acquired, "keeps when the space was first obtained")
+	assert.Equal(t, acquiredAt.Add(time.Second), holders[0].Transferred)
+	assert.Contains(t, string(holders[0].Stack), "claimInCallback")
+
+	var buf bytes.Buffer
+	require.NoError(t, limit.DumpHolders(&buf))
+	assert.Contains(t, buf.String(), "held for 2s, claimed from a transfer 1s ago")
+	done.Done()
+	assert.Empty(t, limit.Holders())
+}
+
+func claimInCallback(ticket *simultaneous.TransferTicket[any]) simultaneous.Limited[any] {
+	return ticket.Claim()
+}
@@ -0,0 +1,18 @@
+package simultaneous
+
+import (
+	"context"
+)
+
+// Try takes space in the Limit if it is available right away and returns
+// false if it is not. It is for loops where skipping work when the Limit
+// is busy is normal: unlike Timeout with a timeout of zero, it does not
+// create an error when there is no space.
+//
+//	if done, ok := limit.Try(); ok {
+//		defer done.Done()
+//		...
+//	}
+func (l *Limit[T]) Try() (Limited[T], bool) {
+	return l.try(context.Background(), 1)
+}
@@ -0,0 +1,39 @@
+package simultaneous_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestTry(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	done, ok := limit.Try()
+	require.True(t, ok)
+	_, ok = limit.Try()
+	assert.False(t, ok)
+	done.Done()
+	done, ok = limit.Try()
+	require.True(t, ok)
+	done.Done()
+
+	stats := limit.Stats()
+	assert.Equal(t, uint64(2), stats.Acquisitions)
+	assert.Equal(t, uint64(1), stats.Timeouts)
+}
+
+func TestTryNoAllocations(t *testing.T) {
+	limit := simultaneous.New[any](1)
+	held, ok := limit.Try()
+	require.True(t, ok)
+	defer held.Done()
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = limit.Try()
+	})
+	assert.Zero(t, allocs, "Try when busy")
+}
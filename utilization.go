@@ -0,0 +1,38 @@
+package simultaneous
+
+import (
+	"context"
+
+	"github.com/memsql/errors"
+)
+
+// ErrBadFraction is returned by WaitForUtilization when the fraction is
+// not more than zero and at most one
+var ErrBadFraction errors.String = "utilization fraction must be more than zero and at most one"
+
+// WaitForUtilization waits, without taking any space itself, until at
+// least fraction of the capacity of the Limit is in use. It can be used
+// to scale up before the Limit is saturated. Like Pressure, which it is
+// built on, the capacity is what can be granted right now and, for a
+// Child, it is the utilization of the Limit it was made from. If the
+// context is cancelled first, an error wrapping ctx.Err() is returned.
+func (l *Limit[T]) WaitForUtilization(ctx context.Context, fraction float64) error {
+	if !(fraction > 0 && fraction <= 1) {
+		return ErrBadFraction.Errorf("utilization fraction %v is not in (0, 1]", fraction)
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// with latch, a crossing is not lost if utilization drops again
+	// before the event is read
+	events := l.watchPressure(watchCtx, &pressureWatcher{
+		watermarks: PressureWatermarks{HighUtilization: fraction},
+		latch:      true,
+		events:     make(chan PressureEvent, 1),
+	})
+	for event := range events {
+		if event.Pressured {
+			return nil
+		}
+	}
+	return errors.Wrapf(contextError(ctx), "context cancelled before %v of simultaneous limit (of %d) was in use (%d in use)", fraction, l.capacity(), l.InUse())
+}
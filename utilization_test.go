@@ -0,0 +1,65 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestWaitForUtilization(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](4)
+	result := make(chan error, 1)
+	go func() {
+		result <- limit.WaitForUtilization(context.Background(), 0.75)
+	}()
+	notYet := func(msg string) {
+		select {
+		case err := <-result:
+			require.Failf(t, "returned early", "%s: %v", msg, err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	var held []simultaneous.Limited[any]
+	defer func() { release(held) }()
+	notYet("idle")
+	held = append(held, limit.Forever(context.Background()))
+	notYet("one of four")
+	held = append(held, limit.Forever(context.Background()))
+	notYet("two of four")
+	held = append(held, limit.Forever(context.Background()))
+	select {
+	case err := <-result:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "did not return at three of four")
+	}
+
+	assert.NoError(t, limit.WaitForUtilization(context.Background(), 0.5), "already reached")
+}
+
+func TestWaitForUtilizationCancel(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](4)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := limit.WaitForUtilization(ctx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWaitForUtilizationFraction(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](4)
+	for _, fraction := range []float64{0, -0.5, 1.5} {
+		assert.ErrorIs(t, limit.WaitForUtilization(context.Background(), fraction), simultaneous.ErrBadFraction, "%v", fraction)
+	}
+}
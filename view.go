@@ -0,0 +1,33 @@
+package simultaneous
+
+// View is a read-only view of a Limit. It can be handed to monitoring
+// code that should watch the Limit but never obtain space in it or change
+// it. Its methods are the same as those of Limit.
+type View interface {
+	Stats() Stats
+	InUse() int
+	Available() int
+	Capacity() int
+	Waiting() int
+	Saturated() bool
+}
+
+// View returns a read-only view of the Limit. The view cannot be type
+// asserted back to the Limit or to anything that can obtain space.
+func (l *Limit[T]) View() View {
+	return view[T]{limit: l}
+}
+
+// view wraps the Limit so that only the methods of View are exposed
+type view[T any] struct {
+	limit *Limit[T]
+}
+
+var _ View = view[any]{}
+
+func (v view[T]) Stats() Stats    { return v.limit.Stats() }
+func (v view[T]) InUse() int      { return v.limit.InUse() }
+func (v view[T]) Available() int  { return v.limit.Available() }
+func (v view[T]) Capacity() int   { return v.limit.Capacity() }
+func (v view[T]) Waiting() int    { return v.limit.Waiting() }
+func (v view[T]) Saturated() bool { return v.limit.Saturated() }
@@ -0,0 +1,50 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestView(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	view := limit.View()
+	assert.Equal(t, 2, view.Capacity())
+	assert.Equal(t, 2, view.Available())
+	assert.False(t, view.Saturated())
+
+	a := limit.Forever(context.Background())
+	b := limit.Forever(context.Background())
+	assert.Equal(t, 2, view.InUse())
+	assert.Equal(t, 0, view.Available())
+	assert.True(t, view.Saturated())
+	assert.Equal(t, 0, view.Waiting())
+	assert.Equal(t, uint64(2), view.Stats().Acquisitions)
+
+	limit.SetLimit(1)
+	assert.Equal(t, 0, view.Available(), "not negative below the capacity")
+	a.Done()
+	b.Done()
+	assert.Equal(t, 1, view.Available())
+}
+
+func TestViewReadOnly(t *testing.T) {
+	t.Parallel()
+
+	var view any = simultaneous.New[any](1).View()
+	_, ok := view.(*simultaneous.Limit[any])
+	assert.False(t, ok, "not the Limit")
+	_, ok = view.(simultaneous.Limiter[any])
+	assert.False(t, ok, "cannot acquire")
+	_, ok = view.(interface {
+		Forever(context.Context) simultaneous.Limited[any]
+	})
+	assert.False(t, ok, "cannot wait for space")
+	_, ok = view.(interface{ SetLimit(int) })
+	assert.False(t, ok, "cannot be reconfigured")
+}
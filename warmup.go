@@ -0,0 +1,144 @@
+package simultaneous
+
+import (
+	"math"
+	"time"
+)
+
+// Ramp is the shape of the warm-up set by WithWarmUp
+type Ramp int
+
+const (
+	// RampLinear raises the capacity by the same amount over each part
+	// of the warm-up
+	RampLinear Ramp = iota
+	// RampExponential multiplies the capacity by the same factor over
+	// each part of the warm-up, so it stays low for longer and then
+	// rises quickly
+	RampExponential
+)
+
+// warmUp is configured by WithWarmUp. Except for the settings, it is
+// protected by the lock of the core.
+type warmUp struct {
+	start    int64
+	duration time.Duration
+	ramp     Ramp
+
+	began time.Time // zero until first used
+	timer Timer     // grants when the capacity next rises, if waiting
+}
+
+// WithWarmUp makes the Limit start with a capacity of start and ramp up
+// to its full capacity over duration, so that a cold downstream is not
+// hit with full concurrency all at once. The warm-up begins when the
+// Limit is first used and begins again each time the Limit is resumed
+// after Pause. While warming up, space is only granted within the
+// ramped capacity; Stats and Limit report the full capacity.
+//
+// WithWarmUp changes the Limit and all of its copies. It returns the
+// Limit so that it can be chained with New.
+func (l *Limit[T]) WithWarmUp(start int, duration time.Duration, ramp Ramp) *Limit[T] {
+	l.core.lock.Lock()
+	defer l.core.lock.Unlock()
+	l.core.setWarmUp(start, duration, ramp)
+	return l
+}
+
+// WithWarmUp ramps the capacity up from start. See the WithWarmUp method.
+func WithWarmUp(start int, duration time.Duration, ramp Ramp) Option {
+	return func(s *state) {
+		s.core.setWarmUp(start, duration, ramp)
+	}
+}
+
+// setWarmUp must be called with the lock held
+func (c *core) setWarmUp(start int, duration time.Duration, ramp Ramp) {
+	c.warmConfig = &warmUp{
+		start:    int64(start),
+		duration: duration,
+		ramp:     ramp,
+	}
+	c.restartWarmUp()
+}
+
+// restartWarmUp begins the warm-up again, if there is one. Must be
+// called with the lock held.
+func (c *core) restartWarmUp() {
+	if old := c.warm.Load(); old != nil && old.timer != nil {
+		old.timer.Stop()
+	}
+	if c.warmConfig != nil {
+		w := *c.warmConfig
+		c.warm.Store(&w)
+	}
+	c.updateSlow()
+}
+
+// warmSize returns the capacity allowed by the warm-up, which is less
+// than size while warming up. Must be called with the lock held.
+func (c *core) warmSize(w *warmUp, size int64) int64 {
+	now := c.now()
+	if w.began.IsZero() {
+		w.began = now
+	}
+	elapsed := now.Sub(w.began)
+	if elapsed >= w.duration || w.start >= size {
+		c.warm.Store(nil)
+		c.updateSlow()
+		return size
+	}
+	frac := float64(elapsed) / float64(w.duration)
+	n := w.rampedSize(size, frac)
+	if n >= size {
+		return size
+	}
+	if w.timer == nil && c.waiting.Load() > 0 {
+		// grant when the capacity next rises
+		at := w.began.Add(time.Duration(w.fracFor(size, n+1) * float64(w.duration)))
+		d := at.Sub(now)
+		if d <= 0 {
+			d = time.Millisecond
+		}
+		w.timer = c.afterFunc(d, func() {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			w.timer = nil
+			if c.warm.Load() == w {
+				c.grant()
+			}
+		})
+	}
+	return n
+}
+
+// rampedSize is the capacity when frac of the warm-up has passed
+func (w *warmUp) rampedSize(size int64, frac float64) int64 {
+	start := float64(w.start)
+	if start < 1 {
+		start = 1
+	}
+	var n float64
+	switch w.ramp {
+	case RampExponential:
+		n = start * math.Pow(float64(size)/start, frac)
+	default:
+		n = start + (float64(size)-start)*frac
+	}
+	return int64(math.Floor(n + 1e-9))
+}
+
+// fracFor is the inverse of rampedSize: the fraction of the warm-up
+// after which the capacity is n
+func (w *warmUp) fracFor(size int64, n int64) float64 {
+	start := float64(w.start)
+	if start < 1 {
+		start = 1
+	}
+	switch w.ramp {
+	case RampExponential:
+		return math.Log(float64(n)/start) / math.Log(float64(size)/start)
+	default:
+		return (float64(n) - start) / (float64(size) - start)
+	}
+}
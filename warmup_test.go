@@ -0,0 +1,85 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+// fill takes as much space as is available right away
+func fill(limit *simultaneous.Limit[any]) []simultaneous.Limited[any] {
+	var held []simultaneous.Limited[any]
+	for {
+		done, ok := limit.TryAcquireN(1)
+		if !ok {
+			return held
+		}
+		held = append(held, done)
+	}
+}
+
+func release(held []simultaneous.Limited[any]) {
+	for _, done := range held {
+		done.Done()
+	}
+}
+
+func TestWarmUpLinear(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	limit := simultaneous.New[any](10,
+		simultaneous.WithClock(clock),
+		simultaneous.WithWarmUp(2, 8*time.Second, simultaneous.RampLinear))
+
+	held := fill(limit)
+	assert.Len(t, held, 2, "starts at 2")
+	assert.Equal(t, 10, limit.Limit())
+
+	got := make(chan simultaneous.Limited[any])
+	go func() {
+		done, err := limit.Acquire(context.Background())
+		assert.NoError(t, err)
+		got <- done
+	}()
+	require.Eventually(t, func() bool { return limit.Waiting() == 1 && clock.pending() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Second)
+	held = append(held, <-got)
+	assert.Equal(t, 3, limit.InUse(), "one more each second")
+
+	clock.Advance(3 * time.Second)
+	more := fill(limit)
+	assert.Len(t, more, 3, "6 halfway")
+	held = append(held, more...)
+
+	clock.Advance(4 * time.Second)
+	more = fill(limit)
+	assert.Len(t, more, 4, "all 10 once warmed up")
+	release(append(held, more...))
+
+	limit.Pause()
+	limit.Resume()
+	held = fill(limit)
+	assert.Len(t, held, 2, "warms up again after Resume")
+	release(held)
+}
+
+func TestWarmUpExponential(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	limit := simultaneous.New[any](16, simultaneous.WithClock(clock)).
+		WithWarmUp(1, 4*time.Second, simultaneous.RampExponential)
+
+	for _, want := range []int{1, 2, 4, 8, 16} {
+		held := fill(limit)
+		assert.Len(t, held, want)
+		release(held)
+		clock.Advance(time.Second)
+	}
+}
@@ -0,0 +1,82 @@
+package simultaneous
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// maxHold is configured by WithMaxHold
+type maxHold struct {
+	max      time.Duration
+	callback func(HolderInfo)
+	revoke   bool
+}
+
+// watchdog watches one token that has been held too long
+type watchdog struct {
+	timer    Timer
+	released atomic.Bool // the space has been released, by the holder or by revoking it
+}
+
+// release marks the space as released. It returns false if it already was.
+func (w *watchdog) release() bool {
+	return w.released.CompareAndSwap(false, true)
+}
+
+// WithMaxHold returns a modified Limit that watches for space that is held
+// for longer than max. The callback, if not nil, is called with a
+// description of the holder, including where it got the space. If revoke is
+// true, the space is then released so that others can use it. The holder is
+// not told: it must still call Done, which does not release the space a
+// second time.
+//
+// Watching records a stack trace for every acquisition so it is relatively
+// expensive. The callback is called on its own goroutine.
+func (l Limit[T]) WithMaxHold(max time.Duration, callback func(HolderInfo), revoke bool) *Limit[T] {
+	l.maxHold = &maxHold{
+		max:      max,
+		callback: callback,
+		revoke:   revoke,
+	}
+	return &l
+}
+
+// WithMaxHold watches for space that is held too long. See the
+// WithMaxHold method.
+func WithMaxHold(max time.Duration, callback func(HolderInfo), revoke bool) Option {
+	return func(s *state) {
+		s.maxHold = &maxHold{
+			max:      max,
+			callback: callback,
+			revoke:   revoke,
+		}
+	}
+}
+
+// watchHold starts the watchdog for the token, if there is a max hold
+func (t *token[T]) watchHold() {
+	mh := t.limit.maxHold
+	if mh == nil {
+		return
+	}
+	info := *t.holderInfo()
+	d := t.more()
+	l, n, class, unhold := t.limit, t.n, d.class, d.unhold
+	w := &watchdog{}
+	w.timer = t.limit.afterFunc(mh.max, func() {
+		if w.released.Load() {
+			return
+		}
+		if mh.callback != nil {
+			callObserver(func() { mh.callback(info) })
+		}
+		if mh.revoke && w.release() {
+			if unhold != nil {
+				unhold()
+			}
+			l.record(EventRelease)
+			l.core.release(n, class, l.sub)
+		}
+	})
+	d.watchdog = w
+}
@@ -0,0 +1,56 @@
+package simultaneous_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func holdTooLong(limit *simultaneous.Limit[any]) simultaneous.Limited[any] {
+	return limit.Forever(context.Background())
+}
+
+func TestMaxHold(t *testing.T) {
+	t.Parallel()
+
+	flagged := make(chan simultaneous.HolderInfo, 10)
+	limit := simultaneous.New[any](1, simultaneous.WithMaxHold(10*time.Millisecond, func(h simultaneous.HolderInfo) {
+		flagged <- h
+	}, false))
+
+	// released in time
+	limit.Forever(context.Background()).Done()
+
+	done := holdTooLong(limit)
+	h := <-flagged
+	assert.Contains(t, string(h.Stack), "holdTooLong")
+	assert.Equal(t, int64(1), h.Units)
+	assert.Equal(t, 1, limit.InUse(), "not revoked")
+	done.Done()
+	assert.Equal(t, 0, limit.InUse())
+	assert.Empty(t, flagged)
+}
+
+func TestMaxHoldRevoke(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1).WithMaxHold(10*time.Millisecond, nil, true)
+	done := limit.Forever(context.Background())
+	other := limit.Forever(context.Background())
+	assert.Equal(t, 1, limit.InUse(), "revoked and given to other")
+
+	done.Done()
+	assert.Equal(t, 1, limit.InUse(), "Done after revoke does not release again")
+	other.Done()
+	assert.Equal(t, 0, limit.InUse())
+
+	done = limit.Forever(context.Background())
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 0, limit.InUse())
+	done.Transfer().Claim().Done()
+	assert.Equal(t, 0, limit.InUse(), "nothing carried by transfer after revoke")
+}
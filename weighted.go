@@ -0,0 +1,56 @@
+package simultaneous
+
+import (
+	"context"
+	"time"
+
+	"github.com/memsql/errors"
+)
+
+// AcquireN is like Acquire except that it takes n units of space in
+// the Limit rather than one. Done releases all n units.
+//
+// Space is granted to waiters in the order they arrived, but a waiter
+// that wants more than what is currently available does not stop smaller
+// requests that fit from being granted. WithFIFO and
+// WithAccumulatingReservation change that. A request for more than the
+// capacity of the Limit fails right away with an error wrapping
+// ErrExceedsCapacity, as does a waiting request once SetLimit lowers the
+// capacity below it. A capacity of zero is not too small: requests wait
+// for it to be raised.
+//
+// AcquireN panics if n is less than one.
+func (l *Limit[T]) AcquireN(ctx context.Context, n int64) (Limited[T], error) {
+	checkAmount(n)
+	done, _, err := l.acquire(ctx, n, 0, "")
+	return done, err
+}
+
+// TryAcquireN takes n units of space in the Limit if they are available
+// without waiting. It returns false if they are not. Done releases all
+// n units. Like AcquireN, it panics if n is less than one.
+func (l *Limit[T]) TryAcquireN(n int64) (Limited[T], bool) {
+	checkAmount(n)
+	return l.try(context.Background(), n)
+}
+
+// checkAmount panics if n units is not something that can be taken:
+// taking none would hold nothing and taking less would add capacity
+func checkAmount(n int64) {
+	if n < 1 {
+		panic(errors.Errorf("amount of space (%d) to take from a simultaneous limit is less than one", n))
+	}
+}
+
+// try implements TryAcquireN. It does not allocate when the space is
+// not available.
+func (l *Limit[T]) try(ctx context.Context, n int64) (Limited[T], bool) {
+	l.record(EventAcquireStart)
+	if !l.core.tryAcquire(n, l.sub) {
+		l.record(EventTimeout)
+		l.core.countTryFailure(l.sub)
+		l.gaveUp(ctx, time.Time{})
+		return limited[T](nil), false
+	}
+	return l.acquired(ctx, n, 0, "", time.Time{}), true
+}
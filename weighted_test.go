@@ -0,0 +1,149 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestAcquireN(t *testing.T) {
+	t.Parallel()
+
+	const capacity = 10
+	limit := simultaneous.New[any](capacity)
+	var inUse atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		n := int64(i%4 + 1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := limit.AcquireN(context.Background(), n)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.LessOrEqual(t, inUse.Add(n), int64(capacity))
+			time.Sleep(sleep)
+			inUse.Add(-n)
+			done.Done()
+		}()
+	}
+	wg.Wait()
+
+	done, ok := limit.TryAcquireN(capacity)
+	require.True(t, ok, "all released")
+	done.Done()
+}
+
+func TestTryAcquireN(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](4)
+	big, ok := limit.TryAcquireN(3)
+	require.True(t, ok)
+	_, ok = limit.TryAcquireN(2)
+	assert.False(t, ok, "only one left")
+	small, ok := limit.TryAcquireN(1)
+	require.True(t, ok)
+	_, ok = limit.TryAcquireN(1)
+	assert.False(t, ok, "full")
+
+	big.Done()
+	big.Done()
+	medium, ok := limit.TryAcquireN(3)
+	require.True(t, ok, "big released all three")
+	_, ok = limit.TryAcquireN(1)
+	assert.False(t, ok, "released only once")
+	medium.Done()
+	small.Done()
+}
+
+func TestAcquireNWaits(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](4)
+	held, ok := limit.TryAcquireN(3)
+	require.True(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := limit.AcquireN(ctx, 2)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// a waiter that doesn't fit does not block a smaller one that does
+	bigDone := make(chan simultaneous.Limited[any])
+	go func() {
+		done, err := limit.AcquireN(context.Background(), 4)
+		assert.NoError(t, err)
+		bigDone <- done
+	}()
+	time.Sleep(10 * time.Millisecond)
+	small, ok := limit.TryAcquireN(1)
+	require.True(t, ok, "small request skips ahead")
+	small.Done()
+	held.Done()
+	(<-bigDone).Done()
+
+	done, ok := limit.TryAcquireN(4)
+	require.True(t, ok)
+	done.Done()
+}
+
+func TestSetLimit(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	assert.Equal(t, 1, limit.Limit())
+	first := limit.Forever(context.Background())
+
+	waiting := make(chan simultaneous.Limited[any])
+	for i := 0; i < 2; i++ {
+		go func() {
+			waiting <- limit.Forever(context.Background())
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	limit.SetLimit(3)
+	assert.Equal(t, 3, limit.Limit())
+	second := <-waiting
+	third := <-waiting
+	_, ok := limit.TryAcquireN(1)
+	assert.False(t, ok, "full at new limit")
+
+	limit.SetLimit(1)
+	assert.Equal(t, 1, limit.Limit())
+	first.Done()
+	_, ok = limit.TryAcquireN(1)
+	assert.False(t, ok, "still above the lowered limit")
+	second.Done()
+	_, ok = limit.TryAcquireN(1)
+	assert.False(t, ok, "at the lowered limit")
+	third.Done()
+	done, ok := limit.TryAcquireN(1)
+	require.True(t, ok, "below the lowered limit")
+	_, ok = limit.TryAcquireN(1)
+	assert.False(t, ok)
+	done.Done()
+}
+
+func TestAcquireNAmount(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](4)
+	for _, n := range []int64{0, -5} {
+		assert.Panics(t, func() { _, _ = limit.AcquireN(context.Background(), n) }, "AcquireN(%d)", n)
+		assert.Panics(t, func() { _, _ = limit.TryAcquireN(n) }, "TryAcquireN(%d)", n)
+		assert.Panics(t, func() { limit.LimiterN(n) }, "LimiterN(%d)", n)
+	}
+	assert.Equal(t, 0, limit.InUse())
+	done, ok := limit.TryAcquireN(4)
+	require.True(t, ok, "capacity unchanged")
+	done.Done()
+}
@@ -0,0 +1,89 @@
+package simultaneous
+
+import (
+	"context"
+	"sync"
+
+	"github.com/memsql/errors"
+)
+
+// Workers runs submitted tasks on long-lived goroutines, one for each
+// unit of capacity the Limit had when NewWorkers was called. For many
+// small tasks this is cheaper than starting a goroutine for each and
+// having it wait for space in the Limit.
+//
+// Each task holds space in the Limit while it runs so the Limit can be
+// shared with other callers. Lowering the capacity with SetLimit is
+// respected; raising it does not add workers.
+type Workers[T any] struct {
+	limit  *Limit[T]
+	tasks  chan func(Enforced[T])
+	lock   sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewWorkers starts the workers. Up to queue submitted tasks wait for a
+// worker to be free. With a queue of zero, Submit waits until a worker
+// takes the task.
+func NewWorkers[T any](limit *Limit[T], queue int) *Workers[T] {
+	w := &Workers[T]{
+		limit: limit,
+		tasks: make(chan func(Enforced[T]), queue),
+	}
+	n := limit.Capacity()
+	if n < 1 {
+		n = 1
+	}
+	w.wg.Add(n)
+	for i := 0; i < n; i++ {
+		go w.work()
+	}
+	return w
+}
+
+func (w *Workers[T]) work() {
+	defer w.wg.Done()
+	for fn := range w.tasks {
+		w.run(fn)
+	}
+}
+
+func (w *Workers[T]) run(fn func(Enforced[T])) {
+	// a submitted task is always run; if the Limit turns the worker away
+	// the number of workers still bounds how many tasks run at once
+	done := w.limit.Forever(context.Background())
+	defer done.Done()
+	fn(done)
+}
+
+// Submit queues fn to be run by a worker. It waits for room in the queue
+// or for the context to be cancelled, in which case an error wrapping
+// ctx.Err() is returned and fn is not run. After Close, Submit returns an
+// error wrapping ErrClosed.
+func (w *Workers[T]) Submit(ctx context.Context, fn func(Enforced[T])) error {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	if w.closed {
+		return ErrClosed.Errorf("simultaneous workers are closed")
+	}
+	select {
+	case w.tasks <- fn:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(contextError(ctx), "context cancelled before simultaneous workers accepted task")
+	}
+}
+
+// Close stops accepting tasks and waits for the tasks already submitted
+// to finish. Submit calls that are in progress finish first. Close may be
+// called more than once.
+func (w *Workers[T]) Close() {
+	w.lock.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.tasks)
+	}
+	w.lock.Unlock()
+	w.wg.Wait()
+}
@@ -0,0 +1,75 @@
+package simultaneous_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/simultaneous"
+)
+
+func TestWorkers(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](3)
+	workers := simultaneous.NewWorkers(limit, 10)
+	var ran, running, most atomic.Int32
+	for i := 0; i < 50; i++ {
+		require.NoError(t, workers.Submit(context.Background(), func(simultaneous.Enforced[any]) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := most.Load()
+				if n <= m || most.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			ran.Add(1)
+		}))
+	}
+	workers.Close()
+	assert.Equal(t, int32(50), ran.Load(), "close waits for submitted tasks")
+	assert.Equal(t, int32(3), most.Load())
+	assert.Zero(t, limit.InUse())
+
+	err := workers.Submit(context.Background(), func(simultaneous.Enforced[any]) {})
+	assert.ErrorIs(t, err, simultaneous.ErrClosed)
+	workers.Close()
+}
+
+func TestWorkersSubmitCancel(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](1)
+	workers := simultaneous.NewWorkers(limit, 0)
+	defer workers.Close()
+	release := make(chan struct{})
+	require.NoError(t, workers.Submit(context.Background(), func(simultaneous.Enforced[any]) { <-release }))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := workers.Submit(ctx, func(simultaneous.Enforced[any]) { t.Error("should not run") })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	close(release)
+}
+
+func TestWorkersShareLimit(t *testing.T) {
+	t.Parallel()
+
+	limit := simultaneous.New[any](2)
+	held := limit.Forever(context.Background())
+	workers := simultaneous.NewWorkers(limit, 1)
+	var ran atomic.Int32
+	require.NoError(t, workers.Submit(context.Background(), func(simultaneous.Enforced[any]) { ran.Add(1) }))
+	require.NoError(t, workers.Submit(context.Background(), func(simultaneous.Enforced[any]) { ran.Add(1) }))
+	time.Sleep(10 * time.Millisecond)
+	assert.LessOrEqual(t, limit.InUse(), 2)
+	held.Done()
+	workers.Close()
+	assert.Equal(t, int32(2), ran.Load())
+}